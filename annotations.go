@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// the analyst notes loaded via `--notes`, keyed by "METHOD URL" or, for notes that apply to all methods, by the URL
+var annotations map[string]annotation
+
+// an analyst's note on a URL, e.g. the disposition "false-positive" and a comment why
+type annotation struct {
+	Disposition string `json:"disposition,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+func (a annotation) String() string {
+	switch {
+	case a.Disposition == "":
+		return a.Comment
+	case a.Comment == "":
+		return a.Disposition
+	default:
+		return a.Disposition + ": " + a.Comment
+	}
+}
+
+// reads a notes file. Every line contains a URL, optionally preceded by the method (without one, the note applies to
+// all methods), and the note after "=>". The note starts with a single-word disposition, optionally followed by a
+// comment after ":", e.g.
+//
+//	GET https://example.com/admin => confirmed: the user role can list all accounts
+//	https://example.com/profile => false-positive: only shows the public profile
+//
+// A note that doesn't start with a single word is only a comment. Empty lines and lines starting with `#` are skipped
+func loadAnnotations(path string) (map[string]annotation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	notes := make(map[string]annotation)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, note, err := parseAnnotationLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid note in line %d: %w", lineNumber, err)
+		}
+		notes[key] = note
+	}
+
+	return notes, scanner.Err()
+}
+
+// parses a line of the notes file into the key ("METHOD URL" or the URL) and the note
+func parseAnnotationLine(line string) (string, annotation, error) {
+	target, note, found := strings.Cut(line, "=>")
+	fields := strings.Fields(target)
+	if !found || len(fields) == 0 || len(fields) > 2 {
+		return "", annotation{}, fmt.Errorf("expected \"[<method>] <URL> => <note>\" but got %s", line)
+	}
+
+	key := fields[0]
+	if len(fields) == 2 {
+		key = strings.ToUpper(fields[0]) + " " + fields[1]
+	}
+
+	return key, parseAnnotation(strings.TrimSpace(note)), nil
+}
+
+// splits a note into the disposition and the comment
+func parseAnnotation(note string) annotation {
+	disposition, comment, found := strings.Cut(note, ":")
+	if !found {
+		disposition, comment = note, ""
+	}
+
+	disposition = strings.TrimSpace(disposition)
+	if disposition == "" || strings.ContainsAny(disposition, " \t") {
+		return annotation{Comment: note}
+	}
+
+	return annotation{Disposition: strings.ToLower(disposition), Comment: strings.TrimSpace(comment)}
+}
+
+// returns the note on the request (nil if there's none). A note for the method takes precedence over one for the URL
+func annotationFor(method string, url string) *annotation {
+	if note, ok := annotations[method+" "+url]; ok {
+		return &note
+	}
+	if note, ok := annotations[url]; ok {
+		return &note
+	}
+
+	return nil
+}
+
+// reports if the analyst marked the request as a false positive, which is then left out of the reports and diffs
+func isFalsePositive(method string, url string) bool {
+	note := annotationFor(method, url)
+	return note != nil && note.Disposition == triageFalsePositive
+}
+
+// loads the `--notes` file (if one is set) into the annotations
+func loadNotesFile() error {
+	if notesFile == "" {
+		return nil
+	}
+
+	notes, err := loadAnnotations(notesFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the notes: %w", err)
+	}
+	annotations = notes
+
+	return nil
+}
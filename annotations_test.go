@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestLoadAnnotations(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-notes.txt")
+	content := "# triage notes\n" +
+		"GET https://example.com/admin => Confirmed: the user role can list all accounts\n" +
+		"https://example.com/admin => reviewed\n" +
+		"https://example.com/profile => only shows the public profile\n" +
+		"post https://example.com/api => false-positive: CSRF token missing => 403\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write notes file: %v", err)
+	}
+
+	notes, err := loadAnnotations(path)
+	if err != nil {
+		t.Fatalf("Failed to load notes: %v", err)
+	}
+	annotations = notes
+	defer func() {
+		annotations = nil
+	}()
+
+	tests := []struct {
+		method, url string
+		expected    string
+	}{
+		{"GET", "https://example.com/admin", "confirmed: the user role can list all accounts"},
+		{"POST", "https://example.com/admin", "reviewed"},
+		{"GET", "https://example.com/profile", "only shows the public profile"},
+		{"POST", "https://example.com/api", "false-positive: CSRF token missing => 403"},
+		{"GET", "https://example.com/api", ""},
+	}
+
+	for _, test := range tests {
+		actual := ""
+		if note := annotationFor(test.method, test.url); note != nil {
+			actual = note.String()
+		}
+		if actual != test.expected {
+			t.Errorf("Expected %q for %s %s but got %q", test.expected, test.method, test.url, actual)
+		}
+	}
+
+	result := probe.Result{Method: "GET", URL: "https://example.com/profile", StatusCode: 200, Length: 3}
+	if actual := formatResult(result, false); actual != "| GET | https://example.com/profile => Length: 3, Note: only shows the public profile\n" {
+		t.Errorf("Expected the note in the output but got %q", actual)
+	}
+
+	if err := os.WriteFile(path, []byte("https://example.com/admin reviewed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write notes file: %v", err)
+	}
+	if _, err := loadAnnotations(path); err == nil {
+		t.Errorf("Expected an error for a note without \"=>\"")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+// HSTS max-age values below 180 days are considered weak
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+var hstsMaxAgeRegex = regexp.MustCompile(`(?i)max-age\s*=\s*"?(\d+)`)
+
+// the results of `--audit-headers`. It's nil if the audit is disabled or no issues were found
+var headerAudits []headerAudit
+
+// the findings of `--audit-headers` for a single result
+type headerAudit struct {
+	Method string   `json:"method"`
+	URL    string   `json:"url"`
+	Issues []string `json:"issues"`
+}
+
+// returns the missing or weak security headers of the response. `authenticated` reports if the request carried
+// credentials, in which case the response must not be cacheable
+func auditHeaders(result probe.Result, authenticated bool) []string {
+	var issues []string
+	header := result.Header
+	if header == nil {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	isHTML := mediaType == "text/html" || mediaType == "application/xhtml+xml"
+
+	csp := header.Get("Content-Security-Policy")
+	if isHTML {
+		switch {
+		case csp == "":
+			issues = append(issues, "missing Content-Security-Policy")
+		case strings.Contains(csp, "'unsafe-inline'") || strings.Contains(csp, "'unsafe-eval'"):
+			issues = append(issues, "weak Content-Security-Policy (allows 'unsafe-inline' or 'unsafe-eval')")
+		}
+
+		frameOptions := strings.ToUpper(strings.TrimSpace(header.Get("X-Frame-Options")))
+		switch {
+		case strings.Contains(csp, "frame-ancestors"):
+		case frameOptions == "":
+			issues = append(issues, "missing X-Frame-Options (and no CSP frame-ancestors)")
+		case frameOptions != "DENY" && frameOptions != "SAMEORIGIN":
+			issues = append(issues, "weak X-Frame-Options: "+frameOptions)
+		}
+	}
+
+	if strings.HasPrefix(strings.ToLower(result.URL), "https://") {
+		hsts := header.Get("Strict-Transport-Security")
+		if hsts == "" {
+			issues = append(issues, "missing Strict-Transport-Security")
+		} else if match := hstsMaxAgeRegex.FindStringSubmatch(hsts); match == nil {
+			issues = append(issues, "weak Strict-Transport-Security (no max-age)")
+		} else if maxAge, _ := strconv.Atoi(match[1]); maxAge < minHSTSMaxAge {
+			issues = append(issues, fmt.Sprintf("weak Strict-Transport-Security (max-age=%d is less than 180 days)", maxAge))
+		}
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(header.Get("X-Content-Type-Options")), "nosniff") {
+		issues = append(issues, "missing X-Content-Type-Options: nosniff")
+	}
+
+	if authenticated && result.StatusCode >= 200 && result.StatusCode < 300 {
+		cacheControl := strings.ToLower(header.Get("Cache-Control"))
+		if !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private") {
+			issues = append(issues, "authenticated response without Cache-Control: no-store or private")
+		}
+	}
+
+	return issues
+}
+
+// audits the headers of all results, sorted by URL and method. Results without issues are omitted
+func buildHeaderAudits(urlStatuses map[int][]probe.Result, authenticated bool) []headerAudit {
+	var audits []headerAudit
+	for _, results := range urlStatuses {
+		for _, result := range results {
+			if issues := auditHeaders(result, authenticated); len(issues) > 0 {
+				audits = append(audits, headerAudit{Method: result.Method, URL: result.URL, Issues: issues})
+			}
+		}
+	}
+
+	sort.Slice(audits, func(i, j int) bool {
+		if audits[i].URL != audits[j].URL {
+			return audits[i].URL < audits[j].URL
+		}
+		return audits[i].Method < audits[j].Method
+	})
+
+	return audits
+}
+
+// writes the issues found by `--audit-headers`. Nothing is written if there are none
+func writeHeaderAudits(writer *bufio.Writer, audits []headerAudit) {
+	if len(audits) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Security Header Audit\n\n")
+	for _, audit := range audits {
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s\n", audit.Method, audit.URL))
+		for _, issue := range audit.Issues {
+			_, _ = writer.WriteString(fmt.Sprintf("    %s\n", issue))
+		}
+	}
+	_, _ = writer.WriteString("\n")
+}
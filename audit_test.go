@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestAuditHeaders(t *testing.T) {
+	secure := http.Header{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Security-Policy":   {"default-src 'self'; frame-ancestors 'none'"},
+		"Strict-Transport-Security": {"max-age=31536000; includeSubDomains"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"Cache-Control":             {"no-store"},
+	}
+	weak := http.Header{
+		"Content-Type":              {"text/html"},
+		"Content-Security-Policy":   {"script-src 'self' 'unsafe-inline'"},
+		"Strict-Transport-Security": {"max-age=3600"},
+		"X-Frame-Options":           {"ALLOW-FROM https://example.com"},
+		"Cache-Control":             {"public, max-age=600"},
+	}
+
+	tests := []struct {
+		url      string
+		header   http.Header
+		expected []string
+	}{
+		{"https://example.com/", secure, nil},
+		{"https://example.com/", weak, []string{
+			"weak Content-Security-Policy (allows 'unsafe-inline' or 'unsafe-eval')",
+			"weak X-Frame-Options: ALLOW-FROM HTTPS://EXAMPLE.COM",
+			"weak Strict-Transport-Security (max-age=3600 is less than 180 days)",
+			"missing X-Content-Type-Options: nosniff",
+			"authenticated response without Cache-Control: no-store or private",
+		}},
+		{"http://example.com/api", http.Header{"Content-Type": {"application/json"}, "Cache-Control": {"private"}}, []string{
+			"missing X-Content-Type-Options: nosniff",
+		}},
+	}
+
+	for _, test := range tests {
+		result := probe.Result{Method: "GET", URL: test.url, StatusCode: 200, Header: test.header}
+		if actual := auditHeaders(result, true); fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("Expected %v for %s but got %v", test.expected, test.url, actual)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// builds the value of the Authorization header from `--basic` ("user:pass") or `--bearer` ("<token>"). Returns ""
+// if neither is set
+func authorizationHeader(basic string, bearer string) (string, error) {
+	if basic != "" && bearer != "" {
+		return "", errors.New("--basic and --bearer can't be combined")
+	}
+
+	if basic != "" {
+		if !strings.Contains(basic, ":") {
+			return "", errors.New("--basic must be in the format \"user:pass\"")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(basic)), nil
+	}
+
+	if bearer != "" {
+		return "Bearer " + strings.TrimSpace(strings.TrimPrefix(bearer, "Bearer ")), nil
+	}
+
+	return "", nil
+}
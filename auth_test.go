@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestAuthorizationHeader(t *testing.T) {
+	tests := []struct {
+		basic, bearer string
+		expected      string
+	}{
+		{"", "", ""},
+		{"admin:s3cr:et", "", "Basic YWRtaW46czNjcjpldA=="},
+		{"", "eyJhbGciOiJIUzI1NiJ9", "Bearer eyJhbGciOiJIUzI1NiJ9"},
+		{"", "Bearer abc", "Bearer abc"},
+	}
+
+	for _, test := range tests {
+		if actual, err := authorizationHeader(test.basic, test.bearer); err != nil || actual != test.expected {
+			t.Errorf("Expected %q for basic %q and bearer %q but got %q (err: %v)", test.expected, test.basic, test.bearer, actual, err)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"admin", ""}, {"admin:pass", "token"}} {
+		if _, err := authorizationHeader(invalid[0], invalid[1]); err == nil {
+			t.Errorf("Expected an error for basic %q and bearer %q", invalid[0], invalid[1])
+		}
+	}
+}
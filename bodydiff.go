@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// only the beginning of the bodies is kept and diffed, so that large responses don't use up the memory
+	maxDiffBodyBytes = 16 * 1024
+	maxDiffBodyLines = 1000
+	// the primary bodies are kept for the whole scan, so their total size is capped
+	maxPrimaryBodiesBytes = 64 * 1024 * 1024
+	// number of unchanged lines shown around every change
+	diffContextLines = 2
+	// the diff is cut off after this many lines, as it's only meant as an excerpt for the report
+	maxDiffLines = 20
+	// long lines (e.g. of minified HTML) are cut off to this many characters
+	maxDiffLineLength = 200
+)
+
+// a line of a diff, i.e. an unchanged (' '), removed ('-') or added ('+') one
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// returns a unified diff excerpt of both bodies (empty if they're the same)
+func bodyDiff(primary []byte, other []byte) string {
+	diff := unifiedDiff(diffBodyLines(primary), diffBodyLines(other), diffContextLines)
+	if diff == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	if len(lines) > maxDiffLines {
+		lines = append(lines[:maxDiffLines], "...")
+	}
+	for i, line := range lines {
+		lines[i] = truncateRunes(line, maxDiffLineLength)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// splits the beginning of the body into the lines that are diffed
+func diffBodyLines(body []byte) []string {
+	if len(body) > maxDiffBodyBytes {
+		body = body[:maxDiffBodyBytes]
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(lines) > maxDiffBodyLines {
+		lines = lines[:maxDiffBodyLines]
+	}
+
+	return lines
+}
+
+// computes a unified diff ("@@ -1,3 +1,3 @@" hunks) of the lines via their longest common subsequence, with `context`
+// unchanged lines around every change
+func unifiedDiff(a []string, b []string, context int) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		// removed lines come before the added ones, like in `diff -u`
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	var builder strings.Builder
+	for start := 0; start < len(lines); {
+		// find the next change and the end of its hunk, which also covers the changes within 2*context lines
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		end := first
+		for k := first; k < len(lines) && k <= end+2*context+1; k++ {
+			if lines[k].op != ' ' {
+				end = k
+			}
+		}
+
+		hunkStart, hunkEnd := max(first-context, start), min(end+context+1, len(lines))
+		writeHunk(&builder, lines, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	return builder.String()
+}
+
+// writes the lines[from:to] as a hunk, numbering the lines the way `diff -u` does
+func writeHunk(builder *strings.Builder, lines []diffLine, from int, to int) {
+	aStart, bStart := 1, 1
+	for _, line := range lines[:from] {
+		if line.op != '+' {
+			aStart++
+		}
+		if line.op != '-' {
+			bStart++
+		}
+	}
+
+	aLen, bLen := 0, 0
+	for _, line := range lines[from:to] {
+		if line.op != '+' {
+			aLen++
+		}
+		if line.op != '-' {
+			bLen++
+		}
+	}
+
+	// an empty range starts at the line before it
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+
+	builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen))
+	for _, line := range lines[from:to] {
+		builder.WriteString(string(line.op) + line.text + "\n")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb\nc", "a\nb\nc", ""},
+		{"a\nb\nc", "a\nx\nc", "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9", "1\n2\n3\n4\nfive\n6\n7\n8\n9", "@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n"},
+		{"a\nb", "a\nb\nc", "@@ -1,2 +1,3 @@\n a\n b\n+c\n"},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10", "x\n2\n3\n4\n5\n6\n7\n8\n9\ny", "@@ -1,3 +1,3 @@\n-1\n+x\n 2\n 3\n@@ -8,3 +8,3 @@\n 8\n 9\n-10\n+y\n"},
+	}
+
+	for _, test := range tests {
+		if actual := unifiedDiff(strings.Split(test.a, "\n"), strings.Split(test.b, "\n"), 2); actual != test.expected {
+			t.Errorf("Expected %q for %q and %q but got %q", test.expected, test.a, test.b, actual)
+		}
+	}
+}
+
+func TestBodyDiff_Excerpt(t *testing.T) {
+	var primary, other []string
+	for i := 0; i < 100; i++ {
+		primary = append(primary, "same")
+		other = append(other, "different")
+	}
+
+	diff := bodyDiff([]byte(strings.Join(primary, "\n")), []byte(strings.Join(other, "\n")))
+	lines := strings.Split(diff, "\n")
+	if len(lines) != maxDiffLines+1 || lines[len(lines)-1] != "..." {
+		t.Errorf("Expected the diff to be cut off after %d lines but got %d lines", maxDiffLines, len(lines))
+	}
+}
+
+func TestBodyDiff_LongLines(t *testing.T) {
+	diff := bodyDiff([]byte("same"), []byte(strings.Repeat("ü", 300)))
+	lines := strings.Split(diff, "\n")
+	if !utf8.ValidString(diff) || utf8.RuneCountInString(lines[len(lines)-1]) != maxDiffLineLength {
+		t.Errorf("Expected the long line to be cut to %d characters but got %q", maxDiffLineLength, lines[len(lines)-1])
+	}
+}
+
+func TestComparisonSet_Diff(t *testing.T) {
+	c := newComparisonSet()
+	primary := probe.Result{Method: "GET", URL: "https://example.com/orders", StatusCode: 200, Length: 23}
+	_ = c.hook(false).AfterResponse(&primary, []byte("<h1>Orders</h1>\n#1001\n"))
+
+	other := probe.Result{Method: "GET", URL: "https://example.com/orders", StatusCode: 200, Length: 16}
+	_ = c.hook(true).AfterResponse(&other, []byte("<h1>Orders</h1>\n"))
+
+	verdicts := c.verdicts()
+	if len(verdicts) != 1 || verdicts[0].Diff != "@@ -1,2 +1,1 @@\n <h1>Orders</h1>\n-#1001" {
+		t.Errorf("Expected a diff of the bodies but got %v", verdicts)
+	}
+	if len(c.primaryBodies) != 0 {
+		t.Errorf("Expected the primary body to be released after diffing but got %d bodies", len(c.primaryBodies))
+	}
+
+	// bodies of responses that can't be exposed aren't kept until the second pass
+	denied := probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 403, Length: 9}
+	_ = c.hook(false).AfterResponse(&denied, []byte("Forbidden"))
+	if len(c.primaryBodies) != 0 || c.primaryBodiesBytes != 0 {
+		t.Errorf("Expected the body of the 403 not to be kept but got %d bodies", len(c.primaryBodies))
+	}
+}
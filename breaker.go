@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// trips when more than a given share of the last requests failed, e.g. because the target went down
+type circuitBreaker struct {
+	// the maximum error rate in percent
+	maxRate float64
+	// the outcomes of the last requests (true if the request failed) as a ring buffer
+	window []bool
+	next   int
+	count  int
+	errors int
+}
+
+// creates a circuit breaker over the last `size` requests. It's nil (i.e. disabled) if maxRate is 0
+func newCircuitBreaker(maxRate float64, size int) *circuitBreaker {
+	if maxRate <= 0 || size <= 0 {
+		return nil
+	}
+
+	return &circuitBreaker{maxRate: maxRate, window: make([]bool, size)}
+}
+
+// records the outcome of a request and reports whether the breaker tripped. It only trips once the window is full,
+// so that a few early errors don't abort the scan
+func (b *circuitBreaker) record(failed bool) bool {
+	if b.count == len(b.window) && b.window[b.next] {
+		b.errors--
+	}
+	if b.count < len(b.window) {
+		b.count++
+	}
+
+	b.window[b.next] = failed
+	if failed {
+		b.errors++
+	}
+	b.next = (b.next + 1) % len(b.window)
+
+	return b.count == len(b.window) && b.rate() > b.maxRate
+}
+
+// returns the error rate of the window in percent
+func (b *circuitBreaker) rate() float64 {
+	if b.count == 0 {
+		return 0
+	}
+
+	return float64(b.errors) / float64(b.count) * 100
+}
+
+// clears the window, e.g. after pausing the scan
+func (b *circuitBreaker) reset() {
+	b.window = make([]bool, len(b.window))
+	b.next, b.count, b.errors = 0, 0, 0
+}
+
+func (b *circuitBreaker) String() string {
+	return fmt.Sprintf("%.0f%% of the last %d requests failed (--max-error-rate %.0f%%)", b.rate(), b.count, b.maxRate)
+}
+
+// handles a tripped breaker: with `--error-pause`, the scan is paused (the workers block because the results aren't
+// consumed) and then continues, otherwise it's aborted. Returns whether the scan should be aborted
+func (b *circuitBreaker) trip(pause time.Duration) bool {
+	if pause <= 0 {
+		return true
+	}
+
+	Warn("Pausing the scan for %s: %s", pause, b)
+	time.Sleep(pause)
+	b.reset()
+
+	return false
+}
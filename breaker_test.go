@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestCircuitBreaker(t *testing.T) {
+	if newCircuitBreaker(0, 10) != nil {
+		t.Errorf("Expected the circuit breaker to be disabled without a maximum error rate")
+	}
+
+	b := newCircuitBreaker(50, 4)
+
+	// the breaker doesn't trip before the window is full
+	for i := 0; i < 3; i++ {
+		if b.record(true) {
+			t.Fatalf("Expected the breaker not to trip before the window is full")
+		}
+	}
+
+	// 3 of the last 4 requests failed
+	if !b.record(false) {
+		t.Errorf("Expected the breaker to trip at %.0f%%", b.rate())
+	}
+
+	// the oldest errors drop out of the window: 2 of the last 4 requests failed
+	b.record(false)
+	if b.record(false) {
+		t.Errorf("Expected the breaker not to trip at %.0f%%", b.rate())
+	}
+
+	b.reset()
+	if b.rate() != 0 || b.count != 0 {
+		t.Errorf("Expected an empty window after the reset")
+	}
+}
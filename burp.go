@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	neturl "net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the structure of Burp's "Save items" XML, which can be loaded back into Burp (e.g. via an importer extension)
+type burpItems struct {
+	XMLName     xml.Name   `xml:"items"`
+	BurpVersion string     `xml:"burpVersion,attr"`
+	ExportTime  string     `xml:"exportTime,attr"`
+	Items       []burpItem `xml:"item"`
+}
+
+type burpItem struct {
+	Time           string    `xml:"time"`
+	URL            burpCDATA `xml:"url"`
+	Host           burpHost  `xml:"host"`
+	Port           string    `xml:"port"`
+	Protocol       string    `xml:"protocol"`
+	Method         burpCDATA `xml:"method"`
+	Path           burpCDATA `xml:"path"`
+	Extension      string    `xml:"extension"`
+	Request        burpData  `xml:"request"`
+	Status         int       `xml:"status"`
+	ResponseLength int       `xml:"responselength"`
+	MimeType       string    `xml:"mimetype"`
+	Response       burpData  `xml:"response"`
+	Comment        string    `xml:"comment"`
+}
+
+type burpCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpData struct {
+	Base64 bool   `xml:"base64,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// sends the results with one of the given status codes (all 2xx if none are given) again to capture the full
+// request/response pairs and writes them as Burp items XML
+func exportBurp(urlStatuses map[int][]probe.Result, opts probe.Options, statuses map[int]bool, path string) (int, error) {
+	var selected []probe.Result
+	for status, results := range urlStatuses {
+		if (len(statuses) == 0 && status >= 200 && status < 300) || statuses[status] {
+			selected = append(selected, results...)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].URL != selected[j].URL {
+			return selected[i].URL < selected[j].URL
+		}
+		return selected[i].Method < selected[j].Method
+	})
+
+	// the requests are sent one after another, so the hooks can capture the raw request and response body of each
+	var rawRequest, responseBody []byte
+	opts.RequestHooks = append(opts.RequestHooks, probe.RequestHookFunc(func(req *http.Request) error {
+		dump, err := httputil.DumpRequestOut(req, true)
+		rawRequest = dump
+		return err
+	}))
+	opts.ResponseHooks = append(opts.ResponseHooks, probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		responseBody = body
+		return nil
+	}))
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	export := burpItems{BurpVersion: "sessionprobe " + AppVersion, ExportTime: time.Now().Format(time.RFC1123)}
+	for _, finding := range selected {
+		rawRequest, responseBody = nil, nil
+
+		result := scanner.Do(context.Background(), probe.Request{Method: finding.Method, URL: finding.URL})
+		if handleHTTPError(result.Err, finding.URL) {
+			continue
+		}
+
+		item, err := newBurpItem(result, rawRequest, responseBody)
+		if err != nil {
+			Error("Failed to export %s to Burp: %s", finding.URL, err)
+			continue
+		}
+		export.Items = append(export.Items, item)
+	}
+
+	data, err := xml.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	return len(export.Items), os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+func newBurpItem(result probe.Result, rawRequest []byte, body []byte) (burpItem, error) {
+	parsed, err := neturl.Parse(result.URL)
+	if err != nil {
+		return burpItem{}, err
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "80"
+		if parsed.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	extension := strings.TrimPrefix(path.Ext(parsed.Path), ".")
+	if extension == "" {
+		extension = "null"
+	}
+
+	var response bytes.Buffer
+	fmt.Fprintf(&response, "HTTP/1.1 %d %s\r\n", result.StatusCode, http.StatusText(result.StatusCode))
+	_ = result.Header.Write(&response)
+	response.WriteString("\r\n")
+	response.Write(body)
+
+	comment := "sessionprobe: " + strconv.Itoa(result.StatusCode)
+	if len(result.Labels) > 0 {
+		comment += " [" + strings.Join(result.Labels, ", ") + "]"
+	}
+
+	return burpItem{
+		Time:           time.Now().Format(time.RFC1123),
+		URL:            burpCDATA{result.URL},
+		Host:           burpHost{Name: parsed.Hostname()},
+		Port:           port,
+		Protocol:       parsed.Scheme,
+		Method:         burpCDATA{result.Method},
+		Path:           burpCDATA{parsed.RequestURI()},
+		Extension:      extension,
+		Request:        burpData{Base64: true, Value: base64.StdEncoding.EncodeToString(rawRequest)},
+		Status:         result.StatusCode,
+		ResponseLength: response.Len(),
+		MimeType:       burpMimeType(result.Header.Get("Content-Type")),
+		Response:       burpData{Base64: true, Value: base64.StdEncoding.EncodeToString(response.Bytes())},
+		Comment:        comment,
+	}, nil
+}
+
+// maps the Content-Type to the MIME types Burp uses (e.g. "HTML" or "JSON")
+func burpMimeType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "":
+		return ""
+	case strings.Contains(mediaType, "html"):
+		return "HTML"
+	case strings.Contains(mediaType, "json"):
+		return "JSON"
+	case strings.Contains(mediaType, "xml"):
+		return "XML"
+	case strings.Contains(mediaType, "javascript"):
+		return "script"
+	case strings.HasPrefix(mediaType, "image/"):
+		return "image"
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text"
+	default:
+		return "app"
+	}
+}
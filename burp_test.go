@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestExportBurp(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user": "admin"}`))
+	}))
+	defer ts.Close()
+
+	urlStatuses := map[int][]probe.Result{
+		200: {{Method: "GET", URL: ts.URL + "/admin", StatusCode: 200}},
+		403: {{Method: "GET", URL: ts.URL + "/forbidden", StatusCode: 403}},
+	}
+
+	path := filepath.Join(".", "testing", "test-burp.xml")
+	opts := probe.Options{Headers: map[string][]string{"Cookie": {"session=abc"}}}
+	exported, err := exportBurp(urlStatuses, opts, nil, path)
+	if err != nil || exported != 1 {
+		t.Fatalf("Expected 1 exported finding but got %d (err: %v)", exported, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read Burp export: %v", err)
+	}
+
+	var items burpItems
+	if err := xml.Unmarshal(data, &items); err != nil || len(items.Items) != 1 {
+		t.Fatalf("Expected 1 item but got %v (err: %v)", items.Items, err)
+	}
+
+	item := items.Items[0]
+	request, _ := base64.StdEncoding.DecodeString(item.Request.Value)
+	response, _ := base64.StdEncoding.DecodeString(item.Response.Value)
+
+	if item.Path.Value != "/admin" || item.MimeType != "JSON" || !strings.Contains(string(request), "Cookie: session=abc") {
+		t.Errorf("Unexpected item: %+v\n%s", item, request)
+	}
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200 OK") || !strings.HasSuffix(string(response), `{"user": "admin"}`) {
+		t.Errorf("Unexpected response: %s", response)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the variants that returned a successful response although the original request was denied
+var bypassFindings []bypassFinding
+
+// a modified request that is sent to check whether the access control of a URL can be bypassed
+type bypassVariant struct {
+	// the name of the technique, e.g. "X-Original-URL" or "double slash"
+	technique string
+	url       string
+	header    http.Header
+}
+
+// generates the variants of a denied result
+type bypassGenerator func(result probe.Result) []bypassVariant
+
+// a variant that returned a successful response although the original request was denied
+type bypassFinding struct {
+	Method         string              `json:"method"`
+	URL            string              `json:"url"`
+	Technique      string              `json:"technique"`
+	VariantURL     string              `json:"variant_url"`
+	Headers        map[string][]string `json:"headers,omitempty"`
+	StatusCode     int                 `json:"status_code"`
+	Length         int                 `json:"length"`
+	OriginalStatus int                 `json:"original_status_code"`
+}
+
+// returns the results that were denied, i.e. those with one of the given status codes
+func deniedResults(urlStatuses map[int][]probe.Result, statuses ...int) []probe.Result {
+	var denied []probe.Result
+	for _, status := range statuses {
+		denied = append(denied, urlStatuses[status]...)
+	}
+
+	return denied
+}
+
+// sends the variants of every denied result and returns those that were successful (2xx)
+func probeBypasses(denied []probe.Result, opts probe.Options, generate bypassGenerator) ([]bypassFinding, error) {
+	// the hooks of the main scan (e.g. the verdict recording) must not see these requests
+	opts.ResponseHooks = nil
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := opts.Threads
+	if workers <= 0 {
+		workers = 1
+	}
+
+	type job struct {
+		original probe.Result
+		variant  bypassVariant
+	}
+
+	jobs := make(chan job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var findings []bypassFinding
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				result := scanner.Do(context.Background(), probe.Request{Method: j.original.Method, URL: j.variant.url, Header: j.variant.header})
+				if handleHTTPError(result.Err, j.variant.url) || result.StatusCode < 200 || result.StatusCode >= 300 {
+					continue
+				}
+
+				Warn("Possible bypass of %s %s (%d) via %s: %d", j.original.Method, j.original.URL, j.original.StatusCode, j.variant.technique, result.StatusCode)
+
+				mu.Lock()
+				findings = append(findings, bypassFinding{
+					Method:         j.original.Method,
+					URL:            j.original.URL,
+					Technique:      j.variant.technique,
+					VariantURL:     j.variant.url,
+					Headers:        j.variant.header,
+					StatusCode:     result.StatusCode,
+					Length:         result.Length,
+					OriginalStatus: j.original.StatusCode,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, original := range denied {
+		for _, variant := range generate(original) {
+			jobs <- job{original: original, variant: variant}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Technique < b.Technique
+	})
+
+	return findings, nil
+}
+
+// the path and header tricks of `--bypass-403`
+func pathBypassVariants(result probe.Result) []bypassVariant {
+	parsed, err := neturl.Parse(result.URL)
+	if err != nil {
+		return nil
+	}
+
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	trimmed := strings.TrimSuffix(path, "/")
+	origin := parsed.Scheme + "://" + parsed.Host
+	query := ""
+	if parsed.RawQuery != "" {
+		query = "?" + parsed.RawQuery
+	}
+
+	variants := []bypassVariant{
+		{technique: "/%2e/ prefix", url: origin + "/%2e" + path + query},
+		{technique: "trailing /.;/", url: origin + trimmed + "/.;/" + query},
+		{technique: "trailing ..;/", url: origin + trimmed + "..;/" + query},
+		{technique: "double slash", url: origin + "/" + path + query},
+		{technique: "trailing slash", url: origin + trimmed + "/" + query},
+		{technique: "trailing /.", url: origin + trimmed + "/." + query},
+		{technique: "uppercase", url: origin + strings.ToUpper(path) + query},
+		{technique: "X-Original-URL", url: origin + "/" + query, header: http.Header{"X-Original-URL": {path}}},
+		{technique: "X-Rewrite-URL", url: origin + "/" + query, header: http.Header{"X-Rewrite-URL": {path}}},
+	}
+
+	// "/admin" -> "/Admin" to catch case-sensitive rules
+	if segments := strings.Split(trimmed, "/"); len(segments) > 1 && segments[len(segments)-1] != "" {
+		last := segments[len(segments)-1]
+		segments[len(segments)-1] = strings.ToUpper(last[:1]) + last[1:]
+		variants = append(variants, bypassVariant{technique: "capitalized segment", url: origin + strings.Join(segments, "/") + query})
+	}
+
+	// skip variants that are identical to the original URL (e.g. the trailing slash if there already is one)
+	var unique []bypassVariant
+	for _, variant := range variants {
+		if variant.url != result.URL || variant.header != nil {
+			unique = append(unique, variant)
+		}
+	}
+
+	return unique
+}
+
+// the headers of `--spoof-internal` and the internal values they're set to. Each is sent on its own, so the finding
+// shows which header the access control trusts
+var spoofHeaders = []struct {
+	name  string
+	value string
+}{
+	{"X-Forwarded-For", "127.0.0.1"},
+	{"X-Real-IP", "127.0.0.1"},
+	{"X-Client-IP", "127.0.0.1"},
+	{"X-Originating-IP", "127.0.0.1"},
+	{"True-Client-IP", "127.0.0.1"},
+	{"X-Forwarded-For", "10.0.0.1"},
+	{"X-Forwarded-Host", "localhost"},
+	{"Forwarded", "for=127.0.0.1;host=localhost"},
+}
+
+// the forwarded-header spoofing variants of `--spoof-internal`
+func spoofBypassVariants(result probe.Result) []bypassVariant {
+	var variants []bypassVariant
+	for _, spoof := range spoofHeaders {
+		variants = append(variants, bypassVariant{
+			technique: spoof.name + ": " + spoof.value,
+			url:       result.URL,
+			header:    http.Header{spoof.name: {spoof.value}},
+		})
+	}
+
+	return variants
+}
+
+// the encoding variants of `--mutate-paths`: the last path segment is percent-encoded, double-encoded, written with
+// fullwidth Unicode characters (which NFKC normalization maps back to ASCII), or joined with an encoded slash
+func encodingBypassVariants(result probe.Result) []bypassVariant {
+	parsed, err := neturl.Parse(result.URL)
+	if err != nil {
+		return nil
+	}
+
+	path := strings.TrimSuffix(parsed.EscapedPath(), "/")
+	index := strings.LastIndex(path, "/")
+	prefix, segment := path[:index+1], path[index+1:]
+	if segment == "" || strings.Contains(segment, "%") {
+		return nil
+	}
+
+	origin := parsed.Scheme + "://" + parsed.Host
+	query := ""
+	if parsed.RawQuery != "" {
+		query = "?" + parsed.RawQuery
+	}
+
+	first := segment[0]
+	var full strings.Builder
+	for i := 0; i < len(segment); i++ {
+		full.WriteString(fmt.Sprintf("%%%02X", segment[i]))
+	}
+
+	variants := []bypassVariant{
+		{technique: "percent-encoded character", url: origin + prefix + fmt.Sprintf("%%%02X", first) + segment[1:] + query},
+		{technique: "percent-encoded segment", url: origin + prefix + full.String() + query},
+		{technique: "double-encoded character", url: origin + prefix + fmt.Sprintf("%%25%02X", first) + segment[1:] + query},
+	}
+
+	// fullwidth forms exist for all printable ASCII characters (U+FF01 to U+FF5E)
+	if first > 0x20 && first < 0x7f {
+		fullwidth := neturl.PathEscape(string(rune(0xFF01 + int(first) - 0x21)))
+		variants = append(variants, bypassVariant{technique: "unicode fullwidth character", url: origin + prefix + fullwidth + segment[1:] + query})
+	}
+
+	if len(prefix) > 1 {
+		variants = append(variants, bypassVariant{technique: "encoded slash", url: origin + strings.TrimSuffix(prefix, "/") + "%2F" + segment + query})
+	}
+
+	return variants
+}
+
+// writes the successful bypass variants. Nothing is written if there are none
+func writeBypassFindings(writer *bufio.Writer, findings []bypassFinding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Access Control Bypasses\n\n")
+	for _, f := range findings {
+		headers := ""
+		for name, values := range f.Headers {
+			headers += fmt.Sprintf(", %s: %s", name, strings.Join(values, ", "))
+		}
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s (%d) => %s via %s: Status: %d, Length: %s%s\n",
+			f.Method, f.URL, f.OriginalStatus, f.VariantURL, f.Technique, f.StatusCode, formatLength(f.Length), headers))
+	}
+	_, _ = writer.WriteString("\n")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestPathBypassVariants(t *testing.T) {
+	variants := pathBypassVariants(probe.Result{URL: "https://example.com/api/admin?x=1"})
+
+	expected := map[string]string{
+		"/%2e/ prefix":        "https://example.com/%2e/api/admin?x=1",
+		"trailing /.;/":       "https://example.com/api/admin/.;/?x=1",
+		"double slash":        "https://example.com//api/admin?x=1",
+		"uppercase":           "https://example.com/API/ADMIN?x=1",
+		"capitalized segment": "https://example.com/api/Admin?x=1",
+		"X-Original-URL":      "https://example.com/?x=1",
+	}
+
+	found := make(map[string]string)
+	for _, variant := range variants {
+		found[variant.technique] = variant.url
+	}
+	for technique, url := range expected {
+		if found[technique] != url {
+			t.Errorf("Expected %s for %s but got %s", url, technique, found[technique])
+		}
+	}
+}
+
+func TestProbeBypasses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a naive ACL that only blocks the exact path
+		if r.URL.EscapedPath() == "/admin" || r.Header.Get("X-Original-URL") != "" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	denied := []probe.Result{{Method: "GET", URL: ts.URL + "/admin", StatusCode: http.StatusForbidden}}
+	findings, err := probeBypasses(denied, probe.Options{Threads: 2}, pathBypassVariants)
+	if err != nil {
+		t.Fatalf("Failed to probe bypasses: %v", err)
+	}
+
+	techniques := make(map[string]bool)
+	for _, f := range findings {
+		techniques[f.Technique] = true
+	}
+	for _, technique := range []string{"double slash", "trailing slash", "uppercase"} {
+		if !techniques[technique] {
+			t.Errorf("Expected %s to bypass the ACL but got %v", technique, findings)
+		}
+	}
+	if techniques["X-Original-URL"] {
+		t.Errorf("Expected X-Original-URL not to bypass the ACL")
+	}
+}
+
+func TestSpoofBypassVariants(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// an ACL that trusts the X-Real-IP header of a reverse proxy
+		if r.Header.Get("X-Real-IP") != "127.0.0.1" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	denied := []probe.Result{{Method: "GET", URL: ts.URL + "/internal", StatusCode: http.StatusForbidden}}
+	findings, err := probeBypasses(denied, probe.Options{}, spoofBypassVariants)
+	if err != nil {
+		t.Fatalf("Failed to probe bypasses: %v", err)
+	}
+
+	if len(findings) != 1 || findings[0].Technique != "X-Real-IP: 127.0.0.1" {
+		t.Errorf("Expected only X-Real-IP to bypass the ACL but got %v", findings)
+	}
+}
+
+func TestEncodingBypassVariants(t *testing.T) {
+	variants := encodingBypassVariants(probe.Result{URL: "https://example.com/api/admin/?x=1"})
+
+	expected := map[string]string{
+		"percent-encoded character":   "https://example.com/api/%61dmin?x=1",
+		"percent-encoded segment":     "https://example.com/api/%61%64%6D%69%6E?x=1",
+		"double-encoded character":    "https://example.com/api/%2561dmin?x=1",
+		"unicode fullwidth character": "https://example.com/api/%EF%BD%81dmin?x=1",
+		"encoded slash":               "https://example.com/api%2Fadmin?x=1",
+	}
+
+	if len(variants) != len(expected) {
+		t.Fatalf("Expected %d variants but got %v", len(expected), variants)
+	}
+	for _, variant := range variants {
+		if expected[variant.technique] != variant.url {
+			t.Errorf("Expected %s for %s but got %s", expected[variant.technique], variant.technique, variant.url)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+const (
+	// the label of authenticated responses that shared caches (e.g. CDNs) are allowed to store
+	publiclyCacheableLabel = "publicly-cacheable"
+	// the label of authenticated responses that were served from a shared cache
+	sharedCacheHitLabel = "shared-cache-hit"
+)
+
+// the headers that tell whether a shared cache is involved, e.g. "X-Cache: HIT" or "CF-Cache-Status: HIT"
+var cacheStatusHeaders = []string{"X-Cache", "X-Cache-Status", "CF-Cache-Status", "X-Proxy-Cache"}
+
+// the caching-related headers of a response
+type cacheInfo struct {
+	CacheControl string `json:"cache_control,omitempty"`
+	Age          string `json:"age,omitempty"`
+	XCache       string `json:"x_cache,omitempty"`
+}
+
+// returns the caching-related headers of the response, or nil if there are none
+func newCacheInfo(header http.Header) *cacheInfo {
+	info := cacheInfo{CacheControl: header.Get("Cache-Control"), Age: header.Get("Age")}
+	for _, name := range cacheStatusHeaders {
+		if value := header.Get(name); value != "" {
+			info.XCache = value
+			break
+		}
+	}
+
+	if info == (cacheInfo{}) {
+		return nil
+	}
+
+	return &info
+}
+
+// returns the labels for a response that may be stored in or was served from a shared cache
+func cacheLabels(header http.Header) []string {
+	info := newCacheInfo(header)
+	if info == nil {
+		return nil
+	}
+
+	var labels []string
+	cacheControl := strings.ToLower(info.CacheControl)
+	if !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private") &&
+		(strings.Contains(cacheControl, "public") || strings.Contains(cacheControl, "s-maxage")) {
+		labels = append(labels, publiclyCacheableLabel)
+	}
+
+	age, _ := strconv.Atoi(strings.TrimSpace(info.Age))
+	if age > 0 || strings.Contains(strings.ToUpper(info.XCache), "HIT") {
+		labels = append(labels, sharedCacheHitLabel)
+	}
+
+	return labels
+}
+
+// returns a ResponseHook that labels successful authenticated responses which are publicly cacheable or were served
+// from a shared cache, since another user could then be served the cached response
+func cacheHook() probe.ResponseHook {
+	return probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			return nil
+		}
+
+		if labels := cacheLabels(result.Header); len(labels) > 0 {
+			result.Labels = append(result.Labels, labels...)
+			Warn("Authenticated response of %s %s is cached: %s", result.Method, result.URL, strings.Join(labels, ", "))
+		}
+
+		return nil
+	})
+}
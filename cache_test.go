@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCacheLabels(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		expected []string
+	}{
+		{http.Header{}, nil},
+		{http.Header{"Cache-Control": {"private, max-age=60"}}, nil},
+		{http.Header{"Cache-Control": {"public, max-age=600"}}, []string{publiclyCacheableLabel}},
+		{http.Header{"Cache-Control": {"max-age=0, s-maxage=300"}, "Age": {"12"}}, []string{publiclyCacheableLabel, sharedCacheHitLabel}},
+		{http.Header{"Cf-Cache-Status": {"HIT"}}, []string{sharedCacheHitLabel}},
+		{http.Header{"X-Cache": {"Miss from cloudfront"}, "Age": {"0"}}, nil},
+	}
+
+	for _, test := range tests {
+		if actual := cacheLabels(test.header); fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("Expected %v for %v but got %v", test.expected, test.header, actual)
+		}
+	}
+}
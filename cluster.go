@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the responses of the scan, grouped by their status code and body
+var clusters = newClusterSet()
+
+type clusterSet struct {
+	mu       sync.Mutex
+	clusters map[string]*responseCluster
+}
+
+// a distinct response (status code and body) and the requests that produced it
+type responseCluster struct {
+	StatusCode int `json:"status_code"`
+	// the (shortened) SHA-256 of the body, or of the length if the body wasn't read (`--no-body`)
+	Hash   string   `json:"hash"`
+	Length int      `json:"length"`
+	Count  int      `json:"count"`
+	URLs   []string `json:"urls"`
+}
+
+func newClusterSet() *clusterSet {
+	return &clusterSet{clusters: make(map[string]*responseCluster)}
+}
+
+// returns a short hash that identifies the body. Dynamic content (`--normalize-regex`) is removed first, so that
+// otherwise identical responses end up in the same cluster
+func bodyHash(body []byte, length int) string {
+	var sum [sha256.Size]byte
+	if body != nil {
+		sum = sha256.Sum256(normalizeBody(body))
+	} else {
+		sum = sha256.Sum256([]byte("length:" + strconv.Itoa(length)))
+	}
+
+	return hex.EncodeToString(sum[:6])
+}
+
+// returns a ResponseHook that adds every matched response to the cluster of its status code and body
+func (c *clusterSet) hook() probe.ResponseHook {
+	return probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		if !result.Matched {
+			return nil
+		}
+
+		hash := bodyHash(body, result.Length)
+		key := fmt.Sprintf("%d %s", result.StatusCode, hash)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		cluster := c.clusters[key]
+		if cluster == nil {
+			cluster = &responseCluster{StatusCode: result.StatusCode, Hash: hash, Length: result.Length}
+			c.clusters[key] = cluster
+		}
+		cluster.Count++
+		cluster.URLs = append(cluster.URLs, result.Method+" "+result.URL)
+
+		return nil
+	})
+}
+
+// returns the clusters with more than one response, the largest first. Responses that are unique don't need to be
+// collapsed, so they're only reported in the usual buckets
+func (c *clusterSet) summary() []responseCluster {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var summary []responseCluster
+	for _, cluster := range c.clusters {
+		if cluster.Count < 2 {
+			continue
+		}
+
+		entry := *cluster
+		entry.URLs = append([]string(nil), cluster.URLs...)
+		sort.Strings(entry.URLs)
+		summary = append(summary, entry)
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		a, b := summary[i], summary[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.StatusCode != b.StatusCode {
+			return a.StatusCode < b.StatusCode
+		}
+		return a.Hash < b.Hash
+	})
+
+	return summary
+}
+
+// writes every cluster once with the requests that produced it. Nothing is written if all responses are unique
+func writeClusters(writer *bufio.Writer, summary []responseCluster) {
+	if len(summary) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Clusters of Identical Responses\n\n")
+	for _, cluster := range summary {
+		_, _ = writer.WriteString(fmt.Sprintf("| %d | %s => Length: %s, Responses: %d\n", cluster.StatusCode, cluster.Hash, formatLength(cluster.Length), cluster.Count))
+		for _, url := range cluster.URLs {
+			_, _ = writer.WriteString("    " + url + "\n")
+		}
+	}
+	_, _ = writer.WriteString("\n")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestClusterSummary(t *testing.T) {
+	set := newClusterSet()
+	hook := set.hook()
+
+	notFound := []byte("<h1>Not Found</h1>")
+	responses := []struct {
+		result probe.Result
+		body   []byte
+	}{
+		{probe.Result{Method: "GET", URL: "https://example.com/b", StatusCode: 404, Length: 18, Matched: true}, notFound},
+		{probe.Result{Method: "GET", URL: "https://example.com/a", StatusCode: 404, Length: 18, Matched: true}, notFound},
+		{probe.Result{Method: "POST", URL: "https://example.com/a", StatusCode: 404, Length: 18, Matched: true}, notFound},
+		// same body, but a different status code
+		{probe.Result{Method: "GET", URL: "https://example.com/c", StatusCode: 200, Length: 18, Matched: true}, notFound},
+		// filtered out
+		{probe.Result{Method: "GET", URL: "https://example.com/d", StatusCode: 404, Length: 18}, notFound},
+		// without bodies, the lengths are compared
+		{probe.Result{Method: "GET", URL: "https://example.com/e", StatusCode: 403, Length: 9, Matched: true}, nil},
+		{probe.Result{Method: "GET", URL: "https://example.com/f", StatusCode: 403, Length: 9, Matched: true}, nil},
+	}
+	for i := range responses {
+		if err := hook.AfterResponse(&responses[i].result, responses[i].body); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	summary := set.summary()
+	if len(summary) != 2 {
+		t.Fatalf("Expected 2 clusters but got %d", len(summary))
+	}
+
+	expected := []string{"GET https://example.com/a", "GET https://example.com/b", "POST https://example.com/a"}
+	if summary[0].StatusCode != 404 || summary[0].Count != 3 || strings.Join(summary[0].URLs, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the 404 cluster with %v but got %v", expected, summary[0])
+	}
+	if summary[1].StatusCode != 403 || summary[1].Count != 2 {
+		t.Errorf("Expected the 403 cluster with 2 responses but got %v", summary[1])
+	}
+}
+
+func TestWriteClusters(t *testing.T) {
+	var builder strings.Builder
+	writer := bufio.NewWriter(&builder)
+	writeClusters(writer, []responseCluster{{StatusCode: 404, Hash: "0a1b2c3d4e5f", Length: 18, Count: 2, URLs: []string{"GET https://example.com/a", "GET https://example.com/b"}}})
+	_ = writer.Flush()
+
+	expected := "Clusters of Identical Responses\n\n| 404 | 0a1b2c3d4e5f => Length: 18, Responses: 2\n    GET https://example.com/a\n    GET https://example.com/b\n\n"
+	if builder.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, builder.String())
+	}
+}
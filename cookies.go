@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// reads a cookies file in the Netscape format (as exported by browser extensions or written by curl's "-c") into a
+// cookie jar. Expired cookies are skipped
+func readCookieFile(path string) (http.CookieJar, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var count int
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		// cookies with the HttpOnly flag are prefixed with "#HttpOnly_", all other lines starting with "#" are comments
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// domain, include subdomains, path, secure, expiry, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) < 6 {
+			return nil, 0, fmt.Errorf("invalid cookie in line %d: %s", lineNumber, line)
+		}
+		value := ""
+		if len(fields) > 6 {
+			value = fields[6]
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid expiry in line %d: %s", lineNumber, fields[4])
+		}
+		// an expiry of 0 denotes a session cookie
+		if expiry != 0 && time.Unix(expiry, 0).Before(time.Now()) {
+			continue
+		}
+
+		cookie := &http.Cookie{
+			Name:   fields[5],
+			Value:  value,
+			Path:   fields[2],
+			Secure: strings.EqualFold(fields[3], "TRUE"),
+		}
+
+		// without the Domain attribute, the jar treats the cookie as host-only
+		host := strings.TrimPrefix(fields[0], ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&neturl.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return jar, count, nil
+}
+
+// returns a RequestHook that adds the cookies of the jar that match the domain and path of the request to its Cookie
+// header (after the cookies provided via `--headers`). Cookies that are already in the header (e.g. the rolling token
+// of a sticky session) are kept instead of being sent twice
+func cookieJarHook(jar http.CookieJar) probe.RequestHook {
+	return probe.RequestHookFunc(func(req *http.Request) error {
+		present := make(map[string]bool)
+		for _, cookie := range req.Cookies() {
+			present[cookie.Name] = true
+		}
+
+		var pairs []string
+		for _, cookie := range jar.Cookies(req.URL) {
+			if !present[cookie.Name] {
+				pairs = append(pairs, cookie.Name+"="+cookie.Value)
+			}
+		}
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		if existing := req.Header.Get("Cookie"); existing != "" {
+			pairs = append([]string{existing}, pairs...)
+		}
+		req.Header.Set("Cookie", strings.Join(pairs, "; "))
+
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCookieFile(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-cookies.txt")
+	content := "# Netscape HTTP Cookie File\n" +
+		".example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n" +
+		"#HttpOnly_app.example.com\tFALSE\t/admin\tTRUE\t0\tadmin\txyz\n" +
+		"app.example.com\tFALSE\t/\tFALSE\t1\texpired\told\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write cookies file: %v", err)
+	}
+
+	jar, count, err := readCookieFile(path)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 cookies but got %d (err: %v)", count, err)
+	}
+
+	tests := map[string]string{
+		"https://app.example.com/admin/users": "a=b; admin=xyz; session=abc",
+		"http://app.example.com/admin/users":  "a=b; session=abc",
+		"https://www.example.com/":            "a=b; session=abc",
+		"https://other.com/":                  "a=b",
+	}
+
+	hook := cookieJarHook(jar)
+	for url, expected := range tests {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Cookie", "a=b")
+
+		if err := hook.BeforeRequest(req); err != nil || req.Header.Get("Cookie") != expected {
+			t.Errorf("Expected %q for URL %s but got %q (err: %v)", expected, url, req.Header.Get("Cookie"), err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the origin sent by `--check-cors`. A server that allows it with credentials allows any origin
+const corsTestOrigin = "https://attacker.example"
+
+// set if `--check-cors` found endpoints that reflect arbitrary origins
+var corsFindings []corsFinding
+
+// an endpoint that allows the test origin to send credentialed requests
+type corsFinding struct {
+	URL string `json:"url"`
+	// the request that was allowed: "GET" (simple request) or "OPTIONS" (preflight)
+	Request          string `json:"request"`
+	AllowOrigin      string `json:"allow_origin"`
+	AllowCredentials bool   `json:"allow_credentials"`
+	AllowMethods     string `json:"allow_methods,omitempty"`
+}
+
+// checks if the CORS headers of the response allow the test origin to read credentialed responses
+func corsAllowsOrigin(header http.Header) bool {
+	allowOrigin := strings.TrimSpace(header.Get("Access-Control-Allow-Origin"))
+	allowCredentials := strings.EqualFold(strings.TrimSpace(header.Get("Access-Control-Allow-Credentials")), "true")
+
+	return allowCredentials && (allowOrigin == corsTestOrigin || allowOrigin == "null")
+}
+
+// sends a GET with the test origin and a preflight OPTIONS to every URL (with the configured headers) and returns the
+// endpoints that reflect the origin and allow credentials
+func checkCORS(urls map[string]bool, opts probe.Options) ([]corsFinding, error) {
+	// the hooks of the main scan (e.g. the verdict recording) must not see these requests
+	opts.ResponseHooks = nil
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := opts.Threads
+	if workers <= 0 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var findings []corsFinding
+
+	Info("Checking the CORS configuration of %d URLs with the origin %s", len(urls), corsTestOrigin)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				requests := []probe.Request{
+					{Method: http.MethodGet, URL: url, Header: http.Header{"Origin": {corsTestOrigin}}},
+					{Method: http.MethodOptions, URL: url, Header: http.Header{
+						"Origin":                         {corsTestOrigin},
+						"Access-Control-Request-Method":  {http.MethodGet},
+						"Access-Control-Request-Headers": {"authorization"},
+					}},
+				}
+
+				for _, request := range requests {
+					result := scanner.Do(context.Background(), request)
+					if handleHTTPError(result.Err, url) || !corsAllowsOrigin(result.Header) {
+						continue
+					}
+
+					mu.Lock()
+					findings = append(findings, corsFinding{
+						URL:              url,
+						Request:          request.Method,
+						AllowOrigin:      result.Header.Get("Access-Control-Allow-Origin"),
+						AllowCredentials: true,
+						AllowMethods:     result.Header.Get("Access-Control-Allow-Methods"),
+					})
+					mu.Unlock()
+
+					Warn("CORS misconfiguration: %s allows the origin %s with credentials (%s)", url, corsTestOrigin, request.Method)
+				}
+			}
+		}()
+	}
+
+	for url := range urls {
+		jobs <- url
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].URL != findings[j].URL {
+			return findings[i].URL < findings[j].URL
+		}
+		return findings[i].Request < findings[j].Request
+	})
+
+	return findings, nil
+}
+
+// writes the CORS misconfigurations found by `--check-cors`. Nothing is written if there are none
+func writeCORSFindings(writer *bufio.Writer, findings []corsFinding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("CORS Misconfigurations\n\n")
+	for _, f := range findings {
+		methods := ""
+		if f.AllowMethods != "" {
+			methods = ", Allow-Methods: " + f.AllowMethods
+		}
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s => Allow-Origin: %s, Allow-Credentials: true%s\n", f.Request, f.URL, f.AllowOrigin, methods))
+	}
+	_, _ = writer.WriteString("\n")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestCheckCORS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/reflect":
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		case "/preflight":
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			}
+		case "/wildcard":
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+	}))
+	defer ts.Close()
+
+	urls := map[string]bool{ts.URL + "/reflect": true, ts.URL + "/preflight": true, ts.URL + "/wildcard": true}
+	findings, err := checkCORS(urls, probe.Options{Threads: 2})
+	if err != nil {
+		t.Fatalf("Failed to check CORS: %v", err)
+	}
+
+	expected := []struct{ url, request string }{
+		{ts.URL + "/preflight", "OPTIONS"},
+		{ts.URL + "/reflect", "GET"},
+		{ts.URL + "/reflect", "OPTIONS"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings but got %v", len(expected), findings)
+	}
+
+	for i, e := range expected {
+		if findings[i].URL != e.url || findings[i].Request != e.request {
+			t.Errorf("Expected %s %s but got %s %s", e.request, e.url, findings[i].Request, findings[i].URL)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/term"
+)
+
+// the environment variable the passphrase of the credentials store is read from. If it isn't set, it's read from the
+// terminal (without echo) or stdin
+const passphraseEnv = "SESSIONPROBE_PASSPHRASE"
+
+// the format version of the credentials store, which is checked on load, so that a store of another version isn't
+// mistaken for one with a wrong passphrase
+const credentialsVersion = 1
+
+// PBKDF2 parameters for deriving the key of the credentials store from the passphrase
+const (
+	credentialsKDFIterations = 600000
+	credentialsSaltSize      = 16
+)
+
+// the encrypted credentials store on disk. The plaintext is a JSON object mapping credential names to headers
+type credentialsFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// the default location of the credentials store, e.g. "~/.config/sessionprobe/credentials.json"
+func defaultCredentialsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "sessionprobe-credentials.json"
+	}
+
+	return filepath.Join(dir, "sessionprobe", "credentials.json")
+}
+
+// decrypts the credentials store. A missing store is empty
+func loadCredentials(path string, passphrase string) (map[string]map[string][]string, error) {
+	credentials := make(map[string]map[string][]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file credentialsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse the credentials store: %w", err)
+	}
+	if file.Version != credentialsVersion {
+		return nil, fmt.Errorf("unsupported version %d of the credentials store (expected %d), it may have been written by another version of sessionprobe", file.Version, credentialsVersion)
+	}
+
+	aead, err := newCredentialsCipher(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the credentials store (wrong passphrase?)")
+	}
+
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse the decrypted credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// encrypts the credentials with a fresh salt and nonce and writes them to the store, which is only readable by the user.
+// The store is replaced via a temporary file, so that it's never half-written and an existing store that was readable
+// by others gets the restricted permissions as well
+func saveCredentials(path string, passphrase string, credentials map[string]map[string][]string) error {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	file := credentialsFile{Version: credentialsVersion, Salt: make([]byte, credentialsSaltSize)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+
+	aead, err := newCredentialsCipher(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// the temporary file is created with the permissions 0600
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// AES-256-GCM with a key derived from the passphrase
+func newCredentialsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("the passphrase of the credentials store is empty")
+	}
+
+	block, err := aes.NewCipher(credentialsKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// derives the AES-256 key from the passphrase via PBKDF2 with HMAC-SHA256
+func credentialsKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, credentialsKDFIterations, 32, sha256.New)
+}
+
+// reads the passphrase from the environment or, if it isn't set there, from the terminal without echoing it. If stdin
+// isn't a terminal (e.g. it's piped), the first line of the reader is the passphrase. With confirm (i.e. when a new
+// store is created), a passphrase typed in the terminal has to be entered twice
+func readPassphrase(reader *bufio.Reader, confirm bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Passphrase of the credentials store: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return "", fmt.Errorf("failed to read the passphrase: %w", err)
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	passphrase, err := readHiddenLine(fd, "Passphrase of the credentials store: ")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+
+	repeated, err := readHiddenLine(fd, "Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if repeated != passphrase {
+		return "", fmt.Errorf("the passphrases don't match")
+	}
+
+	return passphrase, nil
+}
+
+// prompts for a line on the terminal without echoing it
+func readHiddenLine(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %w", err)
+	}
+
+	return string(line), nil
+}
+
+// returns the headers of a stored credential, for `--auth`
+func credentialHeaders(path string, name string) (map[string][]string, error) {
+	passphrase, err := readPassphrase(bufio.NewReader(os.Stdin), false)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := loadCredentials(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, ok := credentials[name]
+	if !ok {
+		return nil, fmt.Errorf("no credential named %s in %s", name, path)
+	}
+
+	return headers, nil
+}
+
+func newAuthCmd() *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the encrypted credentials store",
+		Long: `Stores headers (e.g. session cookies or tokens) encrypted on disk, so that they can be used via --auth <name>
+instead of being passed on the command line. The passphrase is read from the ` + passphraseEnv + ` environment
+variable or, if it isn't set, from the terminal (without echo, and twice when the store is created) or the first line
+of stdin if it's piped.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add or replace a credential",
+		Long: `Adds or replaces a credential. Its headers are read in the format of the --headers-file (one "Key: Value" per
+line) from the --from file or, if it isn't provided, from stdin (after the passphrase).`,
+		Example: `./sessionprobe auth add admin --from ./admin-headers.txt
+./sessionprobe -u ./urls.txt --auth admin`,
+		Args: cobra.ExactArgs(1),
+		Run:  runAuthAdd,
+	}
+	addCmd.Flags().String("from", "", "file containing the headers of the credential (default: stdin)")
+
+	authCmd.AddCommand(addCmd, &cobra.Command{
+		Use:   "list",
+		Short: "List the names of the stored credentials",
+		Args:  cobra.NoArgs,
+		Run:   runAuthList,
+	}, &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a credential",
+		Args:  cobra.ExactArgs(1),
+		Run:   runAuthRemove,
+	})
+
+	return authCmd
+}
+
+func runAuthAdd(cmd *cobra.Command, args []string) {
+	// a new store gets the passphrase that's typed, so it has to be confirmed
+	_, statErr := os.Stat(credentialsPath)
+
+	stdin := bufio.NewReader(os.Stdin)
+	passphrase, err := readPassphrase(stdin, errors.Is(statErr, os.ErrNotExist))
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	credentials, err := loadCredentials(credentialsPath, passphrase)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	var headers map[string][]string
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		headers, err = readHeadersFile(from)
+	} else {
+		fmt.Fprintln(os.Stderr, "Headers (one \"Key: Value\" per line, end with Ctrl+D):")
+		headers, err = readHeaderLines(stdin)
+	}
+	if err != nil {
+		Error("Failed to read the headers: %s", err)
+		return
+	}
+	if len(headers) == 0 {
+		Error("The credential doesn't contain any headers")
+		return
+	}
+
+	credentials[args[0]] = headers
+	if err := saveCredentials(credentialsPath, passphrase, credentials); err != nil {
+		Error("Failed to save the credentials store: %s", err)
+		return
+	}
+	Info("Stored the credential %s (%d headers) in %s", args[0], len(headers), credentialsPath)
+}
+
+func runAuthList(cmd *cobra.Command, args []string) {
+	passphrase, err := readPassphrase(bufio.NewReader(os.Stdin), false)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	credentials, err := loadCredentials(credentialsPath, passphrase)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	var names []string
+	for name := range credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+func runAuthRemove(cmd *cobra.Command, args []string) {
+	passphrase, err := readPassphrase(bufio.NewReader(os.Stdin), false)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	credentials, err := loadCredentials(credentialsPath, passphrase)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if _, ok := credentials[args[0]]; !ok {
+		Error("No credential named %s in %s", args[0], credentialsPath)
+		return
+	}
+	delete(credentials, args[0])
+
+	if err := saveCredentials(credentialsPath, passphrase, credentials); err != nil {
+		Error("Failed to save the credentials store: %s", err)
+		return
+	}
+	Info("Removed the credential %s", args[0])
+}
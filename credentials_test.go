@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the key has to stay the same, so that existing stores can still be decrypted
+func TestCredentialsKey(t *testing.T) {
+	expected := "a1669ea2cbab0f15f29ded3b7c9683bc913c8d92f598bdc25fea6dc13f197717"
+	if key := hex.EncodeToString(credentialsKey("passphrase", []byte("salt"))); key != expected {
+		t.Errorf("Expected %s but got %s", expected, key)
+	}
+}
+
+func TestCredentialsStore(t *testing.T) {
+	EnsureOutputFolderExists(t)
+	path := filepath.Join("testing", "test-credentials.json")
+	os.Remove(path)
+
+	// a missing store is empty
+	credentials, err := loadCredentials(path, "passphrase")
+	if err != nil || len(credentials) != 0 {
+		t.Fatalf("Expected an empty store but got %v (err: %v)", credentials, err)
+	}
+
+	// a store of another version isn't mistaken for one with a wrong passphrase
+	if err := os.WriteFile(path, []byte(`{"version": 2}`), 0644); err != nil {
+		t.Fatalf("Failed to write the store: %v", err)
+	}
+	if _, err := loadCredentials(path, "passphrase"); err == nil || !strings.Contains(err.Error(), "unsupported version 2") {
+		t.Errorf("Expected an error for the unsupported version but got %v", err)
+	}
+
+	// the store that was readable by others is replaced by one that's only readable by the user
+	credentials["admin"] = map[string][]string{"Cookie": {"session=secret-admin-session"}}
+	if err := saveCredentials(path, "passphrase", credentials); err != nil {
+		t.Fatalf("Failed to save the credentials: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "secret-admin-session") {
+		t.Errorf("Expected the credentials to be encrypted on disk")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the store to be only readable by the user but got %v", info.Mode().Perm())
+	}
+
+	loaded, err := loadCredentials(path, "passphrase")
+	if err != nil || loaded["admin"]["Cookie"][0] != "session=secret-admin-session" {
+		t.Errorf("Expected the admin cookie but got %v (err: %v)", loaded, err)
+	}
+
+	if _, err := loadCredentials(path, "wrong"); err == nil {
+		t.Errorf("Expected an error for a wrong passphrase")
+	}
+}
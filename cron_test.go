@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"* * * * *":       time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC),
+		"*/15 * * * *":    time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC),
+		"0 2 * * *":       time.Date(2024, 5, 16, 2, 0, 0, 0, time.UTC),
+		"30 9-17 * * 1-5": time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC),
+		"0 0 * * 0":       time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * *":       time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		"@daily":          time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+		"@every 2h":       now.Add(2 * time.Hour),
+	}
+
+	for expr, expected := range tests {
+		schedule, err := parseCron(expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", expr, err)
+		}
+		if actual := schedule.next(now); !actual.Equal(expected) {
+			t.Errorf("Expected %s to fire at %s but got %s", expr, expected, actual)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "@every 10s"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
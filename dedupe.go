@@ -0,0 +1,160 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	neturl "net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// only exact duplicates are collapsed
+	dedupeExact = "exact"
+	// `item?id=1` and `item?id=2` are collapsed, no matter which query parameters are provided
+	dedupeQueryAgnostic = "query-agnostic"
+	// `item?id=1` and `item?id=2` are collapsed, but `item?id=1&page=2` is kept because its parameter names differ
+	dedupeParamNameOnly = "param-name-only"
+	// like exact, but the URLs are remembered in a bloom filter of fixed size instead of a map. A few URLs may be
+	// skipped as false positives (see bloomFalsePositiveRate), in exchange for deduplicating hundreds of millions of
+	// URLs with little memory
+	dedupeBloom = "bloom"
+)
+
+var dedupeModes = []string{dedupeExact, dedupeQueryAgnostic, dedupeParamNameOnly, dedupeBloom}
+
+// the rate of URLs the bloom filter wrongly reports as seen when it holds `--bloom-capacity` URLs
+const bloomFalsePositiveRate = 0.001
+
+func isValidDedupeMode(mode string) bool {
+	for _, m := range dedupeModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns the key under which the URL is deduplicated for the given mode. The first URL seen for a key is the one
+// that will be probed
+func dedupeKey(url string, mode string) string {
+	if mode == dedupeExact || mode == dedupeBloom {
+		return url
+	}
+
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return url
+	}
+
+	query := parsed.Query()
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+
+	if mode == dedupeQueryAgnostic {
+		return parsed.String()
+	}
+
+	// dedupeParamNameOnly
+	var names []string
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return parsed.String() + "?" + strings.Join(names, "&")
+}
+
+// remembers the dedupe keys of the URLs seen so far
+type urlDeduper interface {
+	// reports if the key was seen before, and remembers it otherwise
+	seen(key string) bool
+}
+
+// returns the deduper for the `--dedupe` mode: a bloom filter for "bloom", and otherwise a set of the keys or, if
+// hashed is set (with `--stream`), of their hashes
+func newURLDeduper(hashed bool) urlDeduper {
+	switch {
+	case dedupeMode == dedupeBloom:
+		return newBloomFilter(bloomCapacity, bloomFalsePositiveRate)
+	case hashed:
+		return make(hashDeduper)
+	default:
+		return make(exactDeduper)
+	}
+}
+
+// keeps the dedupe keys themselves, so that no URL is skipped by mistake
+type exactDeduper map[string]bool
+
+func (d exactDeduper) seen(key string) bool {
+	if d[key] {
+		return true
+	}
+	d[key] = true
+
+	return false
+}
+
+// keeps 64-bit hashes of the dedupe keys instead of the keys themselves, which takes a fraction of the memory for long
+// URLs. A collision (which would skip a URL) is possible, but unlikely even for millions of URLs
+type hashDeduper map[uint64]struct{}
+
+func (d hashDeduper) seen(key string) bool {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	sum := hash.Sum64()
+
+	if _, ok := d[sum]; ok {
+		return true
+	}
+	d[sum] = struct{}{}
+
+	return false
+}
+
+// a bloom filter of the dedupe keys, whose size only depends on the capacity it was created for. It never misses a
+// key that was seen, but may report a key as seen that wasn't
+type bloomFilter struct {
+	bits []uint64
+	// the number of bits set per key
+	hashes int
+}
+
+// creates a bloom filter that has the given false positive rate once it holds `capacity` keys
+func newBloomFilter(capacity int, falsePositiveRate float64) *bloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	// the optimal number of bits is -n*ln(p)/ln(2)^2, and of hash functions (bits/n)*ln(2)
+	bits := math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / float64(capacity) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	return &bloomFilter{bits: make([]uint64, int(bits)/64+1), hashes: hashes}
+}
+
+func (f *bloomFilter) seen(key string) bool {
+	// the bit positions are derived from two hashes (h1 + i*h2), which is as good as independent hash functions
+	h1, h2 := fnv.New64a(), fnv.New64()
+	h1.Write([]byte(key))
+	h2.Write([]byte(key))
+	a, b := h1.Sum64(), h2.Sum64()|1
+
+	size := uint64(len(f.bits)) * 64
+	seen := true
+	for i := 0; i < f.hashes; i++ {
+		bit := (a + uint64(i)*b) % size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			seen = false
+			f.bits[word] |= mask
+		}
+	}
+
+	return seen
+}
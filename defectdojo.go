@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the structure of DefectDojo's "Generic Findings Import" JSON format
+type defectDojoReport struct {
+	Findings []defectDojoFinding `json:"findings"`
+}
+
+type defectDojoFinding struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Severity    string               `json:"severity"`
+	Date        string               `json:"date"`
+	Active      bool                 `json:"active"`
+	Verified    bool                 `json:"verified"`
+	UniqueID    string               `json:"unique_id_from_tool"`
+	Endpoints   []defectDojoEndpoint `json:"endpoints"`
+	Tags        []string             `json:"tags,omitempty"`
+	// set if the analyst marked the URL as a false positive in the `--notes` file
+	FalsePositive bool `json:"false_p,omitempty"`
+}
+
+type defectDojoEndpoint struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Query    string `json:"query,omitempty"`
+}
+
+// builds the findings: access-control verdicts and IDOR candidates (if available), sensitive data, flagged paths and
+// accessible (2xx) URLs
+func buildDefectDojoFindings(urlStatuses map[int][]probe.Result) []defectDojoFinding {
+	date := time.Now().Format("2006-01-02")
+	var findings []defectDojoFinding
+
+	add := func(title string, severity string, method string, url string, evidence string) {
+		findings = append(findings, defectDojoFinding{
+			Title:       fmt.Sprintf("%s: %s %s", title, method, url),
+			Description: evidence,
+			Severity:    severity,
+			Date:        date,
+			Active:      true,
+			UniqueID:    title + " " + method + " " + url,
+			Endpoints:   newDefectDojoEndpoints(url),
+			Tags:        tagList(scanTags),
+		})
+		if note := annotationFor(method, url); note != nil {
+			finding := &findings[len(findings)-1]
+			finding.Description += "\n\nAnalyst note: " + note.String()
+			finding.FalsePositive = note.Disposition == "false-positive"
+		}
+	}
+
+	if comparison != nil {
+		for _, v := range comparison.verdicts() {
+			severity := map[string]string{verdictFullyExposed: "High", verdictPartiallyExposed: "Medium"}[v.Verdict]
+			if severity == "" {
+				continue
+			}
+			add("Broken access control ("+v.Verdict+")", severity, v.Method, v.URL, fmt.Sprintf(
+				"Status: %d (other role: %d)\nLength: %s (other role: %s)\nBody similarity: %.0f%%",
+				v.Status, v.OtherCode, formatLength(v.Length), formatLength(v.OtherLen), v.Similarity*100))
+		}
+	}
+
+	for _, c := range idorCandidates {
+		add("Potential IDOR", "High", c.Method, c.URL, fmt.Sprintf(
+			"Replacing %s with %s in %s returned a different successful response.\nStatus: %d (original: %d)\nLength: %s (original: %s)\nBody similarity: %.0f%%",
+			c.Param, c.Value, c.Original, c.StatusCode, c.OriginalStatus, formatLength(c.Length), formatLength(c.OriginalLength), c.Similarity*100))
+	}
+
+	var results []probe.Result
+	for _, statusResults := range urlStatuses {
+		results = append(results, statusResults...)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].URL != results[j].URL {
+			return results[i].URL < results[j].URL
+		}
+		return results[i].Method < results[j].Method
+	})
+
+	for _, result := range results {
+		evidence := fmt.Sprintf("Status: %d\nLength: %s", result.StatusCode, formatLength(result.Length))
+		if len(result.Labels) > 0 {
+			evidence += "\nLabels: " + strings.Join(result.Labels, ", ")
+		}
+
+		if len(result.Secrets) > 0 {
+			var secrets []string
+			for _, secret := range result.Secrets {
+				secrets = append(secrets, secret.Kind+": "+secret.Value)
+			}
+			add("Sensitive data in response", "Medium", result.Method, result.URL, evidence+"\n"+strings.Join(secrets, "\n"))
+		}
+
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			continue
+		}
+		// a severity assigned by the `--post-hook` takes precedence
+		if word := flaggedPath(result.URL, flagWords); word != "" {
+			add("Interesting path accessible ("+word+")", defectDojoSeverity(result.Severity, "Low"), result.Method, result.URL, evidence)
+		} else {
+			add("Accessible endpoint", defectDojoSeverity(result.Severity, "Info"), result.Method, result.URL, evidence)
+		}
+	}
+
+	return findings
+}
+
+// maps a severity (e.g. "high") to DefectDojo's severities, falling back to the given one if it's unknown
+func defectDojoSeverity(severity string, fallback string) string {
+	for _, known := range []string{"Critical", "High", "Medium", "Low", "Info"} {
+		if strings.EqualFold(severity, known) {
+			return known
+		}
+	}
+
+	return fallback
+}
+
+func newDefectDojoEndpoints(rawURL string) []defectDojoEndpoint {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	port, _ := strconv.Atoi(parsed.Port())
+	return []defectDojoEndpoint{{
+		Protocol: parsed.Scheme,
+		Host:     parsed.Hostname(),
+		Port:     port,
+		Path:     parsed.Path,
+		Query:    parsed.RawQuery,
+	}}
+}
+
+// writes the findings in DefectDojo's generic import format
+func writeDefectDojoFile(urlStatuses map[int][]probe.Result, path string) (int, error) {
+	report := defectDojoReport{Findings: buildDefectDojoFindings(urlStatuses)}
+	if report.Findings == nil {
+		report.Findings = []defectDojoFinding{}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	return len(report.Findings), os.WriteFile(path, data, 0644)
+}
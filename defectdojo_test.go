@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestBuildDefectDojoFindings(t *testing.T) {
+	flagWords = []string{"admin"}
+	defer func() {
+		flagWords = nil
+	}()
+
+	urlStatuses := map[int][]probe.Result{
+		200: {
+			{Method: "GET", URL: "https://example.com:8443/admin?tab=users", StatusCode: 200, Length: 10},
+			{Method: "GET", URL: "https://example.com/profile", StatusCode: 200, Length: 20, Secrets: []probe.Secret{{Kind: "email", Value: "a@example.com"}}},
+		},
+		403: {{Method: "GET", URL: "https://example.com/secret", StatusCode: 403}},
+	}
+
+	findings := buildDefectDojoFindings(urlStatuses)
+
+	expected := []struct{ title, severity string }{
+		{"Sensitive data in response: GET https://example.com/profile", "Medium"},
+		{"Accessible endpoint: GET https://example.com/profile", "Info"},
+		{"Interesting path accessible (admin): GET https://example.com:8443/admin?tab=users", "Low"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings but got %v", len(expected), findings)
+	}
+
+	for i, e := range expected {
+		if findings[i].Title != e.title || findings[i].Severity != e.severity {
+			t.Errorf("Expected %q (%s) but got %q (%s)", e.title, e.severity, findings[i].Title, findings[i].Severity)
+		}
+	}
+
+	endpoint := findings[2].Endpoints[0]
+	if endpoint.Host != "example.com" || endpoint.Port != 8443 || endpoint.Path != "/admin" || endpoint.Query != "tab=users" {
+		t.Errorf("Unexpected endpoint: %+v", endpoint)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffNew           = "NEW"
+	diffRemoved       = "REMOVED"
+	diffStatusChanged = "STATUS CHANGED"
+	diffLengthChanged = "LENGTH CHANGED"
+)
+
+// a single difference between two JSON output files
+type resultDiff struct {
+	Kind   string
+	Method string
+	URL    string
+	Old    *jsonResult
+	New    *jsonResult
+}
+
+// reports if the URL became accessible (i.e. returns a 2xx status code now, but didn't before)
+func (d resultDiff) newlyAccessible() bool {
+	if d.New == nil || d.New.StatusCode < 200 || d.New.StatusCode > 299 {
+		return false
+	}
+
+	return d.Old == nil || d.Old.StatusCode < 200 || d.Old.StatusCode > 299
+}
+
+func (d resultDiff) String() string {
+	switch d.Kind {
+	case diffNew:
+		return fmt.Sprintf("[%s] %s %s => %d (Length: %s)", d.Kind, d.Method, d.URL, d.New.StatusCode, formatLength(d.New.Length))
+	case diffRemoved:
+		return fmt.Sprintf("[%s] %s %s (was %d, Length: %s)", d.Kind, d.Method, d.URL, d.Old.StatusCode, formatLength(d.Old.Length))
+	case diffStatusChanged:
+		return fmt.Sprintf("[%s] %s %s: %d => %d", d.Kind, d.Method, d.URL, d.Old.StatusCode, d.New.StatusCode)
+	default:
+		return fmt.Sprintf("[%s] %s %s: %s => %s", d.Kind, d.Method, d.URL, formatLength(d.Old.Length), formatLength(d.New.Length))
+	}
+}
+
+func newDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <old.json> <new.json>",
+		Short: "Compare two JSON result files",
+		Long: `Compares two result files written via --out-json and reports URLs whose status code or length changed,
+as well as URLs that only appear in one of the files (e.g. new endpoints or fixed issues). URLs marked as
+false-positive in the --notes file are left out.`,
+		Example: `./sessionprobe diff ./before-fix.json ./after-fix.json`,
+		Args:    cobra.ExactArgs(2),
+		Run:     runDiff,
+	}
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	oldReport, err := readJSONFile(args[0])
+	if err != nil {
+		Error("Failed to read %s: %s", args[0], err)
+		return
+	}
+
+	newReport, err := readJSONFile(args[1])
+	if err != nil {
+		Error("Failed to read %s: %s", args[1], err)
+		return
+	}
+
+	if err := loadNotesFile(); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	diffs := diffResults(oldReport.Results, newReport.Results)
+	if len(diffs) == 0 {
+		Info("No differences found")
+		return
+	}
+
+	for _, d := range diffs {
+		if d.newlyAccessible() {
+			// newly accessible pages are the most interesting changes, e.g. when retesting a fix
+			color.Red("%s", d)
+		} else {
+			fmt.Println(d)
+		}
+	}
+
+	Info("Found %d differences", len(diffs))
+}
+
+// compares the results of two scans by method and URL
+func diffResults(oldResults []jsonResult, newResults []jsonResult) []resultDiff {
+	key := func(r jsonResult) string {
+		return r.Method + " " + r.URL
+	}
+
+	oldByKey := make(map[string]jsonResult)
+	for _, r := range oldResults {
+		oldByKey[key(r)] = r
+	}
+
+	newByKey := make(map[string]jsonResult)
+	for _, r := range newResults {
+		newByKey[key(r)] = r
+	}
+
+	var diffs []resultDiff
+	for k, n := range newByKey {
+		n := n
+		if isFalsePositive(n.Method, n.URL) {
+			continue
+		}
+		o, ok := oldByKey[k]
+		switch {
+		case !ok:
+			diffs = append(diffs, resultDiff{Kind: diffNew, Method: n.Method, URL: n.URL, New: &n})
+		case o.StatusCode != n.StatusCode:
+			diffs = append(diffs, resultDiff{Kind: diffStatusChanged, Method: n.Method, URL: n.URL, Old: &o, New: &n})
+		case o.Length != n.Length:
+			diffs = append(diffs, resultDiff{Kind: diffLengthChanged, Method: n.Method, URL: n.URL, Old: &o, New: &n})
+		}
+	}
+
+	for k, o := range oldByKey {
+		o := o
+		if _, ok := newByKey[k]; !ok && !isFalsePositive(o.Method, o.URL) {
+			diffs = append(diffs, resultDiff{Kind: diffRemoved, Method: o.Method, URL: o.URL, Old: &o})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].URL != diffs[j].URL {
+			return diffs[i].URL < diffs[j].URL
+		}
+		return diffs[i].Method < diffs[j].Method
+	})
+
+	return diffs
+}
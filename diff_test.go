@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestDiffResults(t *testing.T) {
+	oldResults := []jsonResult{
+		{Method: "GET", URL: "https://example.com/admin", StatusCode: 403, Length: 10},
+		{Method: "GET", URL: "https://example.com/home", StatusCode: 200, Length: 100},
+		{Method: "GET", URL: "https://example.com/old", StatusCode: 200, Length: 5},
+		{Method: "GET", URL: "https://example.com/same", StatusCode: 200, Length: 5},
+	}
+	newResults := []jsonResult{
+		{Method: "GET", URL: "https://example.com/admin", StatusCode: 200, Length: 500},
+		{Method: "GET", URL: "https://example.com/home", StatusCode: 200, Length: 120},
+		{Method: "GET", URL: "https://example.com/new", StatusCode: 200, Length: 5},
+		{Method: "GET", URL: "https://example.com/same", StatusCode: 200, Length: 5},
+	}
+
+	diffs := diffResults(oldResults, newResults)
+
+	expected := map[string]string{
+		"https://example.com/admin": diffStatusChanged,
+		"https://example.com/home":  diffLengthChanged,
+		"https://example.com/new":   diffNew,
+		"https://example.com/old":   diffRemoved,
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %d differences but got %d: %v", len(expected), len(diffs), diffs)
+	}
+	for _, d := range diffs {
+		if expected[d.URL] != d.Kind {
+			t.Errorf("Expected %s for %s but got %s", expected[d.URL], d.URL, d.Kind)
+		}
+	}
+
+	if !diffs[0].newlyAccessible() {
+		t.Errorf("Expected %s to be newly accessible", diffs[0].URL)
+	}
+}
+
+func TestJSONFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	urlStatuses := map[int][]probe.Result{
+		200: {{Method: "GET", URL: "https://example.com/", StatusCode: 200, Length: 42, Labels: []string{"admin"}}},
+	}
+
+	if err := writeJSONFile(urlStatuses, nil, path); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+
+	report, err := readJSONFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read JSON file: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Length != 42 || report.Results[0].Labels[0] != "admin" {
+		t.Errorf("Unexpected results: %+v", report.Results)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionprobe/pkg/probe"
+
+	"github.com/spf13/pflag"
+)
+
+// how often the coordinator polls the workers for their progress
+var workerPollInterval = 2 * time.Second
+
+// the flags a distributed scan supports: the ones whose options are passed on to the workers (see scanRequest) and the
+// ones the coordinator applies itself, i.e. the inputs, the filtering of the URLs and the outputs. All other flags
+// would be silently ignored by the workers
+var workerFlags = map[string]bool{
+	// passed on to the workers (authentication options end up in the headers)
+	"headers": true, "headers-file": true, "auth": true, "credentials-file": true, "basic": true, "bearer": true,
+	"threads": true, "proxy": true, "skip-verification": true, "filter-regex": true, "filter-lengths": true,
+	"check-post": true, "check-put": true, "check-delete": true, "check-patch": true, "check-all": true,
+	// applied by the coordinator
+	"urls": true, "retry-file": true, "ignore-extensions": true, "ignore-css": true, "ignore-js": true,
+	"scope-include": true, "scope-exclude": true, "exclude-file": true, "allow-dangerous": true, "normalize": true,
+	"dedupe": true, "shard": true, "order": true, "flag-paths": true, "out": true, "out-json": true, "out-junit": true, "out-html": true, "output-dir": true, "output-dir-mode": true,
+	"output-encoding": true, "output-template": true, "group-by": true, "tag": true, "notes": true,
+	"export-defectdojo": true, "notify-slack": true, "notify-discord": true, "notify-teams": true,
+	"workers": true, "worker-token": true, "no-update-check": true, "pprof": true,
+}
+
+// returns the flags that were set, but aren't supported by a distributed scan (see workerFlags)
+func workerConflicts(flags *pflag.FlagSet) []string {
+	var conflicts []string
+	flags.Visit(func(flag *pflag.Flag) {
+		if !workerFlags[flag.Name] {
+			conflicts = append(conflicts, "--"+flag.Name)
+		}
+	})
+
+	return conflicts
+}
+
+// the part of a worker's scan status (see `sessionprobe serve`) the coordinator cares about
+type workerScan struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	Total     int        `json:"total_requests"`
+	Processed int        `json:"processed_requests"`
+	Stats     *scanStats `json:"stats"`
+	Error     string     `json:"error"`
+}
+
+// a client for the API of a single worker
+type workerClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// shards the URLs across the workers (which run `sessionprobe serve`), waits for all of them to complete and merges
+// their results and failed requests. Only the options that are supported by the API are passed on to the workers (see
+// workerConflicts)
+func distributeURLs(urls map[string]bool, opts probe.Options, workerURLs []string, token string) (map[int][]probe.Result, *scanStats, error) {
+	var urlList []string
+	for url := range urls {
+		urlList = append(urlList, url)
+	}
+	sort.Strings(urlList)
+
+	base := scanRequest{
+		Methods:    getMethods(),
+		Headers:    opts.Headers,
+		Threads:    opts.Threads,
+		Proxy:      opts.Proxy,
+		SkipVerify: opts.SkipVerification,
+	}
+	if opts.FilterRegex != nil {
+		base.FilterRegex = opts.FilterRegex.String()
+	}
+	for length := range opts.ExcludedLengths {
+		base.FilterLengths = append(base.FilterLengths, length)
+	}
+
+	shards := shardURLs(urlList, len(workerURLs))
+	stats := newScanStats(len(urlList))
+	urlStatuses := make(map[int][]probe.Result)
+
+	Info("Distributing %d unique URLs (deduplicated) across %d workers", len(urlList), len(workerURLs))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []string
+
+	for i, workerURL := range workerURLs {
+		if len(shards[i]) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(worker *workerClient, shard []string) {
+			defer wg.Done()
+
+			req := base
+			req.URLs = shard
+
+			results, failed, workerStats, err := worker.scan(req)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", worker.url, err))
+				return
+			}
+
+			for _, r := range results {
+				result := probe.Result{Method: r.Method, URL: r.URL, StatusCode: r.StatusCode, Length: r.Length, Truncated: r.Truncated, Labels: r.Labels, Matched: true}
+				urlStatuses[result.StatusCode] = append(urlStatuses[result.StatusCode], result)
+			}
+			failedRequests = append(failedRequests, failed...)
+			stats.merge(workerStats)
+		}(newWorkerClient(workerURL, token), shards[i])
+	}
+
+	wg.Wait()
+	stats.finish()
+
+	if len(errs) > 0 {
+		return urlStatuses, stats, fmt.Errorf("%d worker(s) failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return urlStatuses, stats, nil
+}
+
+// splits the URLs into n shards of (almost) equal size
+func shardURLs(urls []string, n int) [][]string {
+	shards := make([][]string, n)
+	for i, url := range urls {
+		shards[i%n] = append(shards[i%n], url)
+	}
+
+	return shards
+}
+
+func newWorkerClient(url string, token string) *workerClient {
+	return &workerClient{
+		url:    strings.TrimRight(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// submits the scan to the worker, waits for it to complete and fetches its results and failed requests
+func (w *workerClient) scan(req scanRequest) ([]jsonResult, []failedRequest, *scanStats, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var scan workerScan
+	if err := w.do(http.MethodPost, "/scans", body, &scan); err != nil {
+		return nil, nil, nil, err
+	}
+	Info("Worker %s started scan %s with %d requests", w.url, scan.ID, scan.Total)
+
+	for scan.Status == scanRunning {
+		time.Sleep(workerPollInterval)
+
+		if err := w.do(http.MethodGet, "/scans/"+scan.ID, nil, &scan); err != nil {
+			return nil, nil, nil, err
+		}
+		Info("Worker %s: %d/%d requests processed", w.url, scan.Processed, scan.Total)
+	}
+
+	if scan.Status != scanCompleted {
+		return nil, nil, nil, fmt.Errorf("scan %s ended with status %s", scan.ID, scan.Status)
+	}
+
+	var results []jsonResult
+	if err := w.do(http.MethodGet, "/scans/"+scan.ID+"/results", nil, &results); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var failed []failedRequest
+	if err := w.do(http.MethodGet, "/scans/"+scan.ID+"/errors", nil, &failed); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// the worker keeps a scan until it's deleted
+	if err := w.do(http.MethodDelete, "/scans/"+scan.ID, nil, &scan); err != nil {
+		Warn("Failed to delete scan %s on worker %s: %s", scan.ID, w.url, err)
+	}
+
+	return results, failed, scan.Stats, nil
+}
+
+// sends a request to the worker's API and decodes the JSON response into `out`
+func (w *workerClient) do(method string, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, w.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, apiErr["error"])
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
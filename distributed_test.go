@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+
+	"github.com/spf13/pflag"
+)
+
+func TestShardURLs(t *testing.T) {
+	shards := shardURLs([]string{"a", "b", "c", "d", "e"}, 2)
+	if len(shards) != 2 || len(shards[0]) != 3 || len(shards[1]) != 2 {
+		t.Errorf("Unexpected shards: %v", shards)
+	}
+}
+
+func TestDistributeURLs(t *testing.T) {
+	workerPollInterval = 10 * time.Millisecond
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	worker1 := httptest.NewServer(newScanManager().handler("secret"))
+	defer worker1.Close()
+	worker2 := httptest.NewServer(newScanManager().handler("secret"))
+	defer worker2.Close()
+
+	urls := map[string]bool{target.URL + "/a": true, target.URL + "/b": true, target.URL + "/c": true}
+	urlStatuses, stats, err := distributeURLs(urls, probe.Options{Threads: 2}, []string{worker1.URL, worker2.URL}, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(urlStatuses[200]) != 3 || stats.Requests != 3 || stats.StatusCounts[200] != 3 {
+		t.Errorf("Expected 3 merged results with status 200 but got %v (stats: %+v)", urlStatuses, stats)
+	}
+
+	// the failed requests of the workers end up in the coordinator's errors
+	defer func() { failedRequests = nil }()
+	failedRequests = nil
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if _, _, err := distributeURLs(map[string]bool{closed.URL + "/down": true}, probe.Options{Threads: 1}, []string{worker1.URL}, "secret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(failedRequests) != 1 || failedRequests[0].URL != closed.URL+"/down" {
+		t.Errorf("Expected the failed request of the worker but got %v", failedRequests)
+	}
+
+	// a worker with the wrong token fails
+	if _, _, err := distributeURLs(urls, probe.Options{Threads: 2}, []string{worker1.URL}, "wrong"); err == nil {
+		t.Errorf("Expected an error for a worker with the wrong token")
+	}
+}
+
+func TestWorkerConflicts(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArrayP("headers", "H", nil, "")
+	flags.String("out-json", "", "")
+	flags.String("sign", "", "")
+	flags.String("pre-hook", "", "")
+	flags.Int("threads", 10, "")
+
+	if err := flags.Parse([]string{"-H", "Cookie: a=b", "--out-json", "r.json", "--sign", "hmac-sha256", "--pre-hook", "./hook.sh"}); err != nil {
+		t.Fatalf("Failed to parse the flags: %v", err)
+	}
+
+	expected := []string{"--pre-hook", "--sign"}
+	if conflicts := workerConflicts(flags); !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected the conflicts %v but got %v", expected, conflicts)
+	}
+}
+
+// every flag of a scan is either supported by a distributed scan or known to be unsupported, so that a new flag is
+// neither silently dropped by the workers nor refused although the coordinator applies it
+func TestWorkerFlags(t *testing.T) {
+	unsupported := map[string]bool{
+		"audit-headers": true, "bloom-capacity": true, "body-sample": true, "bypass-403": true, "capture-headers": true,
+		"check-cors": true, "compare-headers": true, "compare-unauth": true, "cookie-file": true,
+		"correlation-header": true, "delay": true, "error-pause": true, "error-window": true, "es-auth": true,
+		"es-index": true, "es-url": true, "expect": true, "export-burp": true, "export-burp-status": true,
+		"extract-header": true, "graphql-queries": true, "host-header": true, "http1.0": true, "idor-params": true,
+		"idor-values": true, "interface": true, "jitter": true, "match-jsonpath": true, "match-xpath": true,
+		"matchers": true, "max-body-size": true, "max-error-rate": true, "max-redirects": true, "max-results": true,
+		"mutate-paths": true, "no-body": true, "no-keepalive": true, "normalize-regex": true, "notify-status": true,
+		"notify-url-regex": true, "notify-webhook": true, "port-map": true, "post-hook": true, "post-hook-body": true,
+		"pre-hook": true, "proxy-ca": true, "random-agent": true, "raw-headers": true, "repeat": true, "resolve": true,
+		"resolve-file": true, "rules": true, "scan-secrets": true, "sign": true, "sign-encoding": true,
+		"sign-header": true, "sign-input": true, "sign-key": true, "sni": true, "snippet": true, "source-ip": true,
+		"spoof-internal": true, "stats-interval": true, "sticky-sessions": true, "stop-on-status": true, "stream": true,
+		"tls-ciphers": true, "tls-max": true, "tls-min": true, "user-agent": true, "warm-up": true,
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addScanFlags(flags)
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if workerFlags[flag.Name] == unsupported[flag.Name] {
+			t.Errorf("Expected --%s to be either supported or unsupported by a distributed scan", flag.Name)
+		}
+	})
+
+	// the persistent flags of the root command
+	for name := range workerFlags {
+		if flags.Lookup(name) == nil && name != "notes" && name != "credentials-file" {
+			t.Errorf("Expected --%s to be a flag of a scan", name)
+		}
+	}
+}
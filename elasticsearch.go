@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the number of documents that are sent in a single bulk request
+const esBatchSize = 100
+
+// the Elasticsearch/OpenSearch shipper configured via `--es-url`. It's nil if no URL was provided
+var shipper *esShipper
+
+// bulk-indexes every result into an Elasticsearch or OpenSearch index while the scan is running
+type esShipper struct {
+	url           string
+	index         string
+	authorization string
+	scanStart     time.Time
+	client        *http.Client
+	batch         []esDocument
+	failed        int
+}
+
+// the document indexed for every result
+type esDocument struct {
+	Timestamp  time.Time           `json:"@timestamp"`
+	ScanStart  time.Time           `json:"scan_start"`
+	Tags       map[string]string   `json:"tags,omitempty"`
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"status_code,omitempty"`
+	Length     int                 `json:"length"`
+	DurationMs int64               `json:"duration_ms"`
+	Matched    bool                `json:"matched"`
+	Labels     []string            `json:"labels,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// creates a shipper for the given cluster URL and index. `auth` is either "user:pass" (basic authentication) or an
+// API key
+func newESShipper(url string, index string, auth string) *esShipper {
+	authorization := ""
+	if auth != "" {
+		if strings.Contains(auth, ":") {
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+		} else {
+			authorization = "ApiKey " + auth
+		}
+	}
+
+	return &esShipper{
+		url:           strings.TrimSuffix(url, "/"),
+		index:         index,
+		authorization: authorization,
+		scanStart:     time.Now().UTC(),
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// adds the result to the current batch and sends the batch once it's full
+func (s *esShipper) add(result probe.Result) {
+	doc := esDocument{
+		Timestamp:  time.Now().UTC(),
+		ScanStart:  s.scanStart,
+		Tags:       scanTags,
+		Method:     result.Method,
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		Length:     result.Length,
+		DurationMs: result.Duration.Milliseconds(),
+		Matched:    result.Matched,
+		Labels:     result.Labels,
+		Headers:    captureHeaders(result.Header),
+	}
+	if result.Err != nil {
+		doc.Error = result.Err.Error()
+	}
+
+	s.batch = append(s.batch, doc)
+	if len(s.batch) >= esBatchSize {
+		s.flush()
+	}
+}
+
+// sends the current batch via the bulk API. Failing batches are dropped (and counted), so that an unreachable cluster
+// doesn't stall the scan
+func (s *esShipper) flush() {
+	if len(s.batch) == 0 {
+		return
+	}
+
+	batch := s.batch
+	s.batch = nil
+
+	if err := s.bulk(batch); err != nil {
+		s.failed += len(batch)
+		Warn("Failed to index %d results in Elasticsearch: %s", len(batch), err)
+	}
+}
+
+func (s *esShipper) bulk(docs []esDocument) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]map[string]string{"index": {"_index": s.index}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url+"/_bulk", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.authorization != "" {
+		req.Header.Set("Authorization", s.authorization)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	// the bulk API responds with 200 even if single documents failed
+	var response struct {
+		Errors bool `json:"errors"`
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &response); err == nil && response.Errors {
+		return fmt.Errorf("some documents were rejected")
+	}
+
+	return nil
+}
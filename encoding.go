@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the options of `--output-encoding`
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF8BOM = "utf-8-bom"
+	encodingCRLF    = "crlf"
+	// shorthand for a UTF-8 BOM and CRLF line endings, which Excel on Windows expects of CSV files
+	encodingExcel = "excel"
+)
+
+var outputEncodings = []string{encodingUTF8, encodingUTF8BOM, encodingCRLF, encodingExcel}
+
+// the byte order mark, which tells e.g. Excel that the file is UTF-8
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// how the output file is encoded, e.g. so that a CSV file written via `--output-template` opens correctly in Excel
+type outputEncoding struct {
+	bom  bool
+	crlf bool
+}
+
+// parses a comma-separated list of encoding options, e.g. "utf-8-bom,crlf"
+func parseOutputEncoding(value string) (outputEncoding, error) {
+	var encoding outputEncoding
+	for _, option := range splitList(strings.ToLower(value)) {
+		switch option {
+		case encodingUTF8:
+		case encodingUTF8BOM:
+			encoding.bom = true
+		case encodingCRLF:
+			encoding.crlf = true
+		case encodingExcel:
+			encoding.bom, encoding.crlf = true, true
+		default:
+			return encoding, fmt.Errorf("invalid output encoding: %s (valid options: %s)", option, strings.Join(outputEncodings, ", "))
+		}
+	}
+
+	return encoding, nil
+}
+
+// writes the BOM (if enabled) and returns the writer the output is then written to
+func (e outputEncoding) writer(w io.Writer) (io.Writer, error) {
+	if e.bom {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.crlf {
+		return &crlfWriter{w: w}, nil
+	}
+
+	return w, nil
+}
+
+// converts "\n" line endings to "\r\n", keeping the ones that already are "\r\n"
+type crlfWriter struct {
+	w io.Writer
+	// whether the last byte written was a "\r", which may be followed by the "\n" in the next write
+	lastCR bool
+}
+
+func (c *crlfWriter) Write(p []byte) (int, error) {
+	converted := make([]byte, 0, len(p)+len(p)/16)
+	for _, b := range p {
+		if b == '\n' && !c.lastCR {
+			converted = append(converted, '\r')
+		}
+		converted = append(converted, b)
+		c.lastCR = b == '\r'
+	}
+
+	if _, err := c.w.Write(converted); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
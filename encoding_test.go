@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutputEncoding(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", "a,b\nc,d\r\n"},
+		{"utf-8", "a,b\nc,d\r\n"},
+		{"crlf", "a,b\r\nc,d\r\n"},
+		{"utf-8-bom", "\xef\xbb\xbfa,b\nc,d\r\n"},
+		{"Excel", "\xef\xbb\xbfa,b\r\nc,d\r\n"},
+		{"utf-8-bom, crlf", "\xef\xbb\xbfa,b\r\nc,d\r\n"},
+	}
+
+	for _, test := range tests {
+		encoding, err := parseOutputEncoding(test.value)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", test.value, err)
+		}
+
+		var buf bytes.Buffer
+		writer, err := encoding.writer(&buf)
+		if err != nil {
+			t.Fatalf("Failed to create the writer: %v", err)
+		}
+		// the "\r\n" is split across two writes
+		writer.Write([]byte("a,b\nc,d\r"))
+		writer.Write([]byte("\n"))
+
+		if buf.String() != test.expected {
+			t.Errorf("Expected %q for %q but got %q", test.expected, test.value, buf.String())
+		}
+	}
+
+	if _, err := parseOutputEncoding("latin1"); err == nil {
+		t.Errorf("Expected an error for an unsupported encoding")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the file (next to the output file) the URLs of the failed requests are written to, so that they can be re-checked
+// via `--retry-file`
+const failedURLsFile = "failed.txt"
+
+// the requests that failed (e.g. because of timeouts, DNS or TLS errors), so that they show up in the output
+var failedRequests []failedRequest
+
+// a request that didn't get a response
+type failedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// the kind of the error, e.g. "timeout" or "DNS"
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	// the ID sent in the `--correlation-header`, if the request got that far
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+func newFailedRequest(result probe.Result) failedRequest {
+	return failedRequest{
+		Method:        result.Method,
+		URL:           result.URL,
+		Kind:          errorKind(result.Err),
+		Reason:        result.Err.Error(),
+		CorrelationID: result.CorrelationID,
+	}
+}
+
+// classifies an error of a request, so that e.g. all DNS errors can be spotted at a glance
+func errorKind(err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, probe.ErrRedirectLoop):
+		return "redirect loop"
+	case errors.Is(err, probe.ErrTooManyRedirects):
+		return "too many redirects"
+	case errors.As(err, &dnsErr):
+		return "DNS"
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr), errors.As(err, &recordErr):
+		return "TLS"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// writes the failed requests, sorted by URL and method
+func writeFailedRequests(writer *bufio.Writer, failed []failedRequest) {
+	if len(failed) == 0 {
+		return
+	}
+
+	sorted := append([]failedRequest{}, failed...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].URL != sorted[j].URL {
+			return sorted[i].URL < sorted[j].URL
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	_, _ = writer.WriteString("Errors\n\n")
+	for _, f := range sorted {
+		id := ""
+		if f.CorrelationID != "" {
+			id = fmt.Sprintf(", ID: %s", f.CorrelationID)
+		}
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s, Error: %s (%s)%s\n", f.Method, f.URL, f.Kind, f.Reason, id))
+	}
+	_, _ = writer.WriteString("\n")
+}
+
+// writes the unique URLs of the failed requests to a file, one per line. If no request failed, nothing is written and
+// an existing file (e.g. the retry list of an earlier scan) is left alone. Returns the number of URLs written
+func writeFailedURLs(failed []failedRequest, path string) (int, error) {
+	if len(failed) == 0 {
+		return 0, nil
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, f := range failed {
+		if !seen[f.URL] {
+			seen[f.URL] = true
+			urls = append(urls, f.URL)
+		}
+	}
+	sort.Strings(urls)
+
+	return len(urls), os.WriteFile(path, []byte(strings.Join(urls, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&net.DNSError{Err: "no such host", Name: "doesnotexist.invalid", IsNotFound: true}, "DNS"},
+		{fmt.Errorf("Get: %w", context.DeadlineExceeded), "timeout"},
+		{fmt.Errorf("Get: %w", probe.ErrRedirectLoop), "redirect loop"},
+		{fmt.Errorf("Get: %w", probe.ErrTooManyRedirects), "too many redirects"},
+		{fmt.Errorf("something else"), "error"},
+	}
+
+	for _, test := range tests {
+		if kind := errorKind(test.err); kind != test.expected {
+			t.Errorf("Expected %s for %v but got %s", test.expected, test.err, kind)
+		}
+	}
+}
+
+func TestFailedRequestsInOutput(t *testing.T) {
+	// a port that nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	url := "http://" + listener.Addr().String() + "/down"
+	listener.Close()
+
+	scanner, err := probe.NewScanner(probe.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create the scanner: %v", err)
+	}
+	result := scanner.Do(context.Background(), probe.Request{Method: "GET", URL: url})
+	if result.Err == nil {
+		t.Fatalf("Expected the request to %s to fail", url)
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writeFailedRequests(writer, []failedRequest{newFailedRequest(result)})
+	writer.Flush()
+
+	expected := fmt.Sprintf("| GET | %s, Error: connection refused (", url)
+	if !strings.HasPrefix(buf.String(), "Errors\n\n") || !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected the output to contain %q but got %q", expected, buf.String())
+	}
+}
+
+func TestWriteFailedURLs(t *testing.T) {
+	EnsureOutputFolderExists(t)
+	path := filepath.Join("testing", failedURLsFile)
+
+	failed := []failedRequest{
+		{Method: "POST", URL: "https://example.com/b"},
+		{Method: "GET", URL: "https://example.com/b"},
+		{Method: "GET", URL: "https://example.com/a"},
+	}
+	count, err := writeFailedURLs(failed, path)
+	if err != nil {
+		t.Fatalf("Failed to write the failed URLs: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	expected := "https://example.com/a\nhttps://example.com/b\n"
+	if count != 2 || string(data) != expected {
+		t.Errorf("Expected 2 URLs (%q) but got %d (%q)", expected, count, string(data))
+	}
+
+	// a run without failures doesn't touch the file of a previous run
+	if count, err := writeFailedURLs(nil, path); err != nil || count != 0 {
+		t.Fatalf("Expected no URLs to be written but got %d (err: %v)", count, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != expected {
+		t.Errorf("Expected %s to be kept but got %q", path, string(data))
+	}
+}
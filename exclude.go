@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// the rules of the `--exclude-file`
+var excludeRules []excludeRule
+
+// a gitignore-like pattern of the `--exclude-file`
+type excludeRule struct {
+	regex *regexp.Regexp
+	// patterns with a "/" (other than a trailing one) are matched against the path from its start, all others against
+	// the host and every segment of the path
+	anchored bool
+	// a pattern starting with "!" brings back URLs excluded by an earlier pattern
+	negate bool
+}
+
+// reads the exclusion patterns, one per line. Like in a .gitignore, empty lines and lines starting with `#` are
+// skipped, and the last matching pattern decides whether a URL is excluded. The patterns are
+//
+//	*.png           a host or path segment ("*" and "?" don't match "/")
+//	staging.*       e.g. the host staging.example.com
+//	/api/v1/health  the path and everything below it
+//	/static/**/*.js "**" matches any number of path segments
+//	!/static/app.js re-includes a URL excluded by an earlier pattern
+func loadExcludeFile(path string) ([]excludeRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []excludeRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		rule := excludeRule{}
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		}
+
+		// a trailing "/" only matches what's below the path, e.g. "/admin/" matches "/admin/users" but not "/admin"
+		belowOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		rule.anchored = strings.Contains(pattern, "/")
+		expr := "^" + globToRegex(strings.TrimPrefix(pattern, "/"))
+		switch {
+		case rule.anchored && belowOnly:
+			expr += "/.*$"
+		case rule.anchored:
+			expr += "(/.*)?$"
+		default:
+			expr += "$"
+		}
+
+		if rule.regex, err = regexp.Compile(expr); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// translates a glob pattern into a regex, where "**" matches anything and "*" and "?" match anything but "/"
+func globToRegex(pattern string) string {
+	var builder strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			// also matches no directory at all, e.g. "/static/**/*.js" matches "/static/app.js"
+			builder.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			builder.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			builder.WriteString("[^/]*")
+		case pattern[i] == '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	return builder.String()
+}
+
+// checks the URL against the rules of the `--exclude-file`. The query string and fragment aren't taken into account
+func isExcluded(url string) bool {
+	if len(excludeRules) == 0 {
+		return false
+	}
+
+	host, urlPath := "", url
+	if parsed, err := neturl.Parse(url); err == nil {
+		host, urlPath = parsed.Hostname(), parsed.Path
+	}
+	urlPath = strings.TrimPrefix(urlPath, "/")
+	segments := append([]string{host}, strings.Split(urlPath, "/")...)
+
+	excluded := false
+	for _, rule := range excludeRules {
+		if rule.negate != excluded {
+			// the rule can't change the outcome
+			continue
+		}
+		if rule.matches(urlPath, segments) {
+			excluded = !rule.negate
+		}
+	}
+
+	return excluded
+}
+
+func (r excludeRule) matches(urlPath string, segments []string) bool {
+	if r.anchored {
+		return r.regex.MatchString(urlPath)
+	}
+
+	for _, segment := range segments {
+		if segment != "" && r.regex.MatchString(segment) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsExcluded(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-scope.exclude")
+	content := "# static files\n" +
+		"*.png\n" +
+		"/static/**/*.js\n" +
+		"!/static/app.js\n" +
+		"\n" +
+		"/api/v1/health\n" +
+		"/admin/\n" +
+		"staging.*\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write exclude file: %v", err)
+	}
+
+	rules, err := loadExcludeFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load exclude file: %v", err)
+	}
+	excludeRules = rules
+	defer func() {
+		excludeRules = nil
+	}()
+
+	tests := map[string]bool{
+		"https://example.com/img/logo.png?v=2":        true,
+		"https://example.com/logo.png.html":           false,
+		"https://example.com/static/js/vendor/lib.js": true,
+		"https://example.com/static/main.js":          true,
+		"https://example.com/static/app.js":           false,
+		"https://example.com/api/v1/health":           true,
+		"https://example.com/api/v1/health/db":        true,
+		"https://example.com/api/v1/healthz":          false,
+		"https://example.com/v2/api/v1/health":        false,
+		"https://example.com/admin":                   false,
+		"https://example.com/admin/users":             true,
+		"https://staging.example.com/dashboard":       true,
+		"https://example.com/staging":                 false,
+		"https://example.com/dashboard":               false,
+	}
+
+	for url, expected := range tests {
+		if actual := isExcluded(url); actual != expected {
+			t.Errorf("Expected %v for URL %s but got %v", expected, url, actual)
+		}
+	}
+}
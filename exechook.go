@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the exit code with which the `--post-hook` command drops a result from the output
+const postHookDropExitCode = 1
+
+// the request that is passed to the `--pre-hook` command on stdin
+type hookRequest struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body,omitempty"`
+}
+
+// the (optional) output of the `--pre-hook` command. The headers replace the respective headers of the request, and a
+// non-nil body replaces its body
+type hookRequestChanges struct {
+	Headers map[string][]string `json:"headers"`
+	Body    *string             `json:"body"`
+}
+
+// a RequestHook that runs an external command for every request, e.g. to implement a custom authentication scheme
+type commandRequestHook struct {
+	command []string
+}
+
+func newCommandRequestHook(command string) (*commandRequestHook, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the pre-hook command is empty")
+	}
+
+	return &commandRequestHook{command: fields}, nil
+}
+
+// passes the request as JSON to the command and applies the changes it prints (if any). A failing command aborts the
+// request
+func (h *commandRequestHook) BeforeRequest(req *http.Request) error {
+	body, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+
+	input, err := json.Marshal(hookRequest{Method: req.Method, URL: req.URL.String(), Headers: req.Header, Body: body})
+	if err != nil {
+		return err
+	}
+
+	output, err := runHookCommand(req.Context(), h.command, input)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var changes hookRequestChanges
+	if err := json.Unmarshal(output, &changes); err != nil {
+		return fmt.Errorf("invalid output of the pre-hook: %w", err)
+	}
+
+	for name, values := range changes.Headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if changes.Body != nil {
+		data := []byte(*changes.Body)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	return nil
+}
+
+// runs a hook command with the given stdin and returns its stdout. The stderr of the command is part of the error
+func runHookCommand(ctx context.Context, command []string, input []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// the result that is passed to the `--post-hook` command on stdin
+type hookResult struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"status_code"`
+	Length     int                 `json:"length"`
+	Headers    map[string][]string `json:"headers"`
+	Labels     []string            `json:"labels,omitempty"`
+	// only set with `--post-hook-body`
+	Body string `json:"body,omitempty"`
+}
+
+// the (optional) output of the `--post-hook` command
+type hookResultChanges struct {
+	Labels   []string `json:"labels"`
+	Severity string   `json:"severity"`
+}
+
+// a ResponseHook that runs an external command for every result, e.g. for custom triage logic. The command drops the
+// result from the output by exiting with 1, and may print labels and a severity to be added to the result
+type commandResponseHook struct {
+	command  []string
+	withBody bool
+}
+
+func newCommandResponseHook(command string, withBody bool) (*commandResponseHook, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the post-hook command is empty")
+	}
+
+	return &commandResponseHook{command: fields, withBody: withBody}, nil
+}
+
+func (h *commandResponseHook) AfterResponse(result *probe.Result, body []byte) error {
+	input := hookResult{
+		Method:     result.Method,
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		Length:     result.Length,
+		Headers:    result.Header,
+		Labels:     result.Labels,
+	}
+	if h.withBody {
+		input.Body = string(body)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	output, err := runHookCommand(context.Background(), h.command, data)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == postHookDropExitCode {
+		result.Matched = false
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var changes hookResultChanges
+	if err := json.Unmarshal(output, &changes); err != nil {
+		return fmt.Errorf("invalid output of the post-hook: %w", err)
+	}
+	result.Labels = append(result.Labels, changes.Labels...)
+	if changes.Severity != "" {
+		result.Severity = strings.ToLower(changes.Severity)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+// writes an executable shell script into the testing folder
+func writeHookScript(t *testing.T, name string, script string) string {
+	EnsureOutputFolderExists(t)
+
+	path, err := filepath.Abs(filepath.Join("testing", name))
+	if err != nil {
+		t.Fatalf("Failed to resolve the script path: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Failed to write the script: %v", err)
+	}
+
+	return path
+}
+
+func TestCommandRequestHook(t *testing.T) {
+	// the script checks the input and replaces the Authorization header and the body
+	script := writeHookScript(t, "pre-hook.sh", `input=$(cat)
+case "$input" in
+  *'"method":"POST"'*'"body":"original"'*) ;;
+  *) echo "unexpected input: $input" >&2; exit 1 ;;
+esac
+echo '{"headers": {"Authorization": ["Custom signed"]}, "body": "modified"}'
+`)
+
+	hook, err := newCommandRequestHook(script)
+	if err != nil {
+		t.Fatalf("Failed to create the hook: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewReader([]byte("original")))
+	req.Header.Set("Authorization", "Bearer old")
+	if err := hook.BeforeRequest(req); err != nil {
+		t.Fatalf("The pre-hook failed: %v", err)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get("Authorization") != "Custom signed" || string(body) != "modified" || req.ContentLength != 8 {
+		t.Errorf("Expected the header and body to be replaced but got %q and %q", req.Header.Get("Authorization"), body)
+	}
+}
+
+func TestCommandRequestHookFailure(t *testing.T) {
+	hook, _ := newCommandRequestHook(writeHookScript(t, "failing-hook.sh", "echo broken >&2\nexit 3\n"))
+
+	req, _ := http.NewRequest("GET", "https://example.com", nil)
+	if err := hook.BeforeRequest(req); err == nil {
+		t.Errorf("Expected a failing pre-hook to abort the request")
+	}
+
+	// no output means no changes
+	hook, _ = newCommandRequestHook(writeHookScript(t, "noop-hook.sh", "cat > /dev/null\n"))
+	req.Header.Set("X-Test", "value")
+	if err := hook.BeforeRequest(req); err != nil || req.Header.Get("X-Test") != "value" {
+		t.Errorf("Expected the request to be unchanged but got %v (err: %v)", req.Header, err)
+	}
+
+	if _, err := newCommandRequestHook("  "); err == nil {
+		t.Errorf("Expected an error for an empty command")
+	}
+}
+
+func TestCommandResponseHook(t *testing.T) {
+	// drops 404s, and labels responses whose body contains "admin"
+	script := writeHookScript(t, "post-hook.sh", `input=$(cat)
+case "$input" in
+  *'"status_code":404'*) exit 1 ;;
+  *'"body":"admin panel"'*) echo '{"labels": ["admin"], "severity": "HIGH"}' ;;
+esac
+`)
+
+	hook, err := newCommandResponseHook(script, true)
+	if err != nil {
+		t.Fatalf("Failed to create the hook: %v", err)
+	}
+
+	tests := []struct {
+		result   probe.Result
+		body     string
+		matched  bool
+		labels   string
+		severity string
+	}{
+		{probe.Result{StatusCode: 200, Matched: true}, "admin panel", true, "admin", "high"},
+		{probe.Result{StatusCode: 200, Matched: true}, "welcome", true, "", ""},
+		{probe.Result{StatusCode: 404, Matched: true}, "not found", false, "", ""},
+	}
+
+	for _, test := range tests {
+		result := test.result
+		if err := hook.AfterResponse(&result, []byte(test.body)); err != nil {
+			t.Fatalf("The post-hook failed: %v", err)
+		}
+		if result.Matched != test.matched || strings.Join(result.Labels, ",") != test.labels || result.Severity != test.severity {
+			t.Errorf("Expected matched=%v, labels=%q and severity=%q for %q but got %v, %v and %q",
+				test.matched, test.labels, test.severity, test.body, result.Matched, result.Labels, result.Severity)
+		}
+	}
+
+	// any other exit code is an error
+	hook, _ = newCommandResponseHook(writeHookScript(t, "failing-post-hook.sh", "exit 2\n"), false)
+	if err := hook.AfterResponse(&probe.Result{}, nil); err == nil {
+		t.Errorf("Expected an error for exit code 2")
+	}
+}
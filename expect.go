@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the expectations loaded via `--expect`. It's nil if no expectations file was provided
+var expectations *expectationSet
+
+// expected status codes per request (method + URL), used to turn a scan into an authorization regression test
+type expectationSet struct {
+	mu       sync.Mutex
+	expected map[string][]int
+	actual   map[string]int
+}
+
+// a request whose status code differed from the expected one(s). A status code of 0 means the request failed or the
+// URL wasn't checked at all
+type deviation struct {
+	Key      string
+	Expected []int
+	Actual   int
+}
+
+func (d deviation) String() string {
+	var expected []string
+	for _, code := range d.Expected {
+		expected = append(expected, strconv.Itoa(code))
+	}
+
+	actual := strconv.Itoa(d.Actual)
+	if d.Actual == 0 {
+		actual = "no response"
+	}
+
+	return fmt.Sprintf("%s: expected %s but got %s", d.Key, strings.Join(expected, " or "), actual)
+}
+
+// reads an expectations file. Every line contains a URL and the expected status code(s), optionally preceded by the
+// method (default GET), e.g. `https://example.com/admin 403` or `POST https://example.com/api/users 401,403`. Empty
+// lines and lines starting with `#` are skipped
+func loadExpectations(path string) (*expectationSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	set := &expectationSet{expected: make(map[string][]int), actual: make(map[string]int)}
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		method := "GET"
+		switch len(fields) {
+		case 2:
+		case 3:
+			method = strings.ToUpper(fields[0])
+			fields = fields[1:]
+		default:
+			return nil, fmt.Errorf("invalid expectation in line %d: %s", lineNumber, line)
+		}
+
+		var codes []int
+		for _, part := range strings.Split(fields[1], ",") {
+			code, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code in line %d: %s", lineNumber, part)
+			}
+			codes = append(codes, code)
+		}
+
+		set.expected[expectationKey(method, fields[0])] = codes
+	}
+
+	return set, scanner.Err()
+}
+
+func expectationKey(method string, url string) string {
+	return method + " " + url
+}
+
+// records the status code of a result. Failed requests are recorded as 0
+func (e *expectationSet) record(result probe.Result) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := result.StatusCode
+	if result.Err != nil {
+		status = 0
+	}
+	e.actual[expectationKey(result.Method, result.URL)] = status
+}
+
+// returns all requests whose status code didn't match the expectations, sorted by method and URL
+func (e *expectationSet) deviations() []deviation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var out []deviation
+	for key, expected := range e.expected {
+		actual := e.actual[key]
+		if !containsInt(expected, actual) {
+			out = append(out, deviation{Key: key, Expected: expected, Actual: actual})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+
+	return out
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
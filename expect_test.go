@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestExpectations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expected.txt")
+	content := `# comment
+https://example.com/admin 403
+https://example.com/dashboard 200
+POST https://example.com/api/users 401,403
+https://example.com/down 200
+https://example.com/unchecked 200
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write expectations file: %v", err)
+	}
+
+	set, err := loadExpectations(path)
+	if err != nil {
+		t.Fatalf("Failed to load expectations: %v", err)
+	}
+
+	set.record(probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 200})
+	set.record(probe.Result{Method: "GET", URL: "https://example.com/dashboard", StatusCode: 200})
+	set.record(probe.Result{Method: "POST", URL: "https://example.com/api/users", StatusCode: 401})
+	set.record(probe.Result{Method: "GET", URL: "https://example.com/down", Err: errors.New("timeout")})
+
+	deviations := set.deviations()
+	expected := []string{
+		"GET https://example.com/admin",
+		"GET https://example.com/down",
+		"GET https://example.com/unchecked",
+	}
+	if len(deviations) != len(expected) {
+		t.Fatalf("Expected %d deviations but got %v", len(expected), deviations)
+	}
+	for i, d := range deviations {
+		if d.Key != expected[i] {
+			t.Errorf("Expected deviation %s but got %s", expected[i], d.Key)
+		}
+	}
+}
+
+func TestLoadExpectations_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expected.txt")
+	os.WriteFile(path, []byte("https://example.com/admin forbidden\n"), 0644)
+
+	if _, err := loadExpectations(path); err == nil {
+		t.Errorf("Expected an error for an invalid status code")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the headers that reveal the technologies behind a host
+var fingerprintHeaders = []string{"Server", "X-Powered-By", "Via"}
+
+// the technologies seen per host during the scan
+var fingerprints = newFingerprintSet()
+
+type fingerprintSet struct {
+	mu    sync.Mutex
+	hosts map[string]map[string]map[string]bool
+}
+
+// the technologies of a single host, e.g. {"Server": ["nginx/1.25.3"], "X-Powered-By": ["PHP/8.2"]}
+type hostFingerprint struct {
+	Host    string              `json:"host"`
+	Headers map[string][]string `json:"headers"`
+}
+
+func newFingerprintSet() *fingerprintSet {
+	return &fingerprintSet{hosts: make(map[string]map[string]map[string]bool)}
+}
+
+// returns the fingerprint headers of the response, or nil if there are none
+func fingerprintOf(header http.Header) map[string]string {
+	var fingerprint map[string]string
+	for _, name := range fingerprintHeaders {
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			if fingerprint == nil {
+				fingerprint = make(map[string]string)
+			}
+			fingerprint[name] = value
+		}
+	}
+
+	return fingerprint
+}
+
+// returns a ResponseHook that records the fingerprint headers of every response per host
+func (f *fingerprintSet) hook() probe.ResponseHook {
+	return probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		fingerprint := fingerprintOf(result.Header)
+		if fingerprint == nil {
+			return nil
+		}
+
+		host := result.URL
+		if parsed, err := neturl.Parse(result.URL); err == nil {
+			host = parsed.Host
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.hosts[host] == nil {
+			f.hosts[host] = make(map[string]map[string]bool)
+		}
+		for name, value := range fingerprint {
+			if f.hosts[host][name] == nil {
+				f.hosts[host][name] = make(map[string]bool)
+			}
+			f.hosts[host][name][value] = true
+		}
+
+		return nil
+	})
+}
+
+// returns the technologies per host, sorted by host
+func (f *fingerprintSet) summary() []hostFingerprint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var summary []hostFingerprint
+	for host, headers := range f.hosts {
+		entry := hostFingerprint{Host: host, Headers: make(map[string][]string)}
+		for name, values := range headers {
+			for value := range values {
+				entry.Headers[name] = append(entry.Headers[name], value)
+			}
+			sort.Strings(entry.Headers[name])
+		}
+		summary = append(summary, entry)
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Host < summary[j].Host
+	})
+
+	return summary
+}
+
+// writes the technologies per host. Nothing is written if no host revealed any
+func writeFingerprints(writer *bufio.Writer, summary []hostFingerprint) {
+	if len(summary) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Technologies per Host\n\n")
+	for _, entry := range summary {
+		var parts []string
+		for _, name := range fingerprintHeaders {
+			if values := entry.Headers[name]; len(values) > 0 {
+				parts = append(parts, fmt.Sprintf("%s: %s", name, strings.Join(values, ", ")))
+			}
+		}
+		_, _ = writer.WriteString(fmt.Sprintf("| %s => %s\n", entry.Host, strings.Join(parts, "; ")))
+	}
+	_, _ = writer.WriteString("\n")
+}
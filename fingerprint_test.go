@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestFingerprintSummary(t *testing.T) {
+	set := newFingerprintSet()
+	hook := set.hook()
+
+	responses := []probe.Result{
+		{URL: "https://app.example.com/a", Header: http.Header{"Server": {"nginx"}, "X-Powered-By": {"PHP/8.2"}}},
+		{URL: "https://app.example.com/b", Header: http.Header{"Server": {"Apache"}, "X-Powered-By": {"PHP/8.2"}}},
+		{URL: "https://api.example.com/", Header: http.Header{"Via": {"1.1 varnish"}}},
+		{URL: "https://cdn.example.com/", Header: http.Header{"Content-Type": {"text/css"}}},
+	}
+	for i := range responses {
+		if err := hook.AfterResponse(&responses[i], nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := "[{api.example.com map[Via:[1.1 varnish]]} {app.example.com map[Server:[Apache nginx] X-Powered-By:[PHP/8.2]]}]"
+	if actual := fmt.Sprint(set.summary()); actual != expected {
+		t.Errorf("Expected %s but got %s", expected, actual)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/fatih/color"
+	"sessionprobe/pkg/probe"
+)
+
+// the watchlist of interesting path words that is used if --flag-paths isn't provided
+const defaultFlagPaths = "admin,backup,debug,actuator,swagger,internal,config,console,phpinfo,.git,.env"
+
+var magenta = color.New(color.FgMagenta, color.Bold).SprintFunc()
+
+// returns the first watchlist word that is contained in the path of the URL (case-insensitive), or "" if there is none
+func flaggedPath(rawURL string, words []string) string {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+	path = strings.ToLower(path)
+
+	for _, word := range words {
+		if strings.Contains(path, strings.ToLower(word)) {
+			return word
+		}
+	}
+
+	return ""
+}
+
+// labels results on the watchlist and logs them right away, so that they're visible during long scans
+func flagResult(result *probe.Result, words []string) {
+	word := flaggedPath(result.URL, words)
+	if word == "" {
+		return
+	}
+
+	result.Labels = append(result.Labels, "flagged:"+word)
+	Flag("Interesting path (%s): | %s | %d | %s => Length: %s", word, result.Method, result.StatusCode, result.URL, formatLength(result.Length))
+}
+
+// logs a highlighted message, which is used for results on the --flag-paths watchlist
+func Flag(format string, a ...interface{}) {
+	log.Printf("%s", magenta(fmt.Sprintf(format, a...)))
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestFlaggedPath(t *testing.T) {
+	words := splitList(defaultFlagPaths)
+
+	tests := map[string]string{
+		"https://example.com/Admin/users":         "admin",
+		"https://example.com/actuator/health":     "actuator",
+		"https://example.com/api/swagger-ui.html": "swagger",
+		"https://example.com/.git/config":         "config",
+		"https://example.com/dashboard":           "",
+		"https://example.com/search?q=admin":      "",
+	}
+
+	for url, expected := range tests {
+		if actual := flaggedPath(url, words); actual != expected {
+			t.Errorf("Expected %q for URL %s but got %q", expected, url, actual)
+		}
+	}
+}
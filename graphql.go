@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// labels added by the GraphQL response hook start with this prefix, so they can be told apart from matcher labels
+const graphqlErrorLabel = "graphql-error: "
+
+// the last path segments that identify a GraphQL endpoint
+var graphqlPaths = map[string]bool{"graphql": true, "graphiql": true, "gql": true}
+
+// the operation sent to the detected endpoints if no `--graphql-queries` file is provided
+var defaultGraphQLOperations = []graphqlOperation{{Query: "query { __typename }"}}
+
+// set if GraphQL endpoints were found among the URLs
+var graphqlResults []graphqlResult
+
+// a single GraphQL operation, as read from the `--graphql-queries` file
+type graphqlOperation struct {
+	// the name of the operation, which is sent as operationName (optional if the query contains a single operation)
+	Name      string                 `json:"name,omitempty"`
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResult struct {
+	URL        string   `json:"url"`
+	Operation  string   `json:"operation"`
+	StatusCode int      `json:"status_code"`
+	Length     int      `json:"length"`
+	Errors     []string `json:"errors,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+}
+
+// reports if the URL looks like a GraphQL endpoint, e.g. "https://example.com/api/graphql"
+func isGraphQLEndpoint(rawURL string) bool {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	return graphqlPaths[strings.ToLower(segments[len(segments)-1])]
+}
+
+// reads a JSON file containing a list of GraphQL operations
+func loadGraphQLOperations(path string) ([]graphqlOperation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []graphqlOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL queries file: %w", err)
+	}
+
+	for i, operation := range operations {
+		if strings.TrimSpace(operation.Query) == "" {
+			return nil, fmt.Errorf("GraphQL operation %d has no query", i+1)
+		}
+	}
+
+	return operations, nil
+}
+
+// returns the messages of the "errors" of a GraphQL response (nil if the body isn't a GraphQL response)
+func graphqlErrors(body []byte) []string {
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range response.Errors {
+		messages = append(messages, e.Message)
+	}
+
+	return messages
+}
+
+// sends every operation as a JSON POST (with the configured headers) to every GraphQL endpoint among the URLs
+func probeGraphQL(urls map[string]bool, opts probe.Options, operations []graphqlOperation) ([]graphqlResult, error) {
+	var endpoints []string
+	for url := range urls {
+		if isGraphQLEndpoint(url) {
+			endpoints = append(endpoints, url)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	sort.Strings(endpoints)
+
+	// the hooks of the main scan (e.g. the verdict recording) must not see these requests
+	opts.ResponseHooks = []probe.ResponseHook{probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		for _, message := range graphqlErrors(body) {
+			result.Labels = append(result.Labels, graphqlErrorLabel+message)
+		}
+		return nil
+	})}
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	type job struct {
+		url       string
+		operation graphqlOperation
+	}
+
+	jobs := make(chan job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var results []graphqlResult
+
+	Info("Sending %d GraphQL operations to %d GraphQL endpoints", len(operations), len(endpoints))
+
+	workers := opts.Threads
+	if workers <= 0 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				body, err := json.Marshal(j.operation.payload())
+				if err != nil {
+					Error("Failed to encode GraphQL operation %s: %s", j.operation.displayName(), err)
+					continue
+				}
+
+				result := scanner.Do(context.Background(), probe.Request{
+					Method: http.MethodPost,
+					URL:    j.url,
+					Body:   body,
+					Header: http.Header{"Content-Type": {"application/json"}},
+				})
+				if handleHTTPError(result.Err, j.url) {
+					continue
+				}
+
+				r := graphqlResult{URL: j.url, Operation: j.operation.displayName(), StatusCode: result.StatusCode, Length: result.Length}
+				for _, label := range result.Labels {
+					if message, ok := strings.CutPrefix(label, graphqlErrorLabel); ok {
+						r.Errors = append(r.Errors, message)
+					} else {
+						r.Labels = append(r.Labels, label)
+					}
+				}
+
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, endpoint := range endpoints {
+		for _, operation := range operations {
+			jobs <- job{url: endpoint, operation: operation}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].URL != results[j].URL {
+			return results[i].URL < results[j].URL
+		}
+		return results[i].Operation < results[j].Operation
+	})
+
+	return results, nil
+}
+
+// the JSON body of the POST request
+func (o graphqlOperation) payload() map[string]interface{} {
+	payload := map[string]interface{}{"query": o.Query}
+	if o.Name != "" {
+		payload["operationName"] = o.Name
+	}
+	if len(o.Variables) > 0 {
+		payload["variables"] = o.Variables
+	}
+
+	return payload
+}
+
+func (o graphqlOperation) displayName() string {
+	if o.Name != "" {
+		return o.Name
+	}
+
+	return strings.Join(strings.Fields(o.Query), " ")
+}
+
+// writes the status, length and errors of every GraphQL operation. Nothing is written if no endpoint was found
+func writeGraphQLResults(writer *bufio.Writer, results []graphqlResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("GraphQL Operations\n\n")
+	for _, r := range results {
+		labels := ""
+		if len(r.Labels) > 0 {
+			labels = fmt.Sprintf(" [%s]", strings.Join(r.Labels, ", "))
+		}
+
+		_, _ = writer.WriteString(fmt.Sprintf("| POST | %s | %s => Status: %d, Length: %s, Errors: %d%s\n",
+			r.URL, r.Operation, r.StatusCode, formatLength(r.Length), len(r.Errors), labels))
+		for _, message := range r.Errors {
+			_, _ = writer.WriteString(fmt.Sprintf("    error: %s\n", message))
+		}
+	}
+	_, _ = writer.WriteString("\n")
+}
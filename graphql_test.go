@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestIsGraphQLEndpoint(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/graphql":        true,
+		"https://example.com/api/GraphQL/":   true,
+		"https://example.com/v1/gql?x=1":     true,
+		"https://example.com/graphql/schema": false,
+		"https://example.com/api/users":      false,
+	}
+
+	for url, expected := range tests {
+		if actual := isGraphQLEndpoint(url); actual != expected {
+			t.Errorf("Expected %v for URL %s but got %v", expected, url, actual)
+		}
+	}
+}
+
+func TestProbeGraphQL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&payload) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if payload["operationName"] == "Admin" {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "not authorized"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"__typename": "Query"}}`))
+	}))
+	defer ts.Close()
+
+	urls := map[string]bool{ts.URL + "/graphql": true, ts.URL + "/home": true}
+	operations := []graphqlOperation{{Query: "{ __typename }"}, {Name: "Admin", Query: "query Admin { users { email } }"}}
+
+	results, err := probeGraphQL(urls, probe.Options{}, operations)
+	if err != nil {
+		t.Fatalf("Failed to probe GraphQL: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results but got %v", results)
+	}
+	if results[0].Operation != "Admin" || len(results[0].Errors) != 1 || results[0].Errors[0] != "not authorized" {
+		t.Errorf("Expected the error \"not authorized\" for the Admin operation but got %v", results[0])
+	}
+	if results[1].StatusCode != http.StatusOK || len(results[1].Errors) != 0 {
+		t.Errorf("Expected a successful __typename query but got %v", results[1])
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	neturl "net/url"
+	"sort"
+
+	"sessionprobe/pkg/probe"
+)
+
+const (
+	groupByStatus = "status"
+	groupByHost   = "host"
+	groupByMethod = "method"
+	groupByNone   = "none"
+)
+
+var groupByModes = []string{groupByStatus, groupByHost, groupByMethod, groupByNone}
+
+func isValidGroupBy(mode string) bool {
+	for _, m := range groupByModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writes the results grouped by host or method (or as a single list for "none"). Within a group, the results are
+// sorted by URL, method and status code
+func writeGroupedResults(writer *bufio.Writer, urlStatuses map[int][]probe.Result) {
+	groups := make(map[string][]probe.Result)
+	for _, results := range urlStatuses {
+		for _, result := range results {
+			key := groupKey(result)
+			groups[key] = append(groups[key], result)
+		}
+	}
+
+	var keys []string
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		results := groups[k]
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].URL != results[j].URL {
+				return results[i].URL < results[j].URL
+			}
+			if results[i].Method != results[j].Method {
+				return results[i].Method < results[j].Method
+			}
+			return results[i].StatusCode < results[j].StatusCode
+		})
+
+		switch groupBy {
+		case groupByHost:
+			_, _ = writer.WriteString(fmt.Sprintf("Responses for Host: %s\n\n", k))
+		case groupByMethod:
+			_, _ = writer.WriteString(fmt.Sprintf("Responses with Method: %s\n\n", k))
+		default:
+			_, _ = writer.WriteString("Responses\n\n")
+		}
+
+		for _, result := range results {
+			_, _ = writer.WriteString(formatResult(result, true))
+		}
+		_, _ = writer.WriteString("\n")
+	}
+}
+
+// returns the group of the result for the current `--group-by`
+func groupKey(result probe.Result) string {
+	switch groupBy {
+	case groupByHost:
+		if parsed, err := neturl.Parse(result.URL); err == nil && parsed.Host != "" {
+			return parsed.Host
+		}
+		return result.URL
+	case groupByMethod:
+		return result.Method
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestWriteGroupedResults(t *testing.T) {
+	urlStatuses := map[int][]probe.Result{
+		200: {
+			{Method: "GET", URL: "https://b.example.com/home", StatusCode: 200, Length: 10},
+			{Method: "POST", URL: "https://a.example.com/api", StatusCode: 200, Length: 5},
+		},
+		403: {{Method: "GET", URL: "https://a.example.com/admin", StatusCode: 403, Length: 0}},
+	}
+
+	defer func() {
+		groupBy = groupByStatus
+	}()
+
+	tests := map[string]string{
+		groupByHost: `Responses for Host: a.example.com
+
+| GET | 403 | https://a.example.com/admin => Length: 0
+| POST | 200 | https://a.example.com/api => Length: 5
+
+Responses for Host: b.example.com
+
+| GET | 200 | https://b.example.com/home => Length: 10
+
+`,
+		groupByMethod: `Responses with Method: GET
+
+| GET | 403 | https://a.example.com/admin => Length: 0
+| GET | 200 | https://b.example.com/home => Length: 10
+
+Responses with Method: POST
+
+| POST | 200 | https://a.example.com/api => Length: 5
+
+`,
+	}
+
+	for mode, expected := range tests {
+		groupBy = mode
+
+		var b strings.Builder
+		writer := bufio.NewWriter(&b)
+		writeGroupedResults(writer, urlStatuses)
+		writer.Flush()
+
+		if b.String() != expected {
+			t.Errorf("Unexpected output for --group-by %s:\n%s", mode, b.String())
+		}
+	}
+}
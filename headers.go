@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// the response headers selected via `--capture-headers`, in their canonical form
+var capturedHeaders []string
+
+// returns the captured headers of the response (nil if none were selected or none of them is set)
+func captureHeaders(header http.Header) map[string][]string {
+	var captured map[string][]string
+	for _, name := range capturedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			if captured == nil {
+				captured = make(map[string][]string)
+			}
+			captured[name] = values
+		}
+	}
+
+	return captured
+}
+
+// extracts a value from a response header via `--extract-header`, e.g. "Location: (.*)"
+type headerExtractor struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// the extractors of `--extract-header`
+var headerExtractors []headerExtractor
+
+// parses the extractors in the format "Name: regex"
+func parseHeaderExtractors(args []string) ([]headerExtractor, error) {
+	var extractors []headerExtractor
+	for _, arg := range args {
+		name, expr, found := strings.Cut(arg, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header extractor %q (expected \"Name: regex\")", arg)
+		}
+
+		regex, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of header extractor %q: %w", arg, err)
+		}
+		extractors = append(extractors, headerExtractor{name: http.CanonicalHeaderKey(strings.TrimSpace(name)), regex: regex})
+	}
+
+	return extractors, nil
+}
+
+// returns the values extracted from the response headers per header name (nil if nothing matched). The first capture
+// group of the regex is extracted, or the whole match if it has none
+func extractHeaders(header http.Header) map[string][]string {
+	var extracted map[string][]string
+	for _, extractor := range headerExtractors {
+		for _, value := range header.Values(extractor.name) {
+			match := extractor.regex.FindStringSubmatch(value)
+			if match == nil {
+				continue
+			}
+
+			if extracted == nil {
+				extracted = make(map[string][]string)
+			}
+			extracted[extractor.name] = append(extracted[extractor.name], match[min(1, len(match)-1)])
+		}
+	}
+
+	return extracted
+}
+
+// returns the names of the headers provided via `--headers` and the `--headers-file` in the order (and with the casing)
+// they were provided in, so that `--raw-headers` can send them like that
+func headerOrder(args []string, path string) ([]string, error) {
+	var names []string
+	for _, arg := range args {
+		for _, pair := range splitHeaders(arg) {
+			if name, _, ok := strings.Cut(pair, ":"); ok {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	if path == "" {
+		return names, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if name, _, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "#") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	return names, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestCaptureHeaders(t *testing.T) {
+	capturedHeaders = []string{"Server", "Set-Cookie", "X-Powered-By"}
+	defer func() {
+		capturedHeaders = nil
+	}()
+
+	header := http.Header{}
+	header.Set("Server", "nginx")
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+	header.Set("Content-Type", "text/html")
+
+	expected := map[string][]string{"Server": {"nginx"}, "Set-Cookie": {"a=1", "b=2"}}
+	if actual := captureHeaders(header); fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestExtractHeaders(t *testing.T) {
+	extractors, err := parseHeaderExtractors([]string{"Location: ^https?://[^/]+(/.*)", "x-ratelimit-remaining: \\d+", "Set-Cookie: session=([^;]+)"})
+	if err != nil {
+		t.Fatalf("Failed to parse extractors: %v", err)
+	}
+	headerExtractors = extractors
+	defer func() {
+		headerExtractors = nil
+	}()
+
+	header := http.Header{}
+	header.Set("Location", "https://example.com/login?next=/admin")
+	header.Set("X-RateLimit-Remaining", "42")
+	header.Add("Set-Cookie", "theme=dark")
+	header.Add("Set-Cookie", "session=abc123; HttpOnly")
+
+	expected := map[string][]string{"Location": {"/login?next=/admin"}, "Set-Cookie": {"abc123"}, "X-Ratelimit-Remaining": {"42"}}
+	if actual := extractHeaders(header); fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+
+	result := probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 302, Header: header}
+	if actual := formatResult(result, false); actual != "| GET | https://example.com/admin => Length: 0, Location: /login?next=/admin, Set-Cookie: abc123, X-Ratelimit-Remaining: 42\n" {
+		t.Errorf("Expected the extracted values in the output but got %q", actual)
+	}
+
+	if actual := extractHeaders(http.Header{}); actual != nil {
+		t.Errorf("Expected nothing to be extracted but got %v", actual)
+	}
+
+	for _, arg := range []string{"no regex", "Location: ("} {
+		if _, err := parseHeaderExtractors([]string{arg}); err == nil {
+			t.Errorf("Expected an error for %q", arg)
+		}
+	}
+}
+
+func TestHeaderOrder(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-raw-headers.txt")
+	if err := os.WriteFile(path, []byte("# comment\nx-api-key: secret\n\nCOOKIE: a=1; b=2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write headers file: %v", err)
+	}
+
+	names, err := headerOrder([]string{"X-Forwarded-For: 127.0.0.1;x-real-ip:127.0.0.1", "cookie: c=3; d=4"}, path)
+	if err != nil {
+		t.Fatalf("Failed to read the header order: %v", err)
+	}
+
+	expected := []string{"X-Forwarded-For", "x-real-ip", "cookie", "x-api-key", "COOKIE"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v but got %v", expected, names)
+	}
+}
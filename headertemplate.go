@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+const randomStringAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// the functions available in header values, e.g. "X-Nonce: {{uuid}}". They are evaluated for every request
+var headerFuncs = template.FuncMap{
+	"uuid":     probe.NewUUID,
+	"unixtime": func() int64 { return time.Now().Unix() },
+	"randstr":  randomString,
+}
+
+// returns a random alphanumeric string of the given length
+func randomString(length int) (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(randomStringAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(randomStringAlphabet[n.Int64()])
+	}
+
+	return b.String(), nil
+}
+
+// the headers whose values contain template functions, e.g. "{{unixtime}}"
+type headerTemplates map[string][]*template.Template
+
+// parses the header values that contain template functions. Returns nil if there are none, so that no hook is needed
+func parseHeaderTemplates(headers map[string][]string) (headerTemplates, error) {
+	var templates headerTemplates
+	for name, values := range headers {
+		if !containsTemplate(values) {
+			continue
+		}
+
+		for _, value := range values {
+			tmpl, err := template.New(name).Funcs(headerFuncs).Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template in the %s header: %w", name, err)
+			}
+
+			if templates == nil {
+				templates = make(headerTemplates)
+			}
+			templates[name] = append(templates[name], tmpl)
+		}
+	}
+
+	return templates, nil
+}
+
+func containsTemplate(values []string) bool {
+	for _, value := range values {
+		if strings.Contains(value, "{{") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaces the values of the templated headers with freshly evaluated ones. Like the static headers, multiple cookies
+// are joined into a single Cookie header
+func (h headerTemplates) BeforeRequest(req *http.Request) error {
+	for name, templates := range h {
+		var values []string
+		for _, tmpl := range templates {
+			var value strings.Builder
+			if err := tmpl.Execute(&value, nil); err != nil {
+				return fmt.Errorf("failed to evaluate the %s header: %w", name, err)
+			}
+			values = append(values, value.String())
+		}
+
+		if name == "Cookie" {
+			req.Header.Set(name, strings.Join(values, "; "))
+			continue
+		}
+
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	return nil
+}
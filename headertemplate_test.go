@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHeaderTemplates(t *testing.T) {
+	templates, err := parseHeaderTemplates(map[string][]string{
+		"X-Request-Id": {"{{uuid}}"},
+		"X-Timestamp":  {"{{unixtime}}"},
+		"Cookie":       {"session=abc", "nonce={{randstr 8}}"},
+		"X-Static":     {"static"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the header templates: %v", err)
+	}
+	if len(templates) != 3 {
+		t.Errorf("Expected 3 templated headers but got %d", len(templates))
+	}
+
+	var previous string
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "https://example.com", nil)
+		req.Header.Set("X-Static", "static")
+		if err := templates.BeforeRequest(req); err != nil {
+			t.Fatalf("Failed to evaluate the header templates: %v", err)
+		}
+
+		id := req.Header.Get("X-Request-Id")
+		if !regexp.MustCompile(`^[0-9a-f-]{36}$`).MatchString(id) || id == previous {
+			t.Errorf("Expected a new UUID per request but got %q (previous: %q)", id, previous)
+		}
+		previous = id
+
+		timestamp, err := strconv.ParseInt(req.Header.Get("X-Timestamp"), 10, 64)
+		if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+			t.Errorf("Expected the current unix time but got %q", req.Header.Get("X-Timestamp"))
+		}
+
+		if cookie := req.Header.Get("Cookie"); !regexp.MustCompile(`^session=abc; nonce=[a-zA-Z0-9]{8}$`).MatchString(cookie) {
+			t.Errorf("Expected the cookies to be joined with a random nonce but got %q", cookie)
+		}
+
+		if req.Header.Get("X-Static") != "static" {
+			t.Errorf("Expected the static header to be untouched")
+		}
+	}
+}
+
+func TestParseHeaderTemplatesWithoutTemplates(t *testing.T) {
+	templates, err := parseHeaderTemplates(map[string][]string{"Authorization": {"Bearer abc"}})
+	if err != nil || templates != nil {
+		t.Errorf("Expected no templates but got %v (err: %v)", templates, err)
+	}
+
+	if _, err := parseHeaderTemplates(map[string][]string{"X-Nonce": {"{{randstr"}}); err == nil {
+		t.Errorf("Expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the template of the HTML report written via `--out-html`
+//
+//go:embed web/report.html
+var reportHTML string
+
+const (
+	// size of the timeline chart in pixels
+	chartWidth  = 800
+	chartHeight = 200
+	// long scans are summarized into at most this many points, so that the chart stays readable
+	maxChartPoints = 300
+)
+
+// the data the HTML report is rendered from
+type htmlReport struct {
+	Tags    []string
+	Stats   *scanStats
+	Chart   *timelineChart
+	Results []jsonResult
+}
+
+// the requests per second and the error rate over the duration of the scan as SVG polylines
+type timelineChart struct {
+	Width         int
+	Height        int
+	MaxRate       float64
+	BucketSeconds int
+	Duration      string
+	// the points ("x,y x,y ...") of the polylines
+	Requests string
+	Errors   string
+}
+
+var reportFuncs = template.FuncMap{
+	"formatLength":       formatLength,
+	"formatClassCounts":  formatClassCounts,
+	"formatStatusCounts": func(s *scanStats) string { return s.formatStatusCounts() },
+	"statusDigit":        func(statusCode int) int { return statusCode / 100 },
+	"join":               strings.Join,
+}
+
+// writes the results, the statistics and the timeline chart as a self-contained HTML file
+func writeHTMLFile(urlStatuses map[int][]probe.Result, stats *scanStats, path string) error {
+	tmpl, err := template.New("report").Funcs(reportFuncs).Parse(reportHTML)
+	if err != nil {
+		return err
+	}
+
+	report := htmlReport{Tags: tagList(scanTags), Stats: stats, Results: buildJSONReport(urlStatuses, stats).Results}
+	if stats != nil {
+		report.Chart = newTimelineChart(stats.timeline)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, report)
+}
+
+// builds the chart from the requests and errors per second (nil if there were no requests). Neighbouring seconds
+// are merged if the scan took longer than maxChartPoints seconds
+func newTimelineChart(timeline []timelineBucket) *timelineChart {
+	if len(timeline) == 0 {
+		return nil
+	}
+
+	bucketSeconds := (len(timeline) + maxChartPoints - 1) / maxChartPoints
+	var buckets []timelineBucket
+	for i := 0; i < len(timeline); i += bucketSeconds {
+		var bucket timelineBucket
+		for _, second := range timeline[i:min(i+bucketSeconds, len(timeline))] {
+			bucket.requests += second.requests
+			bucket.errors += second.errors
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	// a single point is drawn as a horizontal line
+	if len(buckets) == 1 {
+		buckets = append(buckets, buckets[0])
+	}
+
+	chart := &timelineChart{
+		Width:         chartWidth,
+		Height:        chartHeight,
+		BucketSeconds: bucketSeconds,
+		Duration:      (time.Duration(len(timeline)) * time.Second).String(),
+	}
+	for _, bucket := range buckets {
+		chart.MaxRate = max(chart.MaxRate, float64(bucket.requests)/float64(bucketSeconds))
+	}
+
+	var requests, errors []string
+	for i, bucket := range buckets {
+		x := float64(i) * chartWidth / float64(len(buckets)-1)
+
+		rate := float64(bucket.requests) / float64(bucketSeconds)
+		errorRate := 0.0
+		if bucket.requests > 0 {
+			errorRate = float64(bucket.errors) / float64(bucket.requests)
+		}
+
+		requests = append(requests, fmt.Sprintf("%.1f,%.1f", x, chartY(rate, chart.MaxRate)))
+		errors = append(errors, fmt.Sprintf("%.1f,%.1f", x, chartY(errorRate, 1)))
+	}
+	chart.Requests = strings.Join(requests, " ")
+	chart.Errors = strings.Join(errors, " ")
+
+	return chart
+}
+
+// returns the y coordinate of the value, with the maximum at the top of the chart (and 0 at the bottom)
+func chartY(value float64, maximum float64) float64 {
+	if maximum <= 0 {
+		return chartHeight
+	}
+
+	return chartHeight - value/maximum*chartHeight
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestNewTimelineChart(t *testing.T) {
+	if chart := newTimelineChart(nil); chart != nil {
+		t.Errorf("Expected no chart without requests but got %+v", chart)
+	}
+
+	chart := newTimelineChart([]timelineBucket{{requests: 10}, {requests: 20, errors: 10}, {requests: 5, errors: 5}})
+	if chart.MaxRate != 20 || chart.BucketSeconds != 1 {
+		t.Errorf("Expected a max. rate of 20 with 1s per point but got %v with %ds", chart.MaxRate, chart.BucketSeconds)
+	}
+	if expected := "0.0,100.0 400.0,0.0 800.0,150.0"; chart.Requests != expected {
+		t.Errorf("Expected the requests %q but got %q", expected, chart.Requests)
+	}
+	if expected := "0.0,200.0 400.0,100.0 800.0,0.0"; chart.Errors != expected {
+		t.Errorf("Expected the errors %q but got %q", expected, chart.Errors)
+	}
+
+	// long scans are summarized
+	timeline := make([]timelineBucket, 3*maxChartPoints)
+	if chart := newTimelineChart(timeline); chart.BucketSeconds != 3 || len(strings.Fields(chart.Requests)) != maxChartPoints {
+		t.Errorf("Expected %d points with 3s each but got %d with %ds", maxChartPoints, len(strings.Fields(chart.Requests)), chart.BucketSeconds)
+	}
+}
+
+func TestWriteHTMLFile(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	stats := newScanStats(1)
+	result := probe.Result{Method: "GET", URL: "https://example.com/<admin>", StatusCode: 200, Length: 12, Labels: []string{"admin"}}
+	stats.add(result)
+	stats.finish()
+
+	path := filepath.Join(".", "testing", "test-report.html")
+	if err := writeHTMLFile(map[int][]probe.Result{200: {result}}, stats, path); err != nil {
+		t.Fatalf("Failed to write HTML report: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read HTML report: %v", err)
+	}
+
+	for _, expected := range []string{"<polyline points=", "https://example.com/&lt;admin&gt;", "2xx: 1 (100.0%)", `<td class="s2">200</td>`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected the report to contain %q but got: %s", expected, data)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	neturl "net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// maps the punycode form of the URLs with internationalized domain names to the form they were provided in (e.g.
+// "https://xn--mnchen-3ya.de/" => "https://münchen.de/"), so that the output can show both
+var idnURLs = make(map[string]string)
+
+// the parameters of punycode (RFC 3492)
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+// converts the host of a URL with an internationalized domain name to punycode (e.g. "https://münchen.de/" =>
+// "https://xn--mnchen-3ya.de/"). The rest of the URL is kept as it is. Reports false if the URL has no such host
+func idnToASCII(url string) (string, bool) {
+	parsed, err := neturl.Parse(url)
+	if err != nil || parsed.Host == "" || isASCII(parsed.Hostname()) {
+		return url, false
+	}
+
+	host := hostToASCII(parsed.Hostname())
+	if port := parsed.Port(); port != "" {
+		host += ":" + port
+	}
+
+	return strings.Replace(url, parsed.Host, host, 1), true
+}
+
+// converts every label of the host that isn't ASCII to punycode with the "xn--" prefix. Since the full IDNA mapping
+// isn't implemented, the labels are only lowercased beforehand
+func hostToASCII(host string) string {
+	// the dots of other scripts separate labels as well
+	host = strings.NewReplacer("。", ".", "．", ".", "｡", ".").Replace(host)
+
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if !isASCII(label) {
+			labels[i] = "xn--" + punycodeEncode(strings.ToLower(label))
+		}
+	}
+
+	return strings.Join(labels, ".")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodes a label as punycode (without the "xn--" prefix) as described in RFC 3492, e.g. "münchen" => "mnchen-3ya"
+func punycodeEncode(label string) string {
+	runes := []rune(label)
+
+	// the ASCII characters are copied as they are, followed by a "-" if there are any
+	var output []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			output = append(output, byte(r))
+		}
+	}
+	basic := len(output)
+	if basic > 0 {
+		output = append(output, '-')
+	}
+
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for handled := basic; handled < len(runes); {
+		// the smallest code point that hasn't been handled yet
+		next := -1
+		for _, r := range runes {
+			if int(r) >= n && (next < 0 || int(r) < next) {
+				next = int(r)
+			}
+		}
+		delta += (next - n) * (handled + 1)
+		n = next
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+
+			// encode the delta as a variable-length integer
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := k - bias
+				if t < punycodeTMin {
+					t = punycodeTMin
+				} else if t > punycodeTMax {
+					t = punycodeTMax
+				}
+				if q < t {
+					break
+				}
+				output = append(output, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			output = append(output, punycodeDigit(q))
+
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+
+	return string(output)
+}
+
+func punycodeAdapt(delta int, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+// returns the character of a digit: 0-25 are "a"-"z", 26-35 are "0"-"9"
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+
+	return byte('0' + d - 26)
+}
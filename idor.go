@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"sessionprobe/pkg/probe"
+)
+
+// set if URLs were probed with permuted IDs via `--idor-params`
+var idorCandidates []idorCandidate
+
+// a URL in which the value of an ID parameter was replaced
+type idorVariant struct {
+	url   string
+	param string
+	value string
+}
+
+// a permuted URL that returned a successful response with different content than the original URL
+type idorCandidate struct {
+	Method         string  `json:"method"`
+	URL            string  `json:"url"`
+	Original       string  `json:"original_url"`
+	Param          string  `json:"param"`
+	Value          string  `json:"value"`
+	StatusCode     int     `json:"status_code"`
+	Length         int     `json:"length"`
+	OriginalStatus int     `json:"original_status_code"`
+	OriginalLength int     `json:"original_length"`
+	Similarity     float64 `json:"similarity"`
+}
+
+// returns the variants of the URL in which the value of every query parameter (e.g. "?id=5") or path parameter (e.g.
+// "/id/5") with one of the given names is replaced by each of the given values
+func idorVariants(rawURL string, params []string, values []string) []idorVariant {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	var variants []idorVariant
+	for _, param := range params {
+		// query parameters are rewritten in place to keep the order of the other parameters
+		pairs := strings.Split(parsed.RawQuery, "&")
+		for i, pair := range pairs {
+			name, current, ok := strings.Cut(pair, "=")
+			if !ok || !strings.EqualFold(name, param) {
+				continue
+			}
+
+			for _, value := range values {
+				if value == current {
+					continue
+				}
+
+				rewritten := append([]string{}, pairs...)
+				rewritten[i] = name + "=" + neturl.QueryEscape(value)
+
+				variant := *parsed
+				variant.RawQuery = strings.Join(rewritten, "&")
+				variants = append(variants, idorVariant{url: variant.String(), param: name, value: value})
+			}
+		}
+
+		segments := strings.Split(parsed.EscapedPath(), "/")
+		for i := 0; i < len(segments)-1; i++ {
+			if !strings.EqualFold(segments[i], param) || segments[i+1] == "" {
+				continue
+			}
+
+			for _, value := range values {
+				if value == segments[i+1] {
+					continue
+				}
+
+				rewritten := append([]string{}, segments...)
+				rewritten[i+1] = neturl.PathEscape(value)
+
+				variant := *parsed
+				if err := setEscapedPath(&variant, strings.Join(rewritten, "/")); err != nil {
+					continue
+				}
+				variants = append(variants, idorVariant{url: variant.String(), param: segments[i], value: value})
+			}
+		}
+	}
+
+	return variants
+}
+
+func setEscapedPath(u *neturl.URL, escaped string) error {
+	path, err := neturl.PathUnescape(escaped)
+	if err != nil {
+		return err
+	}
+
+	u.Path, u.RawPath = path, escaped
+	return nil
+}
+
+// probes the URLs that contain one of the ID parameters along with all their variants under the current session and
+// returns the variants that returned a successful response with different content than the original URL
+func probeIDOR(urls map[string]bool, opts probe.Options, params []string, values []string) ([]idorCandidate, error) {
+	variants := make(map[string][]idorVariant)
+	opts.URLs = nil
+	for url := range urls {
+		if urlVariants := idorVariants(url, params, values); len(urlVariants) > 0 {
+			variants[url] = urlVariants
+			opts.URLs = append(opts.URLs, url)
+			for _, variant := range urlVariants {
+				opts.URLs = append(opts.URLs, variant.url)
+			}
+		}
+	}
+
+	if len(variants) == 0 {
+		Info("No URLs contain one of the IDOR parameters")
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	responses := make(map[string]roleResponse)
+	opts.Methods = getMethods()
+	opts.ResponseHooks = []probe.ResponseHook{probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		response := newRoleResponse(result, body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		responses[result.Method+" "+result.URL] = response
+
+		return nil
+	})}
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	Info("Probing %d URLs with permuted IDs (%d requests)", len(variants), len(opts.URLs)*len(opts.Methods))
+	for result := range scanner.Run(context.Background()) {
+		handleHTTPError(result.Err, result.URL)
+	}
+
+	var candidates []idorCandidate
+	for url, urlVariants := range variants {
+		for _, method := range opts.Methods {
+			original, ok := responses[method+" "+url]
+			if !ok {
+				continue
+			}
+
+			for _, variant := range urlVariants {
+				response, ok := responses[method+" "+variant.url]
+				if !ok || response.statusCode < 200 || response.statusCode >= 300 {
+					continue
+				}
+
+				// an identical response suggests that the parameter is ignored rather than another user's data being returned
+				similarity := similarity(original, response)
+				if similarity >= minExposedSimilarity && lengthDelta(original.length, response.length) <= maxExposedLengthDelta {
+					continue
+				}
+
+				candidates = append(candidates, idorCandidate{
+					Method:         method,
+					URL:            variant.url,
+					Original:       url,
+					Param:          variant.param,
+					Value:          variant.value,
+					StatusCode:     response.statusCode,
+					Length:         response.length,
+					OriginalStatus: original.statusCode,
+					OriginalLength: original.length,
+					Similarity:     similarity,
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].URL != candidates[j].URL {
+			return candidates[i].URL < candidates[j].URL
+		}
+		return candidates[i].Method < candidates[j].Method
+	})
+
+	return candidates, nil
+}
+
+// writes the IDOR candidates found via `--idor-params`. Nothing is written if there are none
+func writeIDORCandidates(writer *bufio.Writer, candidates []idorCandidate) {
+	if len(candidates) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Potential IDORs\n\n")
+	for _, c := range candidates {
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s (%s=%s) => Status: %d, Length: %s (original: %d, %s), Similarity: %.0f%%\n",
+			c.Method, c.URL, c.Param, c.Value, c.StatusCode, formatLength(c.Length), c.OriginalStatus, formatLength(c.OriginalLength), c.Similarity*100))
+	}
+	_, _ = writer.WriteString("\n")
+}
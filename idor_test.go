@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestIdorVariants(t *testing.T) {
+	tests := map[string][]string{
+		"https://example.com/orders?page=2&user_id=5": {
+			"https://example.com/orders?page=2&user_id=1",
+			"https://example.com/orders?page=2&user_id=1337",
+		},
+		"https://example.com/api/id/5/profile": {
+			"https://example.com/api/id/1/profile",
+			"https://example.com/api/id/1337/profile",
+		},
+		"https://example.com/api/users/5": nil,
+	}
+
+	for url, expected := range tests {
+		var actual []string
+		for _, variant := range idorVariants(url, []string{"id", "user_id"}, []string{"1", "5", "1337"}) {
+			actual = append(actual, variant.url)
+		}
+		sort.Strings(actual)
+
+		if fmt.Sprint(actual) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v for URL %s but got %v", expected, url, actual)
+		}
+	}
+}
+
+func TestProbeIDOR(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id") {
+		case "1":
+			fmt.Fprint(w, "Profile of alice: alice@example.com, 42 Main Street, Springfield, premium customer since 2019")
+		case "2":
+			fmt.Fprint(w, "Profile of bob: bob@example.net, 7 Elm Road, Shelbyville, trial account, two open invoices")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	urls := map[string]bool{ts.URL + "/profile?id=1": true}
+	candidates, err := probeIDOR(urls, probe.Options{}, []string{"id"}, []string{"2", "3"})
+	if err != nil {
+		t.Fatalf("Failed to probe IDORs: %v", err)
+	}
+
+	if len(candidates) != 1 || candidates[0].URL != ts.URL+"/profile?id=2" {
+		t.Errorf("Expected only %s/profile?id=2 to be a candidate but got %v", ts.URL, candidates)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the structure of the JSON output file written via `--out-json`
+type jsonReport struct {
+	// the metadata of the scan (`--tag`)
+	Tags    map[string]string `json:"tags,omitempty"`
+	Stats   *scanStats        `json:"stats,omitempty"`
+	Results []jsonResult      `json:"results"`
+	// only set if a second role was probed via `--compare-unauth` or `--compare-headers`
+	Verdicts []accessVerdict `json:"verdicts,omitempty"`
+	// only set if IDs were permuted via `--idor-params`
+	IDOR []idorCandidate `json:"idor,omitempty"`
+	// only set if GraphQL endpoints were found among the URLs
+	GraphQL []graphqlResult `json:"graphql,omitempty"`
+	// session cookies that were issued to unauthenticated requests
+	SessionCookies []sessionCookieFinding `json:"session_cookies,omitempty"`
+	// only set if `--audit-headers` is used
+	HeaderAudits []headerAudit `json:"header_audits,omitempty"`
+	// only set if `--check-cors` found misconfigurations
+	CORS []corsFinding `json:"cors,omitempty"`
+	// the Server, X-Powered-By and Via headers seen per host
+	Technologies []hostFingerprint `json:"technologies,omitempty"`
+	// variants of denied requests that were successful
+	Bypasses []bypassFinding `json:"bypasses,omitempty"`
+	// identical responses (same status code and body) that were returned for more than one request
+	Clusters []responseCluster `json:"clusters,omitempty"`
+	// only set with `--repeat`
+	Repeats []repeatSummary `json:"repeats,omitempty"`
+	// the requests that failed, e.g. because of timeouts, DNS or TLS errors
+	Errors []failedRequest `json:"errors,omitempty"`
+}
+
+type jsonResult struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Length     int    `json:"length"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	// the URL as provided if its host is an internationalized domain name (URL then has the punycode form)
+	IDNURL string `json:"idn_url,omitempty"`
+	// the ID sent in the `--correlation-header`
+	CorrelationID string   `json:"correlation_id,omitempty"`
+	Labels        []string `json:"labels,omitempty"`
+	// the analyst's note from the `--notes` file
+	Annotation *annotation `json:"annotation,omitempty"`
+	// the severity assigned by the `--post-hook`
+	Severity string       `json:"severity,omitempty"`
+	Secrets  []jsonSecret `json:"secrets,omitempty"`
+	// the beginning of the body if `--snippet` is used
+	Snippet string `json:"snippet,omitempty"`
+	// the response headers selected via `--capture-headers`
+	Headers map[string][]string `json:"headers,omitempty"`
+	// the values extracted from the response headers via `--extract-header`
+	Extracted map[string][]string `json:"extracted,omitempty"`
+	// the caching-related headers of the response
+	Cache *cacheInfo `json:"cache,omitempty"`
+	// the Server, X-Powered-By and Via headers of the response
+	Fingerprint map[string]string `json:"fingerprint,omitempty"`
+}
+
+type jsonSecret struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+func newJSONResult(result probe.Result) jsonResult {
+	var secrets []jsonSecret
+	for _, secret := range result.Secrets {
+		secrets = append(secrets, jsonSecret{Kind: secret.Kind, Value: secret.Value})
+	}
+
+	return jsonResult{
+		Method:        result.Method,
+		URL:           result.URL,
+		IDNURL:        idnURLs[result.URL],
+		StatusCode:    result.StatusCode,
+		Length:        result.Length,
+		Truncated:     result.Truncated,
+		CorrelationID: result.CorrelationID,
+		Labels:        result.Labels,
+		Severity:      result.Severity,
+		Annotation:    annotationFor(result.Method, result.URL),
+		Secrets:       secrets,
+		Snippet:       result.Snippet,
+		Headers:       captureHeaders(result.Header),
+		Extracted:     extractHeaders(result.Header),
+		Cache:         newCacheInfo(result.Header),
+		Fingerprint:   fingerprintOf(result.Header),
+	}
+}
+
+// writes the results and the statistics as JSON
+func writeJSONFile(urlStatuses map[int][]probe.Result, stats *scanStats, path string) error {
+	data, err := json.MarshalIndent(buildJSONReport(urlStatuses, stats), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// collects the results (sorted by status code, URL and method), the statistics and the findings of the optional
+// checks into a report
+func buildJSONReport(urlStatuses map[int][]probe.Result, stats *scanStats) jsonReport {
+	report := jsonReport{Tags: scanTags, Stats: stats, Results: []jsonResult{}}
+	if comparison != nil {
+		report.Verdicts = comparison.verdicts()
+	}
+	report.IDOR = idorCandidates
+	report.GraphQL = graphqlResults
+	report.SessionCookies = sessionCookieFindings.sorted()
+	report.HeaderAudits = headerAudits
+	report.CORS = corsFindings
+	report.Technologies = fingerprints.summary()
+	report.Bypasses = bypassFindings
+	report.Clusters = clusters.summary()
+	report.Errors = failedRequests
+	if repeats != nil {
+		report.Repeats = repeats.summaries()
+	}
+	for _, results := range urlStatuses {
+		for _, result := range results {
+			report.Results = append(report.Results, newJSONResult(result))
+		}
+	}
+
+	sort.Slice(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.StatusCode != b.StatusCode {
+			return a.StatusCode < b.StatusCode
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Method < b.Method
+	})
+
+	return report
+}
+
+// reads a JSON output file written via `--out-json`
+func readJSONFile(path string) (*jsonReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the structure of a JUnit XML report as understood by Jenkins, GitLab CI and others
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string `xml:"name,attr"`
+	Tests    int    `xml:"tests,attr"`
+	Failures int    `xml:"failures,attr"`
+	Time     string `xml:"time,attr"`
+	// the tags of the scan (`--tag`)
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (s *junitTestSuite) add(testCase junitTestCase) {
+	s.Cases = append(s.Cases, testCase)
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+}
+
+// builds the test suites: every expectation (`--expect`) and every access-control verdict (`--compare-unauth` or
+// `--compare-headers`) is a test case. Without either, every result is reported as a passed test case
+func buildJUnitReport(urlStatuses map[int][]probe.Result, stats *scanStats) junitTestSuites {
+	duration := "0"
+	if stats != nil {
+		duration = fmt.Sprintf("%.3f", stats.duration().Seconds())
+	}
+
+	var report junitTestSuites
+
+	if expectations != nil {
+		suite := junitTestSuite{Name: "sessionprobe.expectations", Time: duration}
+
+		failed := make(map[string]deviation)
+		for _, d := range expectations.deviations() {
+			failed[d.Key] = d
+		}
+
+		var keys []string
+		for key := range expectations.expected {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			testCase := junitTestCase{Name: key, ClassName: suite.Name}
+			if d, ok := failed[key]; ok {
+				testCase.Failure = &junitFailure{Message: d.String(), Type: "UnexpectedStatus", Text: d.String()}
+			}
+			suite.add(testCase)
+		}
+
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if comparison != nil {
+		suite := junitTestSuite{Name: "sessionprobe.access-control", Time: duration}
+
+		for _, v := range comparison.verdicts() {
+			testCase := junitTestCase{
+				Name:      v.Method + " " + v.URL,
+				ClassName: suite.Name,
+				SystemOut: fmt.Sprintf("%s: status %d/%d, length %s/%s, similarity %.0f%%",
+					v.Verdict, v.Status, v.OtherCode, formatLength(v.Length), formatLength(v.OtherLen), v.Similarity*100),
+			}
+			if v.Verdict != verdictBlocked {
+				testCase.Failure = &junitFailure{Message: v.Verdict, Type: "AccessControl", Text: testCase.SystemOut}
+			}
+			suite.add(testCase)
+		}
+
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if len(report.Suites) == 0 {
+		suite := junitTestSuite{Name: "sessionprobe.results", Time: duration}
+
+		var results []probe.Result
+		for _, statusResults := range urlStatuses {
+			results = append(results, statusResults...)
+		}
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].URL != results[j].URL {
+				return results[i].URL < results[j].URL
+			}
+			return results[i].Method < results[j].Method
+		})
+
+		for _, result := range results {
+			out := fmt.Sprintf("status %d, length %s", result.StatusCode, formatLength(result.Length))
+			if len(result.Labels) > 0 {
+				out += " [" + strings.Join(result.Labels, ", ") + "]"
+			}
+			suite.add(junitTestCase{Name: result.Method + " " + result.URL, ClassName: suite.Name, SystemOut: out})
+		}
+
+		report.Suites = append(report.Suites, suite)
+	}
+
+	for i := range report.Suites {
+		for _, tag := range tagList(scanTags) {
+			name, value, _ := strings.Cut(tag, "=")
+			report.Suites[i].Properties = append(report.Suites[i].Properties, junitProperty{Name: name, Value: value})
+		}
+	}
+
+	return report
+}
+
+// writes the results as JUnit XML, so that the authorization checks show up in the test reports of CI systems
+func writeJUnitFile(urlStatuses map[int][]probe.Result, stats *scanStats, path string) error {
+	data, err := xml.MarshalIndent(buildJUnitReport(urlStatuses, stats), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
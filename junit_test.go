@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestBuildJUnitReport(t *testing.T) {
+	expectations = &expectationSet{
+		expected: map[string][]int{"GET https://example.com/admin": {403}, "GET https://example.com/home": {200}},
+		actual:   map[string]int{"GET https://example.com/admin": 200, "GET https://example.com/home": 200},
+	}
+	defer func() {
+		expectations = nil
+	}()
+
+	report := buildJUnitReport(map[int][]probe.Result{}, nil)
+	if len(report.Suites) != 1 {
+		t.Fatalf("Expected 1 test suite but got %d", len(report.Suites))
+	}
+
+	suite := report.Suites[0]
+	if suite.Tests != 2 || suite.Failures != 1 {
+		t.Errorf("Expected 2 tests and 1 failure but got %d tests and %d failures", suite.Tests, suite.Failures)
+	}
+	if suite.Cases[0].Name != "GET https://example.com/admin" || suite.Cases[0].Failure == nil || suite.Cases[1].Failure != nil {
+		t.Errorf("Expected only GET https://example.com/admin to fail but got %+v", suite.Cases)
+	}
+}
+
+func TestBuildJUnitReportWithoutExpectations(t *testing.T) {
+	urlStatuses := map[int][]probe.Result{
+		200: {{Method: "GET", URL: "https://example.com/home", StatusCode: 200, Length: 5}},
+	}
+
+	report := buildJUnitReport(urlStatuses, nil)
+	if len(report.Suites) != 1 || report.Suites[0].Tests != 1 || report.Suites[0].Failures != 0 {
+		t.Errorf("Expected a single passed test case but got %+v", report.Suites)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	neturl "net/url"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the label of redirects to the detected login page, which are in effect "unauthorized" responses
+const loginRedirectLabel = "unauthorized (redirect to login)"
+
+// a redirect target has to be seen at least this often to be considered the login page
+const minLoginRedirects = 2
+
+var redirectStatuses = []int{301, 302, 303, 307, 308}
+
+// returns the target of a redirect without query and fragment (e.g. "?returnUrl=..."), resolved against the URL of
+// the request. Returns "" if the response has no Location header
+func redirectTarget(result probe.Result) string {
+	location := result.Header.Get("Location")
+	if location == "" {
+		return ""
+	}
+
+	base, err := neturl.Parse(result.URL)
+	if err != nil {
+		return ""
+	}
+	target, err := base.Parse(location)
+	if err != nil {
+		return ""
+	}
+
+	target.RawQuery, target.Fragment = "", ""
+	return strings.TrimSuffix(target.String(), "/")
+}
+
+// detects the login page as the most common redirect target and labels all redirects to it. Returns the login page,
+// or "" if none was detected
+func classifyLoginRedirects(urlStatuses map[int][]probe.Result) string {
+	counts := make(map[string]int)
+	for _, status := range redirectStatuses {
+		for _, result := range urlStatuses[status] {
+			if target := redirectTarget(result); target != "" {
+				counts[target]++
+			}
+		}
+	}
+
+	var login string
+	for target, count := range counts {
+		if count > counts[login] || (count == counts[login] && target < login) {
+			login = target
+		}
+	}
+	if counts[login] < minLoginRedirects {
+		return ""
+	}
+
+	for _, status := range redirectStatuses {
+		for i, result := range urlStatuses[status] {
+			if redirectTarget(result) == login {
+				urlStatuses[status][i].Labels = append(result.Labels, loginRedirectLabel)
+			}
+		}
+	}
+
+	return login
+}
+
+// splits the results into redirects to the login page and all others
+func splitLoginRedirects(results []probe.Result) (login []probe.Result, other []probe.Result) {
+	for _, result := range results {
+		if containsString(result.Labels, loginRedirectLabel) {
+			login = append(login, result)
+		} else {
+			other = append(other, result)
+		}
+	}
+
+	return login, other
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestClassifyLoginRedirects(t *testing.T) {
+	redirect := func(url string, location string) probe.Result {
+		return probe.Result{Method: "GET", URL: url, StatusCode: 302, Header: http.Header{"Location": {location}}}
+	}
+
+	urlStatuses := map[int][]probe.Result{
+		302: {
+			redirect("https://example.com/admin", "/login?returnUrl=%2Fadmin"),
+			redirect("https://example.com/settings", "https://example.com/login/?returnUrl=%2Fsettings"),
+			redirect("https://example.com/old", "/new"),
+		},
+		303: {redirect("https://example.com/account", "../login")},
+	}
+
+	if login := classifyLoginRedirects(urlStatuses); login != "https://example.com/login" {
+		t.Fatalf("Expected the login page https://example.com/login but got %q", login)
+	}
+
+	login, other := splitLoginRedirects(urlStatuses[302])
+	if len(login) != 2 || len(other) != 1 || other[0].URL != "https://example.com/old" {
+		t.Errorf("Expected 2 redirects to the login page and 1 other redirect but got %v and %v", login, other)
+	}
+	if labels := urlStatuses[303][0].Labels; len(labels) != 1 || labels[0] != loginRedirectLabel {
+		t.Errorf("Expected the 303 to be labeled as redirect to login but got %v", labels)
+	}
+}
+
+func TestClassifyLoginRedirectsWithoutLogin(t *testing.T) {
+	urlStatuses := map[int][]probe.Result{
+		302: {{Method: "GET", URL: "https://example.com/a", StatusCode: 302, Header: http.Header{"Location": {"/b"}}}},
+	}
+
+	if login := classifyLoginRedirects(urlStatuses); login != "" {
+		t.Errorf("Expected no login page for a single redirect but got %q", login)
+	}
+}
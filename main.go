@@ -2,7 +2,8 @@ package main
 
 import (
 	"bufio"
-	"crypto/tls"
+	"context"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"log"
@@ -10,29 +11,142 @@ import (
 	"net/http"
 	neturl "net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
-	"sync/atomic"
+	"text/template"
 	"time"
 
+	"sessionprobe/pkg/probe"
+
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	headers          string
+	headers          []string
+	headersFile      string
 	urls             string
 	threads          int
 	out              string
+	outputDir        string
+	outputDirMode    string
+	outputEncArg     string
+	outJSON          string
 	proxy            string
+	proxyCA          string
 	skipVerification bool
 	filterRegex      string
 	filterLengths    string
 	ignoreCSS        bool
 	ignoreJS         bool
+	ignoreExtensions string
+	ignoredExts      map[string]bool
+	scopeInclude     string
+	scopeExclude     string
+	excludeFile      string
+	includeRegex     *regexp.Regexp
+	excludeRegex     *regexp.Regexp
+	allowDangerous   bool
+	dedupeMode       string
+	streamURLs       bool
+	bloomCapacity    int
+	shardArg         string
+	normalize        bool
+	maxBodySize      string
+	bodySample       string
+	noBody           bool
+	maxRedirects     int
+	delay            time.Duration
+	jitter           time.Duration
+	userAgent        string
+	randomAgent      bool
+	hostHeader       string
+	rawHeaders       bool
+	http10           bool
+	noKeepAlive      bool
+	sni              string
+	tlsMin           string
+	tlsMax           string
+	tlsCiphers       string
+	resolve          []string
+	resolveFile      string
+	portMap          []string
+	sourceIP         string
+	iface            string
+	matchersFile     string
+	rulesFile        string
+	matchJSONPath    []string
+	matchXPath       []string
+	correlationHdr   string
+	preHook          string
+	postHook         string
+	postHookBody     bool
+	signAlgorithm    string
+	signKey          string
+	signHeader       string
+	signInput        string
+	signEncoding     string
+	notifyWebhook    string
+	notifyStatus     string
+	notifyURLRegex   string
+	notifySlack      string
+	notifyDiscord    string
+	notifyTeams      string
+	expectFile       string
+	workers          string
+	workerToken      string
+	groupBy          string
+	flagPaths        string
+	flagWords        []string
+	scanSecrets      bool
+	snippetLength    int
+	compareUnauth    bool
+	compareHeaders   string
+	normalizeRegexes []string
+	idorParams       string
+	idorValues       string
+	graphqlQueries   string
+	basicAuth        string
+	authName         string
+	credentialsPath  string
+	bearerToken      string
+	cookieFile       string
+	exportBurpFile   string
+	exportBurpStatus string
+	exportDefectDojo string
+	outJUnit         string
+	outHTML          string
+	tagArgs          []string
+	notesFile        string
+	esURL            string
+	esIndex          string
+	esAuth           string
+	outputTemplate   string
+	captureHeaderArg string
+	extractHeaderArg []string
+	auditHeadersFlag bool
+	checkCORSFlag    bool
+	bypass403        bool
+	spoofInternal    bool
+	mutatePaths      bool
+	maxResults       int
+	stopOnStatus     string
+	maxErrorRate     float64
+	errorWindow      int
+	errorPause       time.Duration
+	statsInterval    time.Duration
+	pprofAddr        string
+	noUpdateCheck    bool
+	retryFile        string
+	repeatCount      int
+	warmUp           int
+	stickySessions   bool
+	order            string
 	methodPOST       bool
 	methodPUT        bool
 	methodDELETE     bool
@@ -43,12 +157,6 @@ var (
 	yellow           = color.New(color.FgYellow).SprintFunc()
 )
 
-type Result struct {
-	Method string
-	URL    string
-	Length int
-}
-
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "sessionprobe",
@@ -59,106 +167,921 @@ func main() {
 ./sessionprobe -u ./urls.txt --threads 15 -H "Cookie: .AspNetCore.Cookies=<cookie>" -o ./output.txt
 ./sessionprobe -u ./urls.txt -H "Authorization: Bearer <token>" --proxy http://localhost:8080
 ./sessionprobe -u ./urls.txt -r "Page Not Found"
-./sessionprobe -u ./urls.txt -H "Cookie: .AspNetCore.Cookies=<cookie>;Cookie: <another-cookie>=<another_value>"`,
+./sessionprobe -u ./urls.txt -H "Cookie: .AspNetCore.Cookies=<cookie>;Cookie: <another-cookie>=<another_value>"
+./sessionprobe -u ./urls.txt --out-json ./new.json && ./sessionprobe diff ./old.json ./new.json
+./sessionprobe validate -u ./urls.txt --headers-file ./headers.txt
+./sessionprobe replay ./results.json -H "Cookie: session=<other-user>" --out-json ./replayed.json`,
 		Run: run,
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&headers, "headers", "H", "", "HTTP headers to be used in the requests in the format \"Key1:Value1;Key2:Value2;...\"")
-	rootCmd.PersistentFlags().StringVarP(&urls, "urls", "u", "", "file containing the URLs to be checked (required)")
-	rootCmd.PersistentFlags().IntVarP(&threads, "threads", "t", 10, "number of threads")
-	rootCmd.PersistentFlags().StringVarP(&out, "out", "o", "output.txt", "output file")
-	rootCmd.PersistentFlags().StringVarP(&proxy, "proxy", "p", "", "proxy URL (default: \"\")")
-	rootCmd.PersistentFlags().BoolVar(&skipVerification, "skip-verification", false, "skip verification of SSL certificates (default false)")
-	rootCmd.PersistentFlags().BoolVar(&ignoreCSS, "ignore-css", true, "ignore URLs ending with .css")
-	rootCmd.PersistentFlags().BoolVar(&ignoreJS, "ignore-js", true, "ignore URLs ending with .js")
-	rootCmd.PersistentFlags().StringVarP(&filterRegex, "filter-regex", "r", "", "Exclude HTTP responses using a regex. Responses whose body matches this regex will not be part of the output.")
-	rootCmd.PersistentFlags().StringVarP(&filterLengths, "filter-lengths", "l", "", "Exclude HTTP responses by body length. You can specify lengths separated by commas (e.g., \"123,456,789\").")
-	rootCmd.PersistentFlags().BoolVar(&methodPOST, "check-post", false, "Check POST method (default false)")
-	rootCmd.PersistentFlags().BoolVar(&methodPUT, "check-put", false, "Check PUT method (default false)")
-	rootCmd.PersistentFlags().BoolVar(&methodDELETE, "check-delete", false, "Check DELETE method (default false)")
-	rootCmd.PersistentFlags().BoolVar(&methodPATCH, "check-patch", false, "Check PATCH method (default false)")
-	rootCmd.PersistentFlags().BoolVar(&methodALL, "check-all", false, "Check POST, DELETE, PUT & PATCH methods (default false)")
+	rootCmd.AddCommand(newScanCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newReportCmd())
+	rootCmd.AddCommand(newReplayCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newAuthCmd())
+	rootCmd.AddCommand(newPreflightCmd())
+	rootCmd.AddCommand(newTrendCmd())
+	rootCmd.AddCommand(newTriageCmd())
+
+	// only the flags that every command needs are persistent, the flags of a scan are registered on the commands that
+	// run one (running sessionprobe without a command is the same as `scan`)
+	rootCmd.PersistentFlags().StringVar(&notesFile, "notes", "", "file with analyst notes, one \"[<method>] <URL> => <disposition>: <comment>\" per line (e.g. \"https://example.com/profile => false-positive: public profile\"), which are merged into the outputs")
+	rootCmd.PersistentFlags().StringVar(&credentialsPath, "credentials-file", defaultCredentialsPath(), "path of the encrypted credentials store")
+	addScanFlags(rootCmd.Flags())
 
 	rootCmd.Execute()
 }
 
+// addScanFlags registers the flags of a scan, which are accepted by the root command, `scan`, `validate` and `replay`
+func addScanFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVarP(&headers, "headers", "H", nil, "HTTP header to be used in the requests in the format \"Key:Value\" (can be used multiple times, or as \"Key1:Value1;Key2:Value2;...\"). Values may contain {{uuid}}, {{unixtime}} and {{randstr N}}, which are evaluated per request")
+	flags.StringVar(&headersFile, "headers-file", "", "file containing HTTP headers to be used in the requests (one \"Key: Value\" per line)")
+	flags.StringVarP(&urls, "urls", "u", "", "file containing the URLs to be checked (required)")
+	addReportFlags(flags)
+	flags.IntVarP(&threads, "threads", "t", 10, "number of threads")
+	flags.StringVar(&outputDir, "output-dir", "", "directory (created if missing) into which the output file and all other relative output paths (e.g. --out-json, --out-html, failed.txt) are written")
+	flags.StringVar(&outputDirMode, "output-dir-mode", "0755", "permissions of the --output-dir if it's created, in octal (e.g. \"0700\")")
+	flags.StringVar(&retryFile, "retry-file", "", "re-check only the URLs that failed in a previous run (e.g., \"failed.txt\", which is written next to the output file) instead of the --urls file")
+	flags.StringVar(&outJSON, "out-json", "", "additional output file in JSON format (e.g. to compare scans via \"sessionprobe diff\")")
+	flags.StringVar(&flagPaths, "flag-paths", defaultFlagPaths, "comma-separated watchlist of interesting path words whose matches are highlighted in the output and logged immediately (empty to disable)")
+	flags.StringVar(&exportBurpFile, "export-burp", "", "file to which the findings are exported as Burp items XML (request/response pairs)")
+	flags.StringVar(&exportBurpStatus, "export-burp-status", "", "only export findings with these status codes to Burp, separated by commas (default: all 2xx)")
+	flags.StringVar(&outJUnit, "out-junit", "", "additional output file in JUnit XML format, in which every expectation or access-control verdict is a test case (e.g. for CI test reports)")
+	flags.StringArrayVar(&tagArgs, "tag", nil, "metadata of the scan in the format \"key=value\", e.g. \"engagement=acme\", which is recorded in all structured outputs (can be used multiple times)")
+	flags.StringVar(&esURL, "es-url", "", "Elasticsearch/OpenSearch URL to which every result is bulk-indexed while the scan is running")
+	flags.StringVar(&esIndex, "es-index", "sessionprobe", "Elasticsearch/OpenSearch index the results are written to")
+	flags.StringVar(&esAuth, "es-auth", "", "Elasticsearch/OpenSearch credentials, either \"user:pass\" or an API key")
+	flags.StringVar(&outputTemplate, "output-template", "", "Go template file that replaces the default layout of the output file (see \"Custom Output\" in the README)")
+	flags.StringVar(&captureHeaderArg, "capture-headers", "", "comma-separated response headers that are recorded per result in the structured outputs (e.g. \"Server,X-Powered-By,Set-Cookie,Location\")")
+	flags.StringArrayVar(&extractHeaderArg, "extract-header", nil, "extract a value from a response header per result in the format \"Name: regex\", e.g. \"Location: (.*)\" or \"X-RateLimit-Remaining: \\d+\" (the first capture group, or the whole match, is extracted; can be used multiple times)")
+	flags.BoolVar(&auditHeadersFlag, "audit-headers", false, "audit the responses for missing or weak security headers (CSP, HSTS, X-Frame-Options, caching of authenticated pages) and add an audit section to the output (default false)")
+	flags.BoolVar(&checkCORSFlag, "check-cors", false, "send a request and a preflight with a foreign Origin to every URL and report endpoints that allow it with credentials (default false)")
+	flags.BoolVar(&bypass403, "bypass-403", false, "retry URLs returning 401/403 with path tricks (e.g. \"/%2e/\", \"/.;/\", double slashes, case changes) and X-Original-URL/X-Rewrite-URL headers, reporting variants that return 2xx (default false)")
+	flags.BoolVar(&spoofInternal, "spoof-internal", false, "retry URLs returning 401/403 with X-Forwarded-For, X-Real-IP, X-Forwarded-Host and similar headers set to internal addresses, reporting variants that return 2xx (default false)")
+	flags.BoolVar(&mutatePaths, "mutate-paths", false, "retry denied URLs (4xx) with percent-encoded, double-encoded and Unicode variants of their path, reporting variants that return 2xx (default false)")
+	flags.IntVar(&maxResults, "max-results", 0, "stop the scan once this many results were found (0 means unlimited)")
+	flags.StringVar(&stopOnStatus, "stop-on-status", "", "stop the scan as soon as a response has one of these status codes, separated by commas (e.g., \"500,503\")")
+	flags.IntVar(&repeatCount, "repeat", 1, "send every request this many times and report the latency and the consistency of the status codes and lengths per URL")
+	flags.IntVar(&warmUp, "warm-up", 0, "number of throwaway GET requests per host before the scan, so that the connection setup doesn't distort the first results and load balancers with session affinity settle")
+	flags.BoolVar(&stickySessions, "sticky-sessions", false, "give every thread its own connection pool and cookie jar, so that cookies set by the responses (e.g. rolling session tokens) are sent with its following requests (default false)")
+	flags.StringVar(&order, "order", orderAsGiven, "order in which the URLs are checked: \"as-given\" (the order of the URLs file), \"by-host\" (all URLs of a host after each other, which maximizes connection reuse) or \"random\" (spreads the load and avoids sequential access patterns)")
+	flags.Float64Var(&maxErrorRate, "max-error-rate", 0, "abort the scan (or pause it, see --error-pause) when more than this percentage of the last --error-window requests failed (0 disables the check)")
+	flags.IntVar(&errorWindow, "error-window", 50, "number of recent requests the --max-error-rate is calculated over")
+	flags.DurationVar(&errorPause, "error-pause", 0, "pause the scan for this duration instead of aborting it when the --max-error-rate is exceeded, e.g. \"5m\"")
+	flags.BoolVar(&noUpdateCheck, "no-update-check", false, "don't check GitHub for a newer version and don't read the ./VERSION file, e.g. in air-gapped environments (same as setting SESSIONPROBE_NO_UPDATE_CHECK=1)")
+	flags.StringVar(&pprofAddr, "pprof", "", "serve the net/http/pprof endpoints on this address during the scan (e.g. \"127.0.0.1:6060\", a port alone is served on 127.0.0.1) to profile its CPU and memory usage")
+	flags.DurationVar(&statsInterval, "stats-interval", 0, "print a snapshot of the throughput, status codes, errors and slowest in-flight requests at this interval, e.g. \"30s\" (a snapshot is also printed on SIGQUIT, i.e. Ctrl+\\)")
+	flags.StringVar(&authName, "auth", "", "name of a credential in the encrypted credentials store (see \"sessionprobe auth\") whose headers are used in the requests")
+	flags.StringVar(&basicAuth, "basic", "", "credentials for HTTP basic authentication in the format \"user:pass\" (sets the Authorization header)")
+	flags.StringVar(&bearerToken, "bearer", "", "bearer token that is sent in the Authorization header")
+	flags.StringVar(&cookieFile, "cookie-file", "", "cookies file in the Netscape format (e.g. exported from a browser or written by curl) whose cookies are sent to matching domains and paths")
+	flags.StringVarP(&proxy, "proxy", "p", "", "proxy URL, e.g. \"http://127.0.0.1:8080\" or \"https://proxy.example.com\" for a proxy that requires TLS (default: \"\")")
+	flags.StringVar(&proxyCA, "proxy-ca", "", "PEM file with the CA certificates the TLS connection to an https:// proxy is verified against (default: the system's CAs)")
+	flags.BoolVar(&skipVerification, "skip-verification", false, "skip verification of SSL certificates (default false)")
+	flags.StringVar(&ignoreExtensions, "ignore-extensions", "css,js,png,jpg,jpeg,gif,ico,svg,woff,woff2,ttf,eot,map", "comma-separated list of file extensions to ignore (e.g., \"css,js,png\")")
+	flags.BoolVar(&ignoreCSS, "ignore-css", true, "ignore URLs ending with .css (alias for adding/removing 'css' in --ignore-extensions)")
+	flags.BoolVar(&ignoreJS, "ignore-js", true, "ignore URLs ending with .js (alias for adding/removing 'js' in --ignore-extensions)")
+	flags.StringVar(&scopeInclude, "scope-include", "", "only check URLs matching this regex (e.g., \"^https://app\\.example\\.com/\")")
+	flags.StringVar(&scopeExclude, "scope-exclude", "", "skip URLs matching this regex (e.g., \"/logout|/delete\")")
+	flags.StringVar(&excludeFile, "exclude-file", "", "skip URLs matching the gitignore-like patterns in this file, one per line (e.g., \"*.png\", \"/api/v1/health\" or \"staging.*\")")
+	flags.BoolVar(&allowDangerous, "allow-dangerous", false, "also check logout and other destructive endpoints, which are skipped by default (default false)")
+	flags.StringVar(&dedupeMode, "dedupe", dedupeExact, "how URLs are deduplicated: \"exact\", \"query-agnostic\" (ignore query strings), \"param-name-only\" (ignore query values) or \"bloom\" (like exact, but with a bloom filter that needs little memory for huge lists and may skip a few URLs)")
+	flags.IntVar(&bloomCapacity, "bloom-capacity", 10000000, "number of URLs the bloom filter of \"--dedupe bloom\" is sized for (about 1.8MB per million URLs), beyond which it skips more URLs by mistake")
+	flags.StringVar(&shardArg, "shard", "", "only check the given slice of the deduplicated URLs, e.g. \"2/5\" for the second of five, so that a scan can be split across several machines (each running with the same URLs file)")
+	flags.BoolVar(&streamURLs, "stream", false, "check the URLs while they're read from the file instead of loading them up front, so that huge lists don't have to fit into memory (the matched results and latencies are still kept for the reports; not combinable with the checks that need all URLs, e.g. --order or --compare-unauth)")
+	flags.BoolVar(&normalize, "normalize", true, "normalize URLs (e.g. case of the host, default ports, dot segments, query order) before deduplicating them")
+	flags.StringVar(&maxBodySize, "max-body-size", "10MB", "maximum number of bytes read per response body, e.g. \"512KB\" or \"1MB\" (0 means unlimited)")
+	flags.StringVar(&bodySample, "body-sample", "", "only request the first bytes of the response bodies via a Range header, e.g. \"4096\" or \"4KB\" (bodies of servers that ignore it are cut off)")
+	flags.BoolVar(&noBody, "no-body", false, "don't read response bodies and only report the status code and Content-Length (default false)")
+	flags.IntVar(&maxRedirects, "max-redirects", 0, "follow up to this many redirects per request and report the final response (0 means redirects aren't followed). Redirect loops are reported as errors")
+	flags.DurationVar(&delay, "delay", 0, "delay before each request of a thread, e.g. \"200ms\"")
+	flags.DurationVar(&jitter, "jitter", 0, "random extra delay (between 0 and the given value) added to --delay, e.g. \"100ms\"")
+	flags.StringVar(&userAgent, "user-agent", "", "User-Agent to be used in the requests (default: Go's User-Agent)")
+	flags.BoolVar(&randomAgent, "random-agent", false, "use a random browser User-Agent for every request (default false)")
+	flags.StringVar(&hostHeader, "host-header", "", "override the Host header of the requests (e.g. to probe a virtual host by IP)")
+	flags.BoolVar(&http10, "http1.0", false, "send HTTP/1.0 instead of HTTP/1.1 requests, e.g. for middleware that applies different rules to them (every request then gets its own connection)")
+	flags.BoolVar(&noKeepAlive, "no-keepalive", false, "send \"Connection: close\" and open a new connection for every request instead of reusing connections (default false)")
+	flags.BoolVar(&rawHeaders, "raw-headers", false, "send the headers with the exact casing and in the order they were provided in via --headers and --headers-file, which Go normalizes otherwise (every request then gets its own connection)")
+	flags.StringVar(&sni, "sni", "", "override the server name (SNI) sent in the TLS handshake")
+	flags.StringVar(&tlsMin, "tls-min", "", "minimum TLS version: \"1.0\", \"1.1\", \"1.2\" or \"1.3\" (e.g. \"1.0\" for legacy appliances, default: Go's default of 1.2)")
+	flags.StringVar(&tlsMax, "tls-max", "", "maximum TLS version: \"1.0\", \"1.1\", \"1.2\" or \"1.3\"")
+	flags.StringVar(&tlsCiphers, "tls-ciphers", "", "comma-separated cipher suites offered for TLS 1.2 and below, which may include insecure ones (e.g., \"TLS_RSA_WITH_AES_128_CBC_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA\")")
+	flags.StringArrayVar(&resolve, "resolve", nil, "resolve host:port to a custom IP in the format \"host:port:ip\" (can be used multiple times)")
+	flags.StringArrayVar(&portMap, "port-map", nil, "send requests for host:port to another port in the format \"host:port=newport\", e.g. \"example.com:443=8443\" or \"*:443=8443\" for all hosts (can be used multiple times)")
+	flags.StringVar(&resolveFile, "resolve-file", "", "file containing \"host:port:ip\" entries (one per line) to resolve hosts to custom IPs")
+	flags.StringVar(&sourceIP, "source-ip", "", "local IP address to send the requests from")
+	flags.StringVar(&iface, "interface", "", "network interface to send the requests from (e.g. \"tun0\")")
+	flags.StringVarP(&filterRegex, "filter-regex", "r", "", "Exclude HTTP responses using a regex. Responses whose body matches this regex will not be part of the output.")
+	flags.StringVarP(&filterLengths, "filter-lengths", "l", "", "Exclude HTTP responses by body length. You can specify lengths separated by commas (e.g., \"123,456,789\").")
+	flags.IntVar(&snippetLength, "snippet", 0, "include the first N characters of the (whitespace-collapsed) response body per result, e.g. \"120\" (default 0, i.e. none)")
+	flags.BoolVar(&scanSecrets, "scan-secrets", false, "search response bodies for sensitive data (AWS keys, JWTs, emails, internal IPs, private keys) and report it per URL (default false)")
+	flags.BoolVar(&compareUnauth, "compare-unauth", false, "additionally probe every URL without the headers and report an access-control verdict per URL instead of status codes (default false)")
+	flags.StringVar(&compareHeaders, "compare-headers", "", "headers of a second role (same format as --headers) to probe every URL with and report an access-control verdict per URL instead of status codes")
+	flags.StringArrayVar(&normalizeRegexes, "normalize-regex", nil, "regex for dynamic content (e.g. \"csrf_token=[a-f0-9]+\") that is removed from the bodies before comparing them, so that identical pages aren't reported as different (can be used multiple times)")
+	flags.StringVar(&idorParams, "idor-params", "", "comma-separated names of ID parameters (query or path, e.g. \"id,user_id\") whose values are permuted to detect IDORs")
+	flags.StringVar(&idorValues, "idor-values", "", "comma-separated values the --idor-params are replaced with (e.g. \"1,2,1337\")")
+	flags.StringVar(&graphqlQueries, "graphql-queries", "", "JSON file with GraphQL operations (name, query, variables) that are sent as POST to every detected GraphQL endpoint (default: a __typename query)")
+	flags.StringVar(&matchersFile, "matchers", "", "JSON file with matchers (conditions over status, headers, body and length) whose labels are added to matching responses")
+	flags.StringVar(&preHook, "pre-hook", "", "command that is run before every request, getting the request as JSON (method, url, headers, body) on stdin. It may print {\"headers\": {...}, \"body\": \"...\"} to modify the request, e.g. for custom authentication schemes")
+	flags.StringVar(&postHook, "post-hook", "", "command that is run for every result, getting it as JSON (method, url, status_code, length, headers, labels) on stdin. Exiting with 1 drops the result, and it may print {\"labels\": [...], \"severity\": \"...\"} to add labels and a severity")
+	flags.BoolVar(&postHookBody, "post-hook-body", false, "additionally pass the response body to the --post-hook command (default false)")
+	flags.StringVar(&signAlgorithm, "sign", "", "sign every request with an HMAC: \"hmac-sha1\", \"hmac-sha256\" or \"hmac-sha512\"")
+	flags.StringVar(&signKey, "sign-key", "", "key for --sign")
+	flags.StringVar(&signHeader, "sign-header", "X-Signature", "header the --sign signature is sent in")
+	flags.StringVar(&signInput, "sign-input", "method+path+date", "parts of the request that are signed (joined by newlines): method, path, query, host, url, date, body or any header name")
+	flags.StringVar(&signEncoding, "sign-encoding", "hex", "encoding of the --sign signature: \"hex\" or \"base64\"")
+	flags.StringVar(&correlationHdr, "correlation-header", "", "header (e.g., \"X-Scan-Id\") that is set to a unique UUID per request, so that the requests can be correlated with the logs of the target. The IDs are recorded in the output")
+	flags.StringVar(&rulesFile, "rules", "", "file with body rules, one \"<regex> => <label>\" per line, whose labels are added to responses with a matching body")
+	flags.StringArrayVar(&matchJSONPath, "match-jsonpath", nil, "JSONPath condition over JSON bodies, optionally followed by a label, e.g. '$.user.role == \"admin\" => admin' (can be used multiple times). Matching responses get the label, or the expression if there's none")
+	flags.StringArrayVar(&matchXPath, "match-xpath", nil, "XPath condition over XML bodies (e.g. of SOAP APIs), optionally followed by a label, e.g. \"//faultcode = 'soap:Client' => soap-fault\" (can be used multiple times). Matching responses get the label, or the expression if there's none")
+	flags.StringVar(&notifyWebhook, "notify-webhook", "", "webhook URL that receives a JSON payload (POST) for every matching result and a final summary")
+	flags.StringVar(&notifyStatus, "notify-status", "", "only notify the webhook about results with these status codes, separated by commas (e.g., \"200,500\")")
+	flags.StringVar(&notifyURLRegex, "notify-url-regex", "", "only notify the webhook about results whose URL matches this regex (e.g., \"/admin|/internal\")")
+	flags.StringVar(&notifySlack, "notify-slack", "", "Slack webhook URL that receives a summary once the scan is complete")
+	flags.StringVar(&notifyDiscord, "notify-discord", "", "Discord webhook URL that receives a summary once the scan is complete")
+	flags.StringVar(&notifyTeams, "notify-teams", "", "Microsoft Teams webhook URL that receives a summary once the scan is complete")
+	flags.StringVar(&expectFile, "expect", "", "file with expected status codes per URL (e.g. \"https://example.com/admin 403\"). Exits with code 1 if any response deviates")
+	flags.StringVar(&workers, "workers", "", "comma-separated list of worker URLs (running \"sessionprobe serve\") to distribute the scan across")
+	flags.StringVar(&workerToken, "worker-token", "", "API token of the workers")
+	flags.BoolVar(&methodPOST, "check-post", false, "Check POST method (default false)")
+	flags.BoolVar(&methodPUT, "check-put", false, "Check PUT method (default false)")
+	flags.BoolVar(&methodDELETE, "check-delete", false, "Check DELETE method (default false)")
+	flags.BoolVar(&methodPATCH, "check-patch", false, "Check PATCH method (default false)")
+	flags.BoolVar(&methodALL, "check-all", false, "Check POST, DELETE, PUT & PATCH methods (default false)")
+}
+
+// addReportFlags registers the flags of the outputs that `report` can write as well
+func addReportFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&out, "out", "o", "output.txt", "output file")
+	flags.StringVar(&exportDefectDojo, "export-defectdojo", "", "file to which the findings are exported in DefectDojo's generic findings import format (JSON)")
+	flags.StringVar(&outHTML, "out-html", "", "additional output file in HTML format with the results, the statistics and a timeline chart of the requests per second and the error rate (e.g. to spot throttling)")
+	flags.StringVar(&outputEncArg, "output-encoding", encodingUTF8, "encoding of the output file, e.g. for CSV files written via --output-template: \"utf-8\", \"utf-8-bom\", \"crlf\" (line endings) or \"excel\" (both), separated by commas")
+	flags.StringVar(&groupBy, "group-by", groupByStatus, "how the results are grouped in the output file: \"status\", \"host\", \"method\" or \"none\"")
+}
+
 // run() gets executed when the root command is called
 func run(cmd *cobra.Command, args []string) {
 	printIntro()
 
+	// with `--no-update-check` (e.g. in air-gapped environments), neither GitHub nor `./VERSION` is consulted, so the
+	// version is only the one set during compilation
+	checkUpdates := !updateCheckDisabled(noUpdateCheck)
+
 	// check if the AppVersion was already set during compilation - otherwise manually get it from `./current_version`
-	CheckAppVersion()
+	if checkUpdates {
+		CheckAppVersion()
+	}
 	color.Yellow("Current version: %s\n\n", AppVersion)
 
-	// check if a later version of this tool exists
-	NotifyOfUpdates()
+	// check if a later version of this tool exists
+	if checkUpdates {
+		NotifyOfUpdates()
+	}
+
+	// a retry file (e.g. the `failed.txt` of a previous run) replaces the URLs file
+	if retryFile != "" {
+		Info("Re-checking the URLs from %s", retryFile)
+		urls = retryFile
+	}
+
+	// the `urls` flag is required
+	if urls == "" {
+		Error("Please provide a URLs file using the '-urls <path_to_urls_file>' argument.")
+		Error("Use --help for more information.")
+		return
+	}
+
+	// create the `--output-dir` before the scan, so that e.g. a read-only Docker volume doesn't fail it at the end.
+	// `sessionprobe validate` doesn't create or touch any files
+	if outputDir != "" {
+		mode, err := strconv.ParseUint(outputDirMode, 8, 32)
+		if err != nil || mode > 0777 {
+			Error("Invalid output-dir-mode: %s (expected octal permissions such as 0755)", outputDirMode)
+			return
+		}
+		if !validateOnly {
+			if err := prepareOutputDir(outputDir, os.FileMode(mode)); err != nil {
+				Error("%s", err)
+				return
+			}
+		}
+
+		for _, path := range []*string{&out, &outJSON, &outJUnit, &outHTML, &exportBurpFile, &exportDefectDojo} {
+			*path = outputPath(outputDir, *path)
+		}
+		Info("Writing the output files to %s", outputDir)
+	}
+
+	if !validateOnly {
+		if err := checkOutputsWritable(out, outJSON, outJUnit, outHTML, exportBurpFile, exportDefectDojo); err != nil {
+			Error("%s", err)
+			return
+		}
+	}
+
+	if !isValidGroupBy(groupBy) {
+		Error("Invalid group-by: %s (valid values: %s)", groupBy, strings.Join(groupByModes, ", "))
+		return
+	}
+
+	if !isValidOrder(order) {
+		Error("Invalid order: %s (valid values: %s)", order, strings.Join(orderModes, ", "))
+		return
+	}
+
+	if !isValidDedupeMode(dedupeMode) {
+		Error("Invalid dedupe mode: %s (valid modes: %s)", dedupeMode, strings.Join(dedupeModes, ", "))
+		return
+	}
+
+	if workers != "" {
+		if conflicts := workerConflicts(cmd.Flags()); len(conflicts) > 0 {
+			Error("--workers can't be combined with %s, which the workers don't support", strings.Join(conflicts, ", "))
+			return
+		}
+	}
+
+	if streamURLs {
+		if conflicts := streamConflicts(); len(conflicts) > 0 {
+			Error("--stream can't be combined with %s, which need all URLs up front", strings.Join(conflicts, ", "))
+			return
+		}
+	}
+
+	maxBodyBytes, err := parseSize(maxBodySize)
+	if err != nil {
+		Error("Invalid max body size: %s", err)
+		return
+	}
+
+	bodySampleBytes, err := parseSize(bodySample)
+	if err != nil {
+		Error("Invalid body sample size: %s", err)
+		return
+	}
+
+	if scanShard, err = parseShard(shardArg); err != nil {
+		Error("%s", err)
+		return
+	}
+	if scanShard.count > 1 {
+		Info("Only checking shard %s of the URLs", scanShard)
+	}
+
+	flagWords = splitList(flagPaths)
+
+	for _, name := range splitList(captureHeaderArg) {
+		capturedHeaders = append(capturedHeaders, http.CanonicalHeaderKey(name))
+	}
+
+	if headerExtractors, err = parseHeaderExtractors(extractHeaderArg); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if scanTags, err = parseTags(tagArgs); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if err := loadNotesFile(); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	ignoredExts = parseExtensions(ignoreExtensions)
+
+	// the legacy `--ignore-css` and `--ignore-js` flags only take effect if they were explicitly provided
+	if cmd.Flags().Changed("ignore-css") {
+		ignoredExts["css"] = ignoreCSS
+	}
+	if cmd.Flags().Changed("ignore-js") {
+		ignoredExts["js"] = ignoreJS
+	}
+
+	if exts := enabledExtensions(ignoredExts); len(exts) > 0 {
+		Info("Ignoring URLs with the following extensions: %s", strings.Join(exts, ", "))
+	}
+
+	// compile the scope regexes provided via `--scope-include` and `--scope-exclude`
+	if includeRegex, err = compileOptionalRegex(scopeInclude); err != nil {
+		Error("Invalid scope-include regex: %s", err)
+		return
+	}
+	if excludeRegex, err = compileOptionalRegex(scopeExclude); err != nil {
+		Error("Invalid scope-exclude regex: %s", err)
+		return
+	}
+	if excludeFile != "" {
+		if excludeRules, err = loadExcludeFile(excludeFile); err != nil {
+			Error("Failed to read the exclude file: %s", err)
+			return
+		}
+	}
+
+	var headersMap map[string][]string
+	for _, header := range headers {
+		headersMap = mergeHeaders(headersMap, parseHeaders(header))
+	}
+	if headersFile != "" {
+		fileHeaders, err := readHeadersFile(headersFile)
+		if err != nil {
+			Error("Failed to read the headers file: %s", err)
+			return
+		}
+		headersMap = mergeHeaders(headersMap, fileHeaders)
+	}
+	var headerNames []string
+	if rawHeaders {
+		if headerNames, err = headerOrder(headers, headersFile); err != nil {
+			Error("Failed to read the headers file: %s", err)
+			return
+		}
+	}
+	if authName != "" {
+		storedHeaders, err := credentialHeaders(credentialsPath, authName)
+		if err != nil {
+			Error("Failed to load the credential %s: %s", authName, err)
+			return
+		}
+		headersMap = mergeHeaders(headersMap, storedHeaders)
+	}
+
+	// resolve references like "secret://env/TOKEN", so that the secrets don't have to be passed on the command line
+	if err := resolveHeaderSecrets(headersMap); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	// `--basic` and `--bearer` take precedence over an Authorization header provided via `--headers`
+	authorization, err := authorizationHeader(basicAuth, bearerToken)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	if authorization != "" {
+		if headersMap == nil {
+			headersMap = make(map[string][]string)
+		}
+		headersMap["Authorization"] = []string{authorization}
+	}
+
+	var requestHooks []probe.RequestHook
+	// header values with template functions (e.g. "{{uuid}}") are evaluated for every request
+	if templates, err := parseHeaderTemplates(headersMap); err != nil {
+		Error("%s", err)
+		return
+	} else if templates != nil {
+		// the workers would send the templates literally
+		if workers != "" {
+			Error("Header templates aren't supported in combination with --workers")
+			return
+		}
+		requestHooks = append(requestHooks, templates)
+	}
+
+	if cookieFile != "" {
+		jar, count, err := readCookieFile(cookieFile)
+		if err != nil {
+			Error("Failed to read the cookie file: %s", err)
+			return
+		}
+		Info("Loaded %d cookies from %s", count, cookieFile)
+		requestHooks = append(requestHooks, cookieJarHook(jar))
+	}
+
+	if preHook != "" {
+		hook, err := newCommandRequestHook(preHook)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+		requestHooks = append(requestHooks, hook)
+	}
+
+	// the signature has to cover the final request, so the signer is the last hook
+	if signAlgorithm != "" {
+		if requestSigner, err = newHMACSigner(signAlgorithm, signKey, signHeader, signInput, signEncoding); err != nil {
+			Error("%s", err)
+			return
+		}
+		requestHooks = append(requestHooks, requestSigner)
+	}
+
+	// collect the static DNS overrides provided via `--resolve` and `--resolve-file`
+	resolveEntries := resolve
+	if resolveFile != "" {
+		fileEntries, err := probe.ReadResolveFile(resolveFile)
+		if err != nil {
+			Error("Failed to read resolve file: %s", err)
+			return
+		}
+		resolveEntries = append(resolveEntries, fileEntries...)
+	}
+	resolveOverrides, err := probe.ParseResolveOverrides(resolveEntries)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	for host, addr := range resolveOverrides {
+		Info("Resolving %s to %s", host, addr)
+	}
+
+	tlsMinVersion, tlsMaxVersion, err := probe.ParseTLSVersionRange(tlsMin, tlsMax)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	cipherSuites, err := probe.ParseCipherSuites(tlsCiphers)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	portMapping, err := probe.ParsePortMap(portMap)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	for addr, port := range portMapping {
+		Info("Sending requests for %s to port %s", addr, port)
+	}
+
+	// bind outgoing connections to the address provided via `--source-ip` or `--interface`
+	localAddr, err := probe.ResolveLocalAddr(sourceIP, iface)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	if localAddr != nil {
+		Info("Sending requests from %s", localAddr.IP)
+	}
+
+	// if a proxy was provided, check if the proxy is reachable. Exit if it's not. `sessionprobe validate` doesn't
+	// open any connections
+	if proxy != "" && !validateOnly {
+		checkProxyReachability(proxy)
+	}
+
+	var proxyRootCAs *x509.CertPool
+	if proxyCA != "" {
+		if proxyRootCAs, err = readCertPool(proxyCA); err != nil {
+			Error("Failed to read the proxy CA: %s", err)
+			return
+		}
+	}
+
+	if maxRedirects < 0 {
+		Error("The --max-redirects can't be negative")
+		return
+	}
+
+	if noBody {
+		Info("Not reading response bodies, lengths are taken from the Content-Length header")
+		if filterRegex != "" {
+			Warn("The --filter-regex is ignored because --no-body is set")
+		}
+		if scanSecrets {
+			Warn("The --scan-secrets is ignored because --no-body is set")
+		}
+		if snippetLength > 0 {
+			Warn("The --snippet is ignored because --no-body is set")
+		}
+	} else if bodySampleBytes > 0 {
+		Info("Only requesting the first %d bytes of the response bodies", bodySampleBytes)
+	}
+
+	// compile the regex provided via `-fr`
+	compiledRegex, err := compileOptionalRegex(filterRegex)
+	if err != nil {
+		Error("Invalid regex: %s", err)
+		return
+	}
+
+	var matchers []probe.Matcher
+	if matchersFile != "" {
+		if matchers, err = probe.LoadMatchers(matchersFile); err != nil {
+			Error("Failed to load matchers: %s", err)
+			return
+		}
+		Info("Loaded %d matchers", len(matchers))
+	}
+	if rulesFile != "" {
+		rules, err := probe.LoadRules(rulesFile)
+		if err != nil {
+			Error("Failed to load rules: %s", err)
+			return
+		}
+		matchers = append(matchers, rules...)
+		Info("Loaded %d rules", len(rules))
+	}
+	for _, expression := range matchJSONPath {
+		matcher, err := probe.JSONPathMatcher(expression)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+		matchers = append(matchers, matcher)
+	}
+	for _, expression := range matchXPath {
+		matcher, err := probe.XPathMatcher(expression)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	var outTemplate *template.Template
+	if outputTemplate != "" {
+		if outTemplate, err = loadOutputTemplate(outputTemplate); err != nil {
+			Error("%s", err)
+			return
+		}
+	}
+
+	encoding, err := parseOutputEncoding(outputEncArg)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if notifyWebhook != "" {
+		notifyRegex, err := compileOptionalRegex(notifyURLRegex)
+		if err != nil {
+			Error("Invalid notify-url-regex: %s", err)
+			return
+		}
+		notifier = newWebhookNotifier(notifyWebhook, parseLengths(notifyStatus), notifyRegex)
+		// the results already queued are still sent if the scan ends early, e.g. because a check after it failed
+		defer notifier.drain()
+		Info("Sending notifications to the webhook on %s", webhookHost(notifyWebhook))
+	}
+
+	if esURL != "" {
+		shipper = newESShipper(esURL, esIndex, esAuth)
+		Info("Indexing the results in %s (index %s)", esURL, esIndex)
+	}
+
+	for kind, url := range map[string]string{"slack": notifySlack, "discord": notifyDiscord, "teams": notifyTeams} {
+		if url != "" {
+			chatWebhooks = append(chatWebhooks, chatWebhook{kind: kind, url: url})
+		}
+	}
+
+	if expectFile != "" {
+		if expectations, err = loadExpectations(expectFile); err != nil {
+			Error("Failed to load expectations: %s", err)
+			return
+		}
+		Info("Loaded %d expectations", len(expectations.expected))
+	}
+
+	file, err := os.Open(urls)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	defer file.Close()
+
+	// the deduplicated URLs in the order in which they are checked. The other checks use them as a set
+	var urlList []string
+	var inputCount int
+	var stream *urlStream
+	if streamURLs {
+		stream = newURLStream(file)
+	} else {
+		urlList, inputCount = readURLs(file)
+		urlList = orderURLs(urlList, order)
+	}
+	urlsMap := make(map[string]bool)
+	for _, url := range urlList {
+		urlsMap[url] = true
+	}
+
+	opts := probe.Options{
+		Headers:           headersMap,
+		Threads:           threads,
+		Proxy:             proxy,
+		ProxyRootCAs:      proxyRootCAs,
+		SkipVerification:  skipVerification,
+		SNI:               sni,
+		TLSMinVersion:     tlsMinVersion,
+		TLSMaxVersion:     tlsMaxVersion,
+		CipherSuites:      cipherSuites,
+		ResolveOverrides:  resolveOverrides,
+		PortMap:           portMapping,
+		LocalAddr:         localAddr,
+		HostHeader:        hostHeader,
+		RawHeaders:        rawHeaders,
+		HeaderOrder:       headerNames,
+		HTTP10:            http10,
+		DisableKeepAlives: noKeepAlive,
+		UserAgent:         userAgent,
+		RandomAgent:       randomAgent,
+		WarmUpRequests:    warmUp,
+		StickySessions:    stickySessions,
+		Delay:             delay,
+		Jitter:            jitter,
+		MaxBodyBytes:      maxBodyBytes,
+		BodySample:        bodySampleBytes,
+		NoBody:            noBody,
+		MaxRedirects:      maxRedirects,
+		FilterRegex:       compiledRegex,
+		ExcludedLengths:   parseLengths(filterLengths),
+		Matchers:          matchers,
+		CorrelationHeader: correlationHdr,
+		ScanSecrets:       scanSecrets,
+		SnippetLength:     snippetLength,
+		RequestHooks:      requestHooks,
+	}
+
+	authenticated := !isUnauthenticated(headersMap) || cookieFile != ""
+	opts.ResponseHooks = append(opts.ResponseHooks, sessionCookieFindings.hook(!authenticated), fingerprints.hook(), clusters.hook())
+	if authenticated {
+		opts.ResponseHooks = append(opts.ResponseHooks, cacheHook())
+	}
+	if postHook != "" {
+		hook, err := newCommandResponseHook(postHook, postHookBody)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+		opts.ResponseHooks = append(opts.ResponseHooks, hook)
+	}
+
+	if compareUnauth || compareHeaders != "" {
+		if compareUnauth && compareHeaders != "" {
+			Error("--compare-unauth and --compare-headers can't be combined")
+			return
+		}
+		if workers != "" {
+			Error("Access-control verdicts aren't supported in combination with --workers")
+			return
+		}
+
+		comparison = newComparisonSet()
+		opts.ResponseHooks = append(opts.ResponseHooks, comparison.hook(false))
+	}
+
+	if bodyNormalizers, err = compileNormalizers(normalizeRegexes); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	// the headers of the second role (none with `--compare-unauth`)
+	var otherHeaders map[string][]string
+	if compareHeaders != "" {
+		otherHeaders = parseHeaders(compareHeaders)
+		if err := resolveHeaderSecrets(otherHeaders); err != nil {
+			Error("%s", err)
+			return
+		}
+	}
+
+	graphqlOperations := defaultGraphQLOperations
+	if graphqlQueries != "" {
+		if graphqlOperations, err = loadGraphQLOperations(graphqlQueries); err != nil {
+			Error("Failed to load GraphQL queries: %s", err)
+			return
+		}
+		Info("Loaded %d GraphQL operations", len(graphqlOperations))
+	}
+
+	if (idorParams == "") != (idorValues == "") {
+		Error("--idor-params and --idor-values have to be used together")
+		return
+	}
+
+	// `sessionprobe validate` ends here, as everything was read and checked
+	if validateOnly {
+		if stream != nil {
+			Info("The configuration is valid (the URLs are only read during a --stream scan)")
+		} else {
+			Info("The configuration is valid: %d unique URLs (of %d in the file) would be checked", len(urlList), inputCount)
+		}
+		return
+	}
+
+	if pprofAddr != "" {
+		listener, err := startPprof(pprofAddr)
+		if err != nil {
+			Error("Failed to start pprof: %s", err)
+			return
+		}
+		defer listener.Close()
+		Info("Serving pprof on http://%s/debug/pprof/", listener.Addr())
+	}
+
+	// map to store URLs by status code
+	var urlStatuses map[int][]probe.Result
+	var stats *scanStats
+	if workers != "" {
+		// as coordinator, a failing worker doesn't discard the results of the other workers
+		urlStatuses, stats, err = distributeURLs(urlsMap, opts, splitList(workers), workerToken)
+		if err != nil {
+			Error("%s", err)
+			if stats == nil {
+				return
+			}
+		}
+	} else {
+		urlStatuses, stats, err = processURLs(urlList, stream, opts)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+	}
+	stats.InputURLs = inputCount
+	if stream != nil {
+		stats.InputURLs = stream.inputCount
+		// the GraphQL endpoints are the only URLs the checks after the scan need in streaming mode
+		urlsMap = stream.graphqlURLs
+	}
+
+	if comparison != nil {
+		if err := comparison.probeOther(urlsMap, opts, otherHeaders); err != nil {
+			Error("%s", err)
+			return
+		}
+	}
+
+	if idorParams != "" {
+		if idorCandidates, err = probeIDOR(urlsMap, opts, splitList(idorParams), splitList(idorValues)); err != nil {
+			Error("%s", err)
+			return
+		}
+		for _, c := range idorCandidates {
+			Warn("Potential IDOR: %s %s (%s=%s) returned %d with different content", c.Method, c.URL, c.Param, c.Value, c.StatusCode)
+		}
+	}
+
+	if graphqlResults, err = probeGraphQL(urlsMap, opts, graphqlOperations); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if login := classifyLoginRedirects(urlStatuses); login != "" {
+		Info("Detected the login page %s, redirects to it are classified as unauthorized", login)
+	}
+
+	if suppressed := suppressFalsePositives(urlStatuses); suppressed > 0 {
+		Info("Left out %d results marked as false-positive in %s", suppressed, notesFile)
+	}
+
+	var generators []bypassGenerator
+	if bypass403 {
+		generators = append(generators, pathBypassVariants)
+	}
+	if spoofInternal {
+		generators = append(generators, spoofBypassVariants)
+	}
+	if len(generators) > 0 {
+		denied := deniedResults(urlStatuses, http.StatusUnauthorized, http.StatusForbidden)
+		Info("Trying to bypass the access control of %d denied requests", len(denied))
+
+		for _, generate := range generators {
+			findings, err := probeBypasses(denied, opts, generate)
+			if err != nil {
+				Error("%s", err)
+				return
+			}
+			bypassFindings = append(bypassFindings, findings...)
+		}
+	}
+
+	// the encoding mutations are compared against all client errors, since normalization issues often show as 404s
+	if mutatePaths {
+		var statuses []int
+		for status := range urlStatuses {
+			if status >= 400 && status < 500 {
+				statuses = append(statuses, status)
+			}
+		}
+		denied := deniedResults(urlStatuses, statuses...)
+		Info("Probing encoded variants of %d denied requests", len(denied))
+
+		findings, err := probeBypasses(denied, opts, encodingBypassVariants)
+		if err != nil {
+			Error("%s", err)
+			return
+		}
+		bypassFindings = append(bypassFindings, findings...)
+	}
+
+	if checkCORSFlag {
+		if corsFindings, err = checkCORS(urlsMap, opts); err != nil {
+			Error("%s", err)
+			return
+		}
+	}
 
-	// the `urls` flag is required
-	if urls == "" {
-		Error("Please provide a URLs file using the '-urls <path_to_urls_file>' argument.")
-		Error("Use --help for more information.")
-		return
+	if auditHeadersFlag {
+		headerAudits = buildHeaderAudits(urlStatuses, authenticated)
+		Info("The security header audit found issues in %d responses", len(headerAudits))
 	}
 
-	if ignoreCSS {
-		Info("Ignoring URLs that end with .css")
+	// print the status classes and the latency statistics
+	var latency strings.Builder
+	stats.writeClasses(&latency)
+	stats.writeLatency(&latency)
+	for _, line := range strings.Split(strings.TrimSpace(latency.String()), "\n") {
+		Info("%s", line)
 	}
 
-	if ignoreJS {
-		Info("Ignoring URLs that end with .js")
+	if notifier != nil {
+		notifier.notifySummary(stats)
 	}
 
-	var headersMap map[string][]string
-	if headers != "" {
-		headersMap = parseHeaders(headers)
+	if shipper != nil {
+		shipper.flush()
+		if shipper.failed > 0 {
+			Warn("%d results couldn't be indexed in Elasticsearch", shipper.failed)
+		}
 	}
 
-	// if a proxy was provided, check if the proxy is reachable. Exit if it's not
-	if proxy != "" {
-		checkProxyReachability(proxy)
+	if len(chatWebhooks) > 0 {
+		notifyChats(buildChatSummary(stats, urlStatuses))
 	}
 
-	// compile the regex provided via `-fr`
-	var compiledRegex *regexp.Regexp
-	if filterRegex != "" {
-		var err error
-		compiledRegex, err = regexp.Compile(filterRegex)
-		if err != nil {
-			Error("Invalid regex: %s", err)
-			return
-		}
+	outFile, err := os.Create(out)
+	if err != nil {
+		Error("%s", err)
+		return
 	}
+	defer outFile.Close()
 
-	file, err := os.Open(urls)
+	outWriter, err := encoding.writer(outFile)
 	if err != nil {
 		Error("%s", err)
 		return
 	}
-	defer file.Close()
 
-	// create semaphore with the specified number of threads
-	sem := make(chan bool, threads)
-	// make sure to wait for all threads to finish before exiting the program
-	var wg sync.WaitGroup
+	if outTemplate != nil {
+		if err := writeTemplateOutput(outTemplate, urlStatuses, stats, outWriter); err != nil {
+			Error("Failed to render the output template: %s", err)
+		}
+	} else {
+		writeToFile(urlStatuses, stats, outWriter)
+	}
 
-	// using a map to deduplicate URLs
-	urlsMap := readURLs(file)
+	failedPath := filepath.Join(filepath.Dir(out), failedURLsFile)
+	if count, err := writeFailedURLs(failedRequests, failedPath); err != nil {
+		Error("Failed to write the failed URLs: %s", err)
+	} else if count > 0 {
+		Warn("%d URLs failed and were written to %s. Re-check them via --retry-file %s", count, failedPath, failedPath)
+	}
 
-	// map to store URLs by status code
-	excludedLengths := parseLengths(filterLengths)
-	urlStatuses := processURLs(urlsMap, headersMap, proxy, &wg, sem, compiledRegex, excludedLengths)
+	if outJSON != "" {
+		if err := writeJSONFile(urlStatuses, stats, outJSON); err != nil {
+			Error("Failed to write JSON output: %s", err)
+		}
+	}
+
+	if outJUnit != "" {
+		if err := writeJUnitFile(urlStatuses, stats, outJUnit); err != nil {
+			Error("Failed to write JUnit output: %s", err)
+		}
+	}
 
-	// wait for all threads to finish
-	wg.Wait()
+	if outHTML != "" {
+		if err := writeHTMLFile(urlStatuses, stats, outHTML); err != nil {
+			Error("Failed to write HTML output: %s", err)
+		}
+	}
 
-	outFile, err := os.Create(out)
-	if err != nil {
-		Error("%s", err)
-		return
+	if exportDefectDojo != "" {
+		if exported, err := writeDefectDojoFile(urlStatuses, exportDefectDojo); err != nil {
+			Error("Failed to export the findings to DefectDojo: %s", err)
+		} else {
+			Info("Exported %d findings to %s", exported, exportDefectDojo)
+		}
 	}
-	defer outFile.Close()
 
-	writeToFile(urlStatuses, outFile)
+	if exportBurpFile != "" {
+		if exported, err := exportBurp(urlStatuses, opts, parseLengths(exportBurpStatus), exportBurpFile); err != nil {
+			Error("Failed to export the findings to Burp: %s", err)
+		} else {
+			Info("Exported %d findings to %s", exported, exportBurpFile)
+		}
+	}
+
+	// in the baseline assertion mode, fail with a non-zero exit code if any response deviated from the expectations
+	if expectations != nil {
+		deviations := expectations.deviations()
+		for _, d := range deviations {
+			Error("Deviation: %s", d)
+		}
+
+		if len(deviations) > 0 {
+			Error("%d of %d expectations failed", len(deviations), len(expectations.expected))
+			outFile.Close()
+			os.Exit(1)
+		}
+		Info("All %d expectations met", len(expectations.expected))
+	}
 }
 
 func printIntro() {
@@ -171,28 +1094,181 @@ func printIntro() {
 	color.Green("##################################\n\n")
 }
 
-func readURLs(file *os.File) map[string]bool {
+// reads the URLs from the file and deduplicates them. Also returns the number of URLs read from the file (i.e.
+// before filtering and deduplication)
+func readURLs(file *os.File) ([]string, int) {
 	// read the URLs line by line
 	scanner := bufio.NewScanner(file)
 
-	// deduplicate URLs
-	urls := make(map[string]bool)
+	// deduplicate URLs. `seen` holds the dedupe keys, so that only the first URL per key is kept
+	var urls []string
+	seen := newURLDeduper(false)
+	inputCount := 0
 	for scanner.Scan() {
-		url := scanner.Text()
+		inputCount++
 
-		if (ignoreCSS && strings.HasSuffix(url, ".css")) ||
-			(ignoreJS && strings.HasSuffix(url, ".js")) {
+		url, key, ok := prepareURL(scanner.Text())
+		if !ok || seen.seen(key) {
 			continue
 		}
 
-		urls[url] = true
+		urls = append(urls, url)
 	}
 
 	if scanner.Err() != nil {
 		Error("%s", scanner.Err())
 	}
 
-	return urls
+	return urls, inputCount
+}
+
+// converts and filters a line of the URLs file. Returns the URL to be checked and the key it's deduplicated by, or false
+// if it's skipped
+func prepareURL(url string) (string, string, bool) {
+	// hosts with unicode characters (e.g. from a browser history) are requested in their punycode form
+	if ascii, ok := idnToASCII(url); ok {
+		idnURLs[ascii] = url
+		url = ascii
+	}
+
+	if hasIgnoredExtension(url, ignoredExts) || !inScope(url) {
+		return "", "", false
+	}
+
+	if !allowDangerous && isDangerous(url) {
+		Warn("Skipping potentially dangerous URL (use --allow-dangerous to check it anyway): %s", url)
+		return "", "", false
+	}
+
+	normalized := url
+	if normalize {
+		normalized = normalizeURL(url)
+	}
+
+	key := dedupeKey(normalized, dedupeMode)
+	if !scanShard.contains(key) {
+		return "", "", false
+	}
+
+	return url, key, true
+}
+
+// splits a comma-separated list, trimming spaces and dropping empty entries
+func splitList(list string) []string {
+	var out []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+
+	return out
+}
+
+// compiles the given regex, or returns nil if no regex was provided
+func compileOptionalRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(expr)
+}
+
+// checks the URL against the `--scope-include` and `--scope-exclude` regexes and the `--exclude-file`
+func inScope(url string) bool {
+	if includeRegex != nil && !includeRegex.MatchString(url) {
+		return false
+	}
+
+	if excludeRegex != nil && excludeRegex.MatchString(url) {
+		return false
+	}
+
+	if isExcluded(url) {
+		return false
+	}
+
+	return true
+}
+
+// path fragments of endpoints that would e.g. invalidate the session being tested or alter the account
+var dangerousPaths = []string{
+	"/logout",
+	"/log-out",
+	"/log_out",
+	"/logoff",
+	"/signout",
+	"/sign-out",
+	"/sign_out",
+	"/session/destroy",
+	"/password/reset",
+	"/password/change",
+	"/reset-password",
+	"/change-password",
+	"/account/delete",
+	"/delete-account",
+	"/deactivate",
+}
+
+// checks if the URL's path contains one of the built-in `dangerousPaths`
+func isDangerous(url string) bool {
+	p := url
+	if parsed, err := neturl.Parse(url); err == nil {
+		p = parsed.Path
+	}
+	p = strings.ToLower(p)
+
+	for _, dangerous := range dangerousPaths {
+		if strings.Contains(p, dangerous) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parses a comma-separated list of extensions (e.g. "css,.js, png") into a set of lowercase extensions without dots
+func parseExtensions(extensions string) map[string]bool {
+	extensionsMap := make(map[string]bool)
+
+	for _, ext := range strings.Split(extensions, ",") {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext == "" {
+			continue
+		}
+		extensionsMap[ext] = true
+	}
+
+	return extensionsMap
+}
+
+// returns the sorted list of extensions that are currently ignored
+func enabledExtensions(extensions map[string]bool) []string {
+	var out []string
+	for ext, enabled := range extensions {
+		if enabled {
+			out = append(out, ext)
+		}
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// checks if the path of the URL ends with one of the ignored extensions. Query strings and fragments are not
+// taken into account, so e.g. `/app.js?v=3` is ignored as well
+func hasIgnoredExtension(url string, extensions map[string]bool) bool {
+	p := url
+	if parsed, err := neturl.Parse(url); err == nil {
+		p = parsed.Path
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))
+	if ext == "" {
+		return false
+	}
+
+	return extensions[ext]
 }
 
 func getMethods() []string {
@@ -225,82 +1301,291 @@ func getMethods() []string {
 	return out
 }
 
-func processURLs(urls map[string]bool, headers map[string][]string, proxy string, wg *sync.WaitGroup, sem chan bool, compiledRegex *regexp.Regexp, allowedLengths map[int]bool) map[int][]Result {
+// checks the URLs, or the ones of the stream if it isn't nil (with `--stream`)
+func processURLs(urls []string, stream *urlStream, opts probe.Options) (map[int][]probe.Result, *scanStats, error) {
 	// map to store URLs by status code
-	urlStatuses := make(map[int][]Result)
-	var urlStatusesMutex sync.Mutex
+	urlStatuses := make(map[int][]probe.Result)
+
+	// with `--repeat`, every URL is checked multiple times
+	repetitions := 1
+	if repeatCount > 1 {
+		repetitions = repeatCount
+		repeats = newRepeatSet()
+	}
 
-	var methods []string = getMethods()
+	for _, url := range urls {
+		for i := 0; i < repetitions; i++ {
+			opts.URLs = append(opts.URLs, url)
+		}
+	}
+	opts.Methods = getMethods()
 
-	// for the progress counter
-	var processedCount int32
-	totalUrls := int32(len(urls))
-	totalMethods := int32(len(methods))
-	totalRequests := totalUrls * totalMethods
+	// the scan is stopped early by cancelling the context, after which the remaining in-flight results are discarded
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if stream != nil {
+		opts.URLSource = stream.start(ctx)
+	}
+
+	// new requests can be held back while the scan is running (see pause_unix.go)
+	opts.Pause = probe.NewPause()
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	Info("Starting to check %d unique URLs (deduplicated) and %d methods => %d requests", totalUrls, totalMethods, totalRequests)
+	// for the progress counter
+	var processedCount int
+	totalUrls := len(urls)
+	totalMethods := len(opts.Methods)
+	totalRequests := len(opts.URLs) * totalMethods
+
+	if stream != nil {
+		Info("Starting to check the URLs while they're read from the file (deduplicated) with %d methods", totalMethods)
+	} else {
+		Info("Starting to check %d unique URLs (deduplicated) and %d methods => %d requests", totalUrls, totalMethods, totalRequests)
+	}
+	if repeats != nil {
+		Info("Every request is sent %d times", repetitions)
+	}
+	if opts.WarmUpRequests > 0 {
+		Info("Warming up every host with %d requests", opts.WarmUpRequests)
+	}
 	Info("We use %d threads", threads)
+	if delay > 0 || jitter > 0 {
+		Info("Each thread waits %s (+ up to %s jitter) before every request", delay, jitter)
+	}
 
-	// process each URL in the deduplicated map
-	for url := range urls {
-		wg.Add(1)
-
-		// will block if there is already `threads` threads running
-		sem <- true
-
-		// launch a new goroutine for each URL
-		go func(url string) {
-			// using defer to ensure the semaphore is released and the waitgroup is decremented regardless of where we exit in the function
-			defer func() {
-				// always release the semaphore token
-				<-sem
-				// always decrement the waitgroup counter
-				wg.Done()
-			}()
-
-			// inside the goroutine of processURLs
-			for _, method := range methods {
-				statusCode, length, matched := checkURL(method, url, headers, proxy, compiledRegex, allowedLengths)
-				if matched {
-					urlStatusesMutex.Lock()
-					urlStatuses[statusCode] = append(urlStatuses[statusCode], Result{Method: method, URL: url, Length: length})
-					urlStatusesMutex.Unlock()
-				}
+	stopPauseSignals := handlePauseSignals(opts.Pause)
+	defer stopPauseSignals()
+
+	stats := newScanStats(totalUrls)
 
-				// increment the processedCount and log progress
-				atomic.AddInt32(&processedCount, 1)
-				percentage := float64(processedCount) / float64(totalRequests) * 100
-				Info("Progress: %.2f%% (%d/%d deduped URLs processed)", percentage, processedCount, totalRequests)
+	// records a result in the statistics and, if it matched, in the output
+	var matchedCount int
+	handleResult := func(result probe.Result) {
+		stats.add(result)
+
+		if expectations != nil {
+			expectations.record(result)
+		}
+
+		if !handleHTTPError(result.Err, result.URL) && result.Matched {
+			flagResult(&result, flagWords)
+			urlStatuses[result.StatusCode] = append(urlStatuses[result.StatusCode], result)
+			matchedCount++
+
+			if notifier != nil {
+				notifier.notifyResult(result)
+			}
+		}
+
+		if result.Err != nil {
+			failedRequests = append(failedRequests, newFailedRequest(result))
+		}
+
+		if shipper != nil {
+			shipper.add(result)
+		}
+	}
+
+	stopStatuses := parseLengths(stopOnStatus)
+	breaker := newCircuitBreaker(maxErrorRate, errorWindow)
+	stopped := false
+
+	snapshots, stopSnapshots := statsSnapshots(statsInterval)
+	defer stopSnapshots()
+
+	results := scanner.Run(ctx)
+	for {
+		result, ok := nextResult(results, snapshots, stats, scanner)
+		if !ok {
+			break
+		}
+
+		if stopped {
+			continue
+		}
+
+		// with `--repeat`, only the first response of a request is part of the output, while the others are only
+		// aggregated in the repeat statistics
+		if repeats == nil || repeats.add(result) {
+			handleResult(result)
+
+			// a 405 doesn't end the story if the Allow header hints at other methods
+			for _, retry := range retryAllowedMethods(scanner, result, opts.Methods) {
+				handleResult(retry)
 			}
+		} else {
+			stats.add(result)
+		}
+
+		// increment the processedCount and log progress
+		processedCount++
+		if stream != nil {
+			// the total isn't known until the whole file was read
+			Info("Progress: %d requests processed", processedCount)
+		} else {
+			percentage := float64(processedCount) / float64(totalRequests) * 100
+			Info("Progress: %.2f%% (%d/%d deduped URLs processed)", percentage, processedCount, totalRequests)
+		}
+
+		reason := stopReason(result, matchedCount, stopStatuses)
+		if breaker != nil && breaker.record(result.Err != nil) && breaker.trip(errorPause) {
+			reason = breaker.String()
+		}
+
+		if reason != "" {
+			Warn("Stopping the scan early: %s", reason)
+			stopped = true
+			cancel()
+		}
+	}
+	stats.finish()
+	if stream != nil {
+		// the reader may still be running after an early stop, and writes the counters and idnURLs
+		cancel()
+		stream.wait()
+		stats.URLs = stream.urlCount
+	}
+
+	return urlStatuses, stats, nil
+}
+
+// returns why the scan should be stopped after the given result, or "" if it should continue
+func stopReason(result probe.Result, matchedCount int, stopStatuses map[int]bool) string {
+	if maxResults > 0 && matchedCount >= maxResults {
+		return fmt.Sprintf("found %d results (--max-results)", matchedCount)
+	}
 
-		}(url)
+	if result.Err == nil && stopStatuses[result.StatusCode] {
+		return fmt.Sprintf("%s %s returned %d (--stop-on-status)", result.Method, result.URL, result.StatusCode)
 	}
 
-	return urlStatuses
+	return ""
 }
 
 // takes a map of HTTP status codes to URLs and writes it to the output file
-func writeToFile(urlStatuses map[int][]Result, outFile *os.File) {
+func writeToFile(urlStatuses map[int][]probe.Result, stats *scanStats, outFile io.Writer) {
 	writer := bufio.NewWriter(outFile)
 
-	// sort the map keys to ensure consistent output
-	var keys []int
-	for k := range urlStatuses {
-		keys = append(keys, k)
+	// start with a header describing the scan, so that the output is self-describing
+	if stats != nil {
+		stats.writeHeader(writer)
+		_, _ = writer.WriteString("\n")
 	}
-	sort.Ints(keys)
 
-	for _, k := range keys {
-		_, _ = writer.WriteString(fmt.Sprintf("Responses with Status Code: %d\n\n", k))
-		for _, result := range urlStatuses[k] {
-			_, _ = writer.WriteString(fmt.Sprintf("| %s | %s => Length: %d\n", result.Method, result.URL, result.Length))
+	if comparison != nil {
+		// the verdicts are the primary report when a second role was probed
+		writeVerdicts(writer, comparison.verdicts())
+	} else if groupBy == groupByStatus {
+		// sort the map keys to ensure consistent output
+		var keys []int
+		for k := range urlStatuses {
+			keys = append(keys, k)
 		}
-		_, _ = writer.WriteString("\n")
+		sort.Ints(keys)
+
+		for _, k := range keys {
+			// redirects to the login page are in effect unauthorized responses, so they get their own bucket
+			login, other := splitLoginRedirects(urlStatuses[k])
+
+			if len(other) > 0 {
+				_, _ = writer.WriteString(fmt.Sprintf("Responses with Status Code: %d\n\n", k))
+				for _, result := range other {
+					_, _ = writer.WriteString(formatResult(result, false))
+				}
+				_, _ = writer.WriteString("\n")
+			}
+
+			if len(login) > 0 {
+				_, _ = writer.WriteString(fmt.Sprintf("Responses with Status Code: %d (unauthorized, redirect to login)\n\n", k))
+				for _, result := range login {
+					_, _ = writer.WriteString(formatResult(result, false))
+				}
+				_, _ = writer.WriteString("\n")
+			}
+		}
+	} else {
+		writeGroupedResults(writer, urlStatuses)
+	}
+
+	writeSecrets(writer, urlStatuses)
+	writeIDORCandidates(writer, idorCandidates)
+	writeGraphQLResults(writer, graphqlResults)
+	writeSessionCookieFindings(writer, sessionCookieFindings.sorted())
+	writeHeaderAudits(writer, headerAudits)
+	writeCORSFindings(writer, corsFindings)
+	writeBypassFindings(writer, bypassFindings)
+	writeFingerprints(writer, fingerprints.summary())
+	writeClusters(writer, clusters.summary())
+	if repeats != nil {
+		writeRepeatSummaries(writer, repeats.summaries())
+	}
+	writeFailedRequests(writer, failedRequests)
+
+	// add the statistics of the scan as footer
+	if stats != nil {
+		_, _ = writer.WriteString("Statistics\n\n")
+		stats.writeLatency(writer)
 	}
 
 	writer.Flush()
 }
 
+// formats a single line of the output file. The status code is only included if the results aren't grouped by it
+func formatResult(result probe.Result, withStatus bool) string {
+	details := ""
+	if result.Truncated {
+		details = " (truncated)"
+	}
+	if idn, ok := idnURLs[result.URL]; ok {
+		details += fmt.Sprintf(", IDN: %s", idn)
+	}
+	if result.CorrelationID != "" {
+		details += fmt.Sprintf(", ID: %s", result.CorrelationID)
+	}
+	if result.Severity != "" {
+		details += fmt.Sprintf(", Severity: %s", result.Severity)
+	}
+	extracted := extractHeaders(result.Header)
+	var names []string
+	for name := range extracted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		details += fmt.Sprintf(", %s: %s", name, strings.Join(extracted[name], "; "))
+	}
+	if note := annotationFor(result.Method, result.URL); note != nil {
+		details += fmt.Sprintf(", Note: %s", note)
+	}
+
+	labels := ""
+	if len(result.Labels) > 0 {
+		labels = fmt.Sprintf(" [%s]", strings.Join(result.Labels, ", "))
+	}
+	if result.Snippet != "" {
+		labels += fmt.Sprintf(" %q", result.Snippet)
+	}
+
+	if withStatus {
+		return fmt.Sprintf("| %s | %d | %s => Length: %s%s%s\n", result.Method, result.StatusCode, result.URL, formatLength(result.Length), details, labels)
+	}
+
+	return fmt.Sprintf("| %s | %s => Length: %s%s%s\n", result.Method, result.URL, formatLength(result.Length), details, labels)
+}
+
+// formats the length of a result. In `--no-body` mode, the length is -1 if the server didn't send a Content-Length
+func formatLength(length int) string {
+	if length < 0 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(length)
+}
+
 func parseLengths(lengths string) map[int]bool {
 	lengthsMap := make(map[int]bool)
 
@@ -322,11 +1607,41 @@ func parseLengths(lengths string) map[int]bool {
 	return lengthsMap
 }
 
+// parses a size like "1024", "512KB" or "1MB" into bytes (using 1024 as base)
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+
+	return n * multiplier, nil
+}
+
 func parseHeaders(headers string) map[string][]string {
 	headerMap := make(map[string][]string)
-	pairs := strings.Split(headers, ";")
-
-	for _, pair := range pairs {
+	for _, pair := range splitHeaders(headers) {
 		parts := strings.SplitN(pair, ":", 2)
 
 		if len(parts) != 2 {
@@ -344,80 +1659,65 @@ func parseHeaders(headers string) map[string][]string {
 	return headerMap
 }
 
-// function to do the HTTP request and check the response's status code and response length
-func checkURL(method string, url string, headers map[string][]string, proxy string, compiledRegex *regexp.Regexp, allowedLengths map[int]bool) (int, int, bool) {
-	client := createHTTPClient(proxy)
-	req, err := prepareHTTPRequest(method, url, headers)
+// splits a `--headers` value such as "Key1:Value1;Key2:Value2" into the single headers
+func splitHeaders(headers string) []string {
+	pairs := strings.Split(headers, ";")
 
-	if err != nil {
-		Error("Failed to create request: %s", err)
-		return 0, 0, false
+	// a single header whose value contains semicolons (e.g. "Cookie: a=1; b=2") isn't a list of headers
+	for _, pair := range pairs[1:] {
+		if !strings.Contains(pair, ":") {
+			return []string{headers}
+		}
 	}
 
-	resp, err := client.Do(req)
-	if handleHTTPError(err, url) {
-		return 0, 0, false
-	}
-	defer resp.Body.Close()
+	return pairs
+}
 
-	bodyBytes, err := readResponseBody(resp.Body, url)
+// reads a file with one header in the format "Key: Value" per line. Empty lines and lines starting with "#" are
+// ignored. Unlike with `--headers`, the values aren't split at semicolons
+func readHeadersFile(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return resp.StatusCode, 0, false
+		return nil, err
 	}
+	defer file.Close()
 
-	// if a regex pattern is provided, check if the response matches
-	return filterResponseByLengthAndRegex(resp.StatusCode, bodyBytes, compiledRegex, allowedLengths)
+	return readHeaderLines(file)
 }
 
-// setting up the HTTP client with potential proxy and other configurations
-func createHTTPClient(proxy string) *http.Client {
-	proxyURLFunc := func(_ *http.Request) (*neturl.URL, error) {
-		return neturl.Parse(proxy)
-	}
+// reads headers in the format of the headers file, e.g. from stdin
+func readHeaderLines(r io.Reader) (map[string][]string, error) {
+	headerMap := make(map[string][]string)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
 
-	if proxy == "" {
-		proxyURLFunc = http.ProxyFromEnvironment
-	}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid header in line %d: %s", lineNumber, line)
+		}
 
-	// custom CheckRedirect function that always returns an error. This prevents the client from following any redirects
-	noRedirect := func(req *http.Request, via []*http.Request) error {
-		return http.ErrUseLastResponse
+		key = strings.TrimSpace(key)
+		headerMap[key] = append(headerMap[key], strings.TrimSpace(value))
 	}
 
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy: proxyURLFunc,
-			TLSClientConfig: &tls.Config{
-				// skip SSL verification if specified
-				InsecureSkipVerify: skipVerification,
-			},
-		},
-		Timeout:       10 * time.Second, // set timeout for HTTP requests
-		CheckRedirect: noRedirect,       // Set the custom redirect policy
-	}
+	return headerMap, scanner.Err()
 }
 
-// create a new HTTP request and set the provided headers
-func prepareHTTPRequest(method string, url string, headers map[string][]string) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return nil, err
+// adds the values of the headers in `other` to `headerMap`
+func mergeHeaders(headerMap map[string][]string, other map[string][]string) map[string][]string {
+	if headerMap == nil {
+		headerMap = make(map[string][]string)
 	}
 
-	// Add custom headers to the request. If multiple cookies are provided, concatenate them.
-	for key, values := range headers {
-		if key == "Cookie" {
-			// Join multiple cookie values into a single header
-			req.Header.Set(key, strings.Join(values, "; "))
-		} else {
-			// For other headers, just set the first value (modify this as needed)
-			for _, value := range values {
-				req.Header.Add(key, value)
-			}
-		}
+	for key, values := range other {
+		headerMap[key] = append(headerMap[key], values...)
 	}
 
-	return req, nil
+	return headerMap
 }
 
 func handleHTTPError(err error, url string) bool {
@@ -441,37 +1741,6 @@ func handleHTTPError(err error, url string) bool {
 	return false
 }
 
-func readResponseBody(body io.ReadCloser, url string) ([]byte, error) {
-	bodyBytes, err := io.ReadAll(body)
-	if err != nil {
-		Error("Error reading response body for URL: %s - %s", url, err)
-		return nil, err
-	}
-
-	return bodyBytes, nil
-}
-
-func filterResponseByLengthAndRegex(statusCode int, bodyBytes []byte, compiledRegex *regexp.Regexp, excludedLengths map[int]bool) (int, int, bool) {
-	length := len(bodyBytes)
-
-	// If the length is in the excludedLengths map, exclude the response.
-	if excludedLengths[length] {
-		return statusCode, length, false
-	}
-
-	// If there's no regex provided, don't filter out any responses.
-	if compiledRegex == nil {
-		return statusCode, length, true
-	}
-
-	// If a regex is provided, only return true if the response does NOT match the regex
-	if !compiledRegex.Match(bodyBytes) {
-		return statusCode, length, true
-	}
-
-	return statusCode, length, false
-}
-
 func checkProxyReachability(proxy string) {
 	if proxy != "" {
 		proxyURL, err := neturl.Parse(proxy)
@@ -480,7 +1749,7 @@ func checkProxyReachability(proxy string) {
 			os.Exit(1)
 		}
 
-		_, err = net.DialTimeout("tcp", proxyURL.Host, 5*time.Second)
+		_, err = net.DialTimeout("tcp", proxyAddress(proxyURL), 5*time.Second)
 		if err != nil {
 			Error("Failed to connect to the proxy: %s", err)
 			Error("In case you're using docker to run the app, remember that you can't refer to the proxy as 'localhost' but need its IP :)")
@@ -489,6 +1758,35 @@ func checkProxyReachability(proxy string) {
 	}
 }
 
+// returns the "host:port" of a proxy, using the default port of its scheme if the URL doesn't contain one
+func proxyAddress(proxyURL *neturl.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxyURL.Scheme]
+	if port == "" {
+		port = "80"
+	}
+
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// reads the PEM certificates of a file into a pool
+func readCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
 func Info(format string, a ...interface{}) {
 	log.Printf("%s", green(fmt.Sprintf(format, a...)))
 }
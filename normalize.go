@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// normalizes a URL so that different spellings of the same resource (e.g. from different tool exports) are
+// deduplicated. This lowercases the scheme and host, strips default ports and fragments, resolves `.` and `..`
+// segments, sorts the query parameters and decodes percent-encoded unreserved characters. URLs that can't be parsed
+// are returned as they are
+func normalizeURL(url string) string {
+	parsed, err := neturl.Parse(strings.TrimSpace(url))
+	if err != nil || parsed.Host == "" {
+		return url
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if port := parsed.Port(); port != "" && defaultPorts[parsed.Scheme] == port {
+		parsed.Host = strings.TrimSuffix(parsed.Host, ":"+port)
+	}
+
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	escapedPath := normalizePercentEncoding(resolveDotSegments(parsed.EscapedPath()))
+	if escapedPath == "" {
+		escapedPath = "/"
+	}
+	if unescaped, err := neturl.PathUnescape(escapedPath); err == nil {
+		parsed.Path = unescaped
+		parsed.RawPath = escapedPath
+	}
+
+	parsed.RawQuery = normalizeQuery(parsed.RawQuery)
+
+	return parsed.String()
+}
+
+// resolves `.` and `..` segments as described in RFC 3986, section 5.2.4, keeping a potential trailing slash
+func resolveDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+
+	var out []string
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		switch segment {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, segment)
+		}
+	}
+
+	return strings.Join(out, "/")
+}
+
+// sorts the query parameters by name (keeping the order of repeated parameters) and normalizes their encoding
+func normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		params[i] = normalizePercentEncoding(param)
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		nameI, _, _ := strings.Cut(params[i], "=")
+		nameJ, _, _ := strings.Cut(params[j], "=")
+		return nameI < nameJ
+	})
+
+	return strings.Join(params, "&")
+}
+
+// decodes percent-encoded unreserved characters (e.g. `%7E` => `~`) and uppercases the hex digits of all others
+func normalizePercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteString(strings.ToUpper(s[i : i+3]))
+			}
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// regexes (`--normalize-regex`) for dynamic fragments of the bodies, e.g. CSRF tokens or timestamps, that are removed
+// before bodies are compared
+var bodyNormalizers []*regexp.Regexp
+
+// compiles the regexes of `--normalize-regex`
+func compileNormalizers(patterns []string) ([]*regexp.Regexp, error) {
+	var normalizers []*regexp.Regexp
+	for _, pattern := range patterns {
+		normalizer, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid normalize regex %q: %w", pattern, err)
+		}
+		normalizers = append(normalizers, normalizer)
+	}
+
+	return normalizers, nil
+}
+
+// removes all fragments matching the bodyNormalizers from the body, so that otherwise identical responses compare as
+// the same. A nil body stays nil
+func normalizeBody(body []byte) []byte {
+	if body == nil {
+		return nil
+	}
+
+	for _, normalizer := range bodyNormalizers {
+		// ReplaceAll returns nil if nothing is left of the body
+		if body = normalizer.ReplaceAll(body, nil); body == nil {
+			body = []byte{}
+		}
+	}
+
+	return body
+}
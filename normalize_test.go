@@ -0,0 +1,72 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Example.COM:443/a/b":            "https://example.com/a/b",
+		"http://example.com:80":                  "http://example.com/",
+		"http://example.com:8080/a":              "http://example.com:8080/a",
+		"https://example.com/a/./b/../c/":        "https://example.com/a/c/",
+		"https://example.com/a/..":               "https://example.com/",
+		"https://example.com/a?b=2&a=1#section":  "https://example.com/a?a=1&b=2",
+		"https://example.com/%7Euser/%2fpath":    "https://example.com/~user/%2Fpath",
+		"https://example.com/search?q=%7e&x=%2f": "https://example.com/search?q=~&x=%2F",
+		"https://example.com/item?id=2&id=1&a=0": "https://example.com/item?a=0&id=2&id=1",
+		"not a url":                              "not a url",
+	}
+
+	for input, expected := range tests {
+		if actual := normalizeURL(input); actual != expected {
+			t.Errorf("Expected %s to be normalized to %s but got %s", input, expected, actual)
+		}
+	}
+}
+
+func TestNormalizeBody(t *testing.T) {
+	normalizers, err := compileNormalizers([]string{`csrf_token=[a-f0-9]+`, `"ts":\d+`})
+	if err != nil {
+		t.Fatalf("Failed to compile normalizers: %v", err)
+	}
+	bodyNormalizers = normalizers
+	defer func() {
+		bodyNormalizers = nil
+	}()
+
+	a := normalizeBody([]byte(`<form action="/save?csrf_token=3f9a">{"ts":1700000000}`))
+	b := normalizeBody([]byte(`<form action="/save?csrf_token=be01c7d2">{"ts":1700000042}`))
+	if string(a) != string(b) || string(a) != `<form action="/save?">{}` {
+		t.Errorf("Expected both bodies to be normalized to the same content but got %q and %q", a, b)
+	}
+
+	if normalizeBody(nil) != nil || normalizeBody([]byte("csrf_token=ab")) == nil {
+		t.Errorf("Expected only a nil body to stay nil")
+	}
+
+	if _, err := compileNormalizers([]string{"("}); err == nil {
+		t.Errorf("Expected an error for an invalid regex")
+	}
+}
+
+func TestComparisonSet_Normalized(t *testing.T) {
+	bodyNormalizers = []*regexp.Regexp{regexp.MustCompile(`csrf_token=[a-f0-9]+`)}
+	defer func() {
+		bodyNormalizers = nil
+	}()
+
+	c := newComparisonSet()
+	primary := probe.Result{Method: "GET", URL: "https://example.com/", StatusCode: 200, Length: 30}
+	_ = c.hook(false).AfterResponse(&primary, []byte("Settings csrf_token=0123456789"))
+	other := probe.Result{Method: "GET", URL: "https://example.com/", StatusCode: 200, Length: 23}
+	_ = c.hook(true).AfterResponse(&other, []byte("Settings csrf_token=abc"))
+
+	verdicts := c.verdicts()
+	if len(verdicts) != 1 || verdicts[0].Verdict != verdictFullyExposed || verdicts[0].Length != 9 || verdicts[0].Diff != "" {
+		t.Errorf("Expected a FULLY-EXPOSED verdict without a diff but got %v", verdicts)
+	}
+}
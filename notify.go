@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the webhook notifier configured via `--notify-webhook`. It's nil if no webhook was provided
+var notifier *webhookNotifier
+
+// the number of result notifications that can be pending before notifyResult blocks
+const notificationQueueSize = 1024
+
+// POSTs a JSON payload to a webhook for every result that passes the notification filters, and a final summary. The
+// results are posted in the background, so that a slow webhook doesn't slow down the scan
+type webhookNotifier struct {
+	url      string
+	statuses map[int]bool
+	urlRegex *regexp.Regexp
+	client   *http.Client
+	queue    chan resultNotification
+	// closed once the queue is drained
+	drained chan struct{}
+	// closes the queue (only once, see drain)
+	closeQueue sync.Once
+}
+
+// the JSON payload of a single result
+type resultNotification struct {
+	Type       string   `json:"type"`
+	Method     string   `json:"method"`
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code"`
+	Length     int      `json:"length"`
+	Labels     []string `json:"labels,omitempty"`
+}
+
+// the JSON payload of the final summary
+type summaryNotification struct {
+	Type     string     `json:"type"`
+	Duration string     `json:"duration"`
+	Stats    *scanStats `json:"stats"`
+}
+
+// creates a notifier for the webhook. If `statuses` is empty, results of all status codes are sent, and if `urlRegex`
+// is nil, results of all URLs are sent
+func newWebhookNotifier(url string, statuses map[int]bool, urlRegex *regexp.Regexp) *webhookNotifier {
+	n := &webhookNotifier{
+		url:      url,
+		statuses: statuses,
+		urlRegex: urlRegex,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan resultNotification, notificationQueueSize),
+		drained:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(n.drained)
+		for notification := range n.queue {
+			n.post(notification)
+		}
+	}()
+
+	return n
+}
+
+// checks if the result passes the notification filters
+func (n *webhookNotifier) wants(result probe.Result) bool {
+	if len(n.statuses) > 0 && !n.statuses[result.StatusCode] {
+		return false
+	}
+
+	if n.urlRegex != nil && !n.urlRegex.MatchString(result.URL) {
+		return false
+	}
+
+	return true
+}
+
+func (n *webhookNotifier) notifyResult(result probe.Result) {
+	if !n.wants(result) {
+		return
+	}
+
+	n.queue <- resultNotification{
+		Type:       "result",
+		Method:     result.Method,
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		Length:     result.Length,
+		Labels:     result.Labels,
+	}
+}
+
+// waits until all pending result notifications were sent. No results can be notified afterwards. It can be called
+// several times, e.g. deferred in case the scan ends before the summary is sent
+func (n *webhookNotifier) drain() {
+	n.closeQueue.Do(func() {
+		close(n.queue)
+	})
+	<-n.drained
+}
+
+// posts the summary once all pending result notifications were sent. No results can be notified afterwards
+func (n *webhookNotifier) notifySummary(stats *scanStats) {
+	n.drain()
+
+	n.post(summaryNotification{
+		Type:     "summary",
+		Duration: stats.duration().Round(time.Second).String(),
+		Stats:    stats,
+	})
+}
+
+// sends the payload to the webhook. Failures are only logged, since they shouldn't stop the scan. The URL of the
+// webhook is left out of the log, as it's a secret
+func (n *webhookNotifier) post(payload interface{}) {
+	if err := postJSON(n.client, n.url, payload); err != nil {
+		Warn("Failed to send webhook notification to %s: %s", webhookHost(n.url), err)
+	}
+}
+
+// returns the host of the webhook URL, which can be logged (unlike the path or query, which usually contain a token)
+func webhookHost(url string) string {
+	parsed, err := neturl.Parse(url)
+	if err != nil || parsed.Host == "" {
+		return "the webhook"
+	}
+
+	return parsed.Host
+}
+
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// the errors of the client contain the URL, which is left out, as the webhook URLs are secrets
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	} else if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// the chat webhooks configured via `--notify-slack`, `--notify-discord` and `--notify-teams`, which receive a summary
+// once the scan is complete
+var chatWebhooks []chatWebhook
+
+type chatWebhook struct {
+	// "slack", "discord" or "teams"
+	kind string
+	url  string
+}
+
+// the maximum number of findings listed in the chat summary
+const maxChatFindings = 5
+
+// builds the human-readable summary that is posted to the chat webhooks
+func buildChatSummary(stats *scanStats, urlStatuses map[int][]probe.Result) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SessionProbe scan completed in %s: %d URLs, %d requests, %d errors\n",
+		stats.duration().Round(time.Second), stats.URLs, stats.Requests, stats.Errors)
+
+	if len(stats.StatusCounts) > 0 {
+		fmt.Fprintf(&b, "Status codes: %s\n", stats.formatStatusCounts())
+		fmt.Fprintf(&b, "Status classes: %s\n", formatClassCounts(stats.StatusClasses))
+	}
+
+	// the top findings are the (reported) results with a 2xx status code, i.e. the ones that were accessible
+	var findings []probe.Result
+	for code, results := range urlStatuses {
+		if code >= 200 && code <= 299 {
+			findings = append(findings, results...)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].URL < findings[j].URL
+	})
+
+	if len(findings) > 0 {
+		fmt.Fprintf(&b, "Top findings (%d accessible):\n", len(findings))
+		for i, finding := range findings {
+			if i == maxChatFindings {
+				fmt.Fprintf(&b, "... and %d more\n", len(findings)-maxChatFindings)
+				break
+			}
+			fmt.Fprintf(&b, "- %s %s => %d (Length: %s)\n", finding.Method, finding.URL, finding.StatusCode, formatLength(finding.Length))
+		}
+	}
+
+	return b.String()
+}
+
+// Discord rejects messages longer than 2000 characters
+const maxDiscordLength = 2000
+
+// cuts the text to at most max characters (not bytes, so that no character is split), ending with "..." if it was cut
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	return string(runes[:max-3]) + "..."
+}
+
+// posts the summary to all chat webhooks in their respective format
+func notifyChats(summary string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, webhook := range chatWebhooks {
+		var payload interface{}
+		switch webhook.kind {
+		case "discord":
+			payload = map[string]string{"content": truncateRunes(summary, maxDiscordLength)}
+		default:
+			// Slack and Teams (incoming webhooks) both accept a simple `text` payload
+			payload = map[string]string{"text": summary}
+		}
+
+		if err := postJSON(client, webhook.url, payload); err != nil {
+			Warn("Failed to send %s notification: %s", webhook.kind, err)
+		}
+	}
+}
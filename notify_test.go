@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, map[int]bool{200: true}, regexp.MustCompile("/admin"))
+	n.notifyResult(probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 200})
+	n.notifyResult(probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 403})
+	n.notifyResult(probe.Result{Method: "GET", URL: "https://example.com/home", StatusCode: 200})
+
+	stats := newScanStats(3)
+	stats.finish()
+	n.notifySummary(stats)
+
+	if len(payloads) != 2 {
+		t.Fatalf("Expected 2 notifications but got %d: %v", len(payloads), payloads)
+	}
+	if payloads[0]["type"] != "result" || payloads[0]["url"] != "https://example.com/admin" {
+		t.Errorf("Unexpected result notification: %v", payloads[0])
+	}
+	if payloads[1]["type"] != "summary" {
+		t.Errorf("Unexpected summary notification: %v", payloads[1])
+	}
+}
+
+func TestWebhookNotifier_SlowWebhook(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, nil, nil)
+
+	// the results are queued instead of being posted while the scan waits
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		n.notifyResult(probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 200})
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected notifyResult not to wait for the webhook but it took %s", elapsed)
+	}
+
+	// the summary is only sent once the queue is drained
+	stats := newScanStats(5)
+	stats.finish()
+	n.notifySummary(stats)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 6 {
+		t.Errorf("Expected 5 result notifications and the summary but got %d", received)
+	}
+}
+
+func TestBuildChatSummary(t *testing.T) {
+	stats := newScanStats(2)
+	stats.add(probe.Result{StatusCode: 200})
+	stats.add(probe.Result{StatusCode: 403})
+	stats.finish()
+
+	urlStatuses := map[int][]probe.Result{
+		200: {{Method: "GET", URL: "https://example.com/admin", StatusCode: 200, Length: 42}},
+		403: {{Method: "GET", URL: "https://example.com/secret", StatusCode: 403}},
+	}
+
+	summary := buildChatSummary(stats, urlStatuses)
+	for _, expected := range []string{"2 URLs, 2 requests, 0 errors", "200: 1, 403: 1", "GET https://example.com/admin => 200 (Length: 42)"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Expected the summary to contain %q but got: %s", expected, summary)
+		}
+	}
+	if strings.Contains(summary, "/secret") {
+		t.Errorf("Expected only accessible URLs in the findings but got: %s", summary)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"äöüäöüäöüäöü", 8, "äöüäö..."},
+	}
+
+	for _, test := range tests {
+		if actual := truncateRunes(test.text, test.max); actual != test.expected {
+			t.Errorf("Expected %q for %q but got %q", test.expected, test.text, actual)
+		}
+	}
+}
+
+func TestWebhookNotifier_Drain(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// a scan that ends early only drains the queue, possibly several times
+	n := newWebhookNotifier(server.URL+"/hooks/secret-token", nil, nil)
+	n.notifyResult(probe.Result{Method: "GET", URL: "https://example.com/admin", StatusCode: 200})
+	n.drain()
+	n.drain()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 1 {
+		t.Errorf("Expected the queued notification to be sent but got %d", received)
+	}
+}
+
+func TestWebhookHost(t *testing.T) {
+	tests := map[string]string{
+		"https://hooks.example.com/services/T000/B000/secret": "hooks.example.com",
+		"http://127.0.0.1:8080/webhook?token=secret":          "127.0.0.1:8080",
+		"not a URL":                                           "the webhook",
+	}
+
+	for url, expected := range tests {
+		if actual := webhookHost(url); actual != expected {
+			t.Errorf("Expected %s for %s but got %s", expected, url, actual)
+		}
+	}
+
+	// the errors of a failed post don't contain the URL either
+	err := postJSON(&http.Client{}, "http://127.0.0.1:1/hooks/secret-token", map[string]string{})
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("Expected an error without the URL but got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/rand"
+	neturl "net/url"
+	"sort"
+)
+
+const (
+	// the order of the URLs file
+	orderAsGiven = "as-given"
+	// all URLs of a host after each other (in the order of the file), which maximizes the reuse of connections
+	orderByHost = "by-host"
+	// shuffled, which spreads the load across the hosts and avoids sequential access patterns
+	orderRandom = "random"
+)
+
+var orderModes = []string{orderAsGiven, orderByHost, orderRandom}
+
+func isValidOrder(order string) bool {
+	return containsString(orderModes, order)
+}
+
+// returns the URLs in the order in which they are checked
+func orderURLs(urls []string, order string) []string {
+	ordered := append([]string{}, urls...)
+
+	switch order {
+	case orderByHost:
+		// a stable sort by the first occurrence of the host keeps the order of the file within a host
+		firstSeen := make(map[string]int)
+		for i, url := range ordered {
+			if _, ok := firstSeen[urlHost(url)]; !ok {
+				firstSeen[urlHost(url)] = i
+			}
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return firstSeen[urlHost(ordered[i])] < firstSeen[urlHost(ordered[j])]
+		})
+	case orderRandom:
+		rand.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	}
+
+	return ordered
+}
+
+// returns the host (including the port) of a URL, or the URL itself if it can't be parsed
+func urlHost(url string) string {
+	parsed, err := neturl.Parse(url)
+	if err != nil || parsed.Host == "" {
+		return url
+	}
+
+	return parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOrderURLs(t *testing.T) {
+	urls := []string{
+		"https://a.example.com/1",
+		"https://b.example.com/1",
+		"https://a.example.com/2",
+		"https://c.example.com/1",
+		"https://b.example.com/2",
+	}
+
+	if ordered := orderURLs(urls, orderAsGiven); strings.Join(ordered, ",") != strings.Join(urls, ",") {
+		t.Errorf("Expected the order of the file but got %v", ordered)
+	}
+
+	expected := []string{
+		"https://a.example.com/1",
+		"https://a.example.com/2",
+		"https://b.example.com/1",
+		"https://b.example.com/2",
+		"https://c.example.com/1",
+	}
+	if ordered := orderURLs(urls, orderByHost); strings.Join(ordered, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the URLs grouped by host (%v) but got %v", expected, ordered)
+	}
+
+	// a shuffle contains the same URLs and doesn't modify the input
+	shuffled := orderURLs(urls, orderRandom)
+	sort.Strings(shuffled)
+	if strings.Join(shuffled, ",") != strings.Join(expected, ",") || urls[1] != "https://b.example.com/1" {
+		t.Errorf("Expected a permutation of the URLs but got %v", shuffled)
+	}
+}
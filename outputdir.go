@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// the hint for "permission denied" errors on output files, which are mostly caused by Docker volumes owned by another
+// user than the one the container runs as
+const dockerVolumeHint = "When running via Docker, make sure the mounted volume is writable by the user of the container, e.g. via \"docker run --user $(id -u):$(id -g) ...\""
+
+// creates the `--output-dir` (if it's missing) with the given permissions and makes sure that files can be created in
+// it, so that e.g. a read-only Docker volume is noticed before the scan rather than after it
+func prepareOutputDir(dir string, mode os.FileMode) error {
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(dir, mode); err != nil {
+			return fmt.Errorf("failed to create the output directory %s: %w. %s", dir, err, dockerVolumeHint)
+		}
+		// MkdirAll applies the umask, so the permissions are set explicitly
+		if err := os.Chmod(dir, mode); err != nil {
+			return fmt.Errorf("failed to set the permissions of the output directory %s: %w", dir, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to access the output directory %s: %w", dir, err)
+	case !info.IsDir():
+		return fmt.Errorf("the output directory %s is not a directory", dir)
+	}
+
+	return checkDirWritable(dir)
+}
+
+// creates (and removes) a temporary file in the directory to find out if it's writable
+func checkDirWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".sessionprobe-write-test-*")
+	if err != nil {
+		return fmt.Errorf("the directory %s isn't writable: %w. %s", dir, err, dockerVolumeHint)
+	}
+	file.Close()
+
+	return os.Remove(file.Name())
+}
+
+// makes sure that the output files can be written before any request is sent, rather than finding out after the scan.
+// Files that don't exist yet are created and removed again, existing ones aren't modified
+func checkOutputsWritable(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		_, statErr := os.Stat(path)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+		switch {
+		case errors.Is(err, fs.ErrPermission):
+			return fmt.Errorf("the output file %s can't be written: %w. %s", path, err, dockerVolumeHint)
+		case errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("the output file %s can't be written because its directory doesn't exist", path)
+		case err != nil:
+			return fmt.Errorf("the output file %s can't be written: %w", path, err)
+		}
+		file.Close()
+
+		if errors.Is(statErr, fs.ErrNotExist) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolves a relative output path inside the `--output-dir`. Absolute paths and empty ones (i.e. outputs that aren't
+// enabled) are kept
+func outputPath(dir string, path string) string {
+	if dir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
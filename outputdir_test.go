@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepareOutputDir(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	dir := filepath.Join(".", "testing", "test-output-dir", "nested")
+	os.RemoveAll(filepath.Dir(dir))
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	if err := prepareOutputDir(dir, 0700); err != nil {
+		t.Fatalf("Failed to prepare the output directory: %v", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected a directory with the permissions 0700 but got %v (err: %v)", info, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected the write test to leave no files behind but got %d", len(entries))
+	}
+
+	// an existing directory is kept as it is
+	if err := prepareOutputDir(dir, 0755); err != nil {
+		t.Errorf("Expected an existing directory to be accepted but got %v", err)
+	}
+
+	file := filepath.Join(dir, "file.txt")
+	os.WriteFile(file, []byte("test"), 0644)
+	if err := prepareOutputDir(file, 0755); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected an error for a file but got %v", err)
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct {
+		dir, path string
+		expected  string
+	}{
+		{"", "output.txt", "output.txt"},
+		{"results", "output.txt", filepath.Join("results", "output.txt")},
+		{"results", "./json/scan.json", filepath.Join("results", "json", "scan.json")},
+		{"results", "/tmp/output.txt", "/tmp/output.txt"},
+		{"results", "", ""},
+	}
+
+	for _, test := range tests {
+		if actual := outputPath(test.dir, test.path); actual != test.expected {
+			t.Errorf("Expected %s for %s in %q but got %s", test.expected, test.path, test.dir, actual)
+		}
+	}
+}
+
+func TestCheckOutputsWritable(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	existing := filepath.Join(".", "testing", "test-existing-output.txt")
+	if err := os.WriteFile(existing, []byte("previous scan"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	missing := filepath.Join(".", "testing", "test-missing-output.txt")
+	os.Remove(missing)
+
+	if err := checkOutputsWritable(existing, "", missing); err != nil {
+		t.Fatalf("Expected the outputs to be writable but got %v", err)
+	}
+
+	if data, _ := os.ReadFile(existing); string(data) != "previous scan" {
+		t.Errorf("Expected the existing file to be kept but got %q", string(data))
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Expected the check to remove the file it created but got %v", err)
+	}
+
+	err := checkOutputsWritable(filepath.Join(".", "testing", "does-not-exist", "output.txt"))
+	if err == nil || !strings.Contains(err.Error(), "directory doesn't exist") {
+		t.Errorf("Expected an error for a missing directory but got %v", err)
+	}
+}
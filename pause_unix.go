@@ -0,0 +1,41 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"sessionprobe/pkg/probe"
+)
+
+// pauses the scan on SIGUSR1 and resumes it on SIGUSR2, e.g. when the owners of the target ask to halt the traffic
+// for a while. Returns a function that stops listening for the signals
+func handlePauseSignals(pause *probe.Pause) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 && pause.Pause() {
+					Warn("Paused the scan (requests in flight are still finished), send SIGUSR2 to resume")
+				} else if sig == syscall.SIGUSR2 && pause.Resume() {
+					Info("Resumed the scan")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	Info("Pause the scan with `kill -USR1 %d` and resume it with `kill -USR2 %d`", os.Getpid(), os.Getpid())
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package main
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestHandlePauseSignals(t *testing.T) {
+	pause := probe.NewPause()
+	stop := handlePauseSignals(pause)
+	defer stop()
+
+	// every signal changes the state, so that it's handled before the next one is sent (the order in which different
+	// pending signals are delivered isn't guaranteed)
+	tests := []struct {
+		signal syscall.Signal
+		paused bool
+	}{
+		{syscall.SIGUSR1, true},
+		{syscall.SIGUSR2, false},
+		{syscall.SIGUSR1, true},
+		{syscall.SIGUSR2, false},
+	}
+
+	for _, test := range tests {
+		if err := syscall.Kill(syscall.Getpid(), test.signal); err != nil {
+			t.Fatalf("Failed to send %s: %v", test.signal, err)
+		}
+
+		// the signal is handled asynchronously
+		deadline := time.Now().Add(2 * time.Second)
+		for pause.Paused() != test.paused && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if pause.Paused() != test.paused {
+			t.Errorf("Expected paused to be %v after %s but got %v", test.paused, test.signal, pause.Paused())
+		}
+	}
+}
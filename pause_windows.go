@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "sessionprobe/pkg/probe"
+
+// Windows has no SIGUSR1/SIGUSR2, so the scan can't be paused there
+func handlePauseSignals(pause *probe.Pause) func() {
+	return func() {}
+}
@@ -0,0 +1,304 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// setting up the HTTP client with potential proxy and other configurations
+func newHTTPClient(opts Options) (*http.Client, error) {
+	proxyURLFunc := http.ProxyFromEnvironment
+	dialContext := newDialContext(opts.ResolveOverrides, opts.PortMap, opts.LocalAddr, "")
+
+	if opts.Proxy != "" {
+		proxyURL, err := neturl.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+
+		// the port map and the resolve overrides are meant for the targets, not for the proxy
+		proxyAddr := proxyDialAddr(proxyURL)
+		dialContext = newDialContext(opts.ResolveOverrides, opts.PortMap, opts.LocalAddr, proxyAddr)
+
+		// the TLS connection to an HTTPS proxy is established by the dialer, so that it's verified against the host of
+		// the proxy and ProxyRootCAs rather than the TLS config of the targets (e.g. the SNI). The transport then talks
+		// plain HTTP over it
+		if proxyURL.Scheme == "https" {
+			dialContext = newTLSProxyDialContext(dialContext, proxyAddr, &tls.Config{
+				ServerName:         proxyURL.Hostname(),
+				RootCAs:            opts.ProxyRootCAs,
+				InsecureSkipVerify: opts.SkipVerification,
+			})
+			proxyURL = &neturl.URL{Scheme: "http", User: proxyURL.User, Host: proxyAddr}
+		}
+
+		proxyURLFunc = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		// skip SSL verification if specified
+		InsecureSkipVerify: opts.SkipVerification,
+		// use a custom SNI if specified (an empty value means the host of the URL is used)
+		ServerName:   opts.SNI,
+		MinVersion:   opts.TLSMinVersion,
+		MaxVersion:   opts.TLSMaxVersion,
+		CipherSuites: opts.CipherSuites,
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:             proxyURLFunc,
+		DialContext:       dialContext,
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: opts.DisableKeepAlives,
+	}
+	// http.Transport always sends HTTP/1.1, so HTTP/1.0 requests are written by the rawTransport as well
+	if opts.RawHeaders || opts.HTTP10 {
+		transport = &rawTransport{proxy: proxyURLFunc, dial: dialContext, tlsConfig: tlsConfig, headerOrder: opts.HeaderOrder, http10: opts.HTTP10}
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		Timeout:       opts.Timeout,                      // set timeout for HTTP requests
+		CheckRedirect: redirectPolicy(opts.MaxRedirects), // only follow redirects if MaxRedirects is set
+	}, nil
+}
+
+// Request is a single request that is sent via Scanner.Do, e.g. a POST with a JSON body
+type Request struct {
+	Method string
+	URL    string
+	// the request body (none if nil)
+	Body []byte
+	// headers that are set in addition to (and take precedence over) the configured Headers
+	Header http.Header
+}
+
+// function to do the HTTP request and check the response's status code and response length. A nil session means the
+// shared one
+func (s *Scanner) checkURL(ctx context.Context, sess *session, method string, url string) Result {
+	return s.do(ctx, sess, Request{Method: method, URL: url})
+}
+
+// Do sends a single request and checks its response the same way Run does. It can be used for requests that Run
+// doesn't cover, e.g. ones with a body. The Delay and Jitter aren't applied
+func (s *Scanner) Do(ctx context.Context, request Request) Result {
+	return s.do(ctx, nil, request)
+}
+
+func (s *Scanner) do(ctx context.Context, sess *session, request Request) Result {
+	if sess == nil {
+		sess = &session{client: s.client}
+	}
+	result := Result{Method: request.Method, URL: request.URL}
+
+	req, err := s.prepareHTTPRequest(ctx, request)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create request: %w", err)
+		return result
+	}
+
+	// set the correlation ID before the hooks, so that e.g. a signature covers it
+	if s.opts.CorrelationHeader != "" {
+		if result.CorrelationID, err = NewUUID(); err != nil {
+			result.Err = fmt.Errorf("failed to generate correlation ID: %w", err)
+			return result
+		}
+		req.Header.Set(s.opts.CorrelationHeader, result.CorrelationID)
+	}
+
+	sampled := s.sampleRange(req)
+
+	// the cookies of a sticky session are added before the hooks, so that e.g. a signature covers them. They replace
+	// the configured ones with the same name
+	sess.addCookies(req)
+
+	for _, hook := range s.opts.RequestHooks {
+		if err := hook.BeforeRequest(req); err != nil {
+			result.Err = fmt.Errorf("request hook failed: %w", err)
+			return result
+		}
+	}
+
+	resp, err := sess.do(req)
+	if err == nil && sampled && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// e.g. an empty body can't be sampled, so the request is sent again without the Range header
+		resp.Body.Close()
+		req.Header.Del("Range")
+		sampled = false
+		resp, err = sess.do(req)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	fullLength := int64(-1)
+	if sampled {
+		fullLength = sampledResponse(resp)
+	}
+
+	result.StatusCode = resp.StatusCode
+	result.Header = resp.Header
+
+	// with NoBody, the body is discarded unread (by closing it) and only the Content-Length is checked
+	if s.opts.NoBody {
+		result.Length = int(resp.ContentLength)
+		result.Matched = !s.opts.ExcludedLengths[result.Length]
+		result.Labels = s.matchLabels(resp, result.Length, nil)
+		return s.runResponseHooks(result, nil)
+	}
+
+	maxBodyBytes := s.opts.MaxBodyBytes
+	if sampled && (maxBodyBytes <= 0 || s.opts.BodySample < maxBodyBytes) {
+		maxBodyBytes = s.opts.BodySample
+	}
+
+	bodyBytes, truncated, err := readResponseBody(resp.Body, maxBodyBytes)
+	if err != nil {
+		result.Err = fmt.Errorf("error reading response body: %w", err)
+		return result
+	}
+	result.Truncated = truncated
+
+	// if a regex pattern is provided, check if the response matches
+	_, result.Length, result.Matched = filterResponseByLengthAndRegex(resp.StatusCode, bodyBytes, s.opts.FilterRegex, s.opts.ExcludedLengths)
+
+	// a sample is reported with the length of the whole body (if known), which the excluded lengths then apply to
+	if sampled {
+		read := int64(len(bodyBytes))
+		result.Truncated = read < fullLength || (fullLength < 0 && read >= s.opts.BodySample)
+		if fullLength >= 0 {
+			result.Length = int(fullLength)
+			result.Matched = !s.opts.ExcludedLengths[result.Length] && (s.opts.FilterRegex == nil || !s.opts.FilterRegex.Match(bodyBytes))
+		}
+	}
+	result.Labels = s.matchLabels(resp, result.Length, bodyBytes)
+	if s.opts.ScanSecrets {
+		result.Secrets = FindSecrets(bodyBytes)
+	}
+	if s.opts.SnippetLength > 0 {
+		result.Snippet = bodySnippet(bodyBytes, s.opts.SnippetLength)
+	}
+
+	return s.runResponseHooks(result, bodyBytes)
+}
+
+// returns the labels of all Matchers that match the response
+func (s *Scanner) matchLabels(resp *http.Response, length int, body []byte) []string {
+	var labels []string
+	for i := range s.opts.Matchers {
+		if s.opts.Matchers[i].Match(resp.StatusCode, resp.Header, length, body) {
+			labels = append(labels, s.opts.Matchers[i].Label)
+		}
+	}
+
+	return labels
+}
+
+// passes the result through all ResponseHooks, stopping at the first one that fails
+func (s *Scanner) runResponseHooks(result Result, body []byte) Result {
+	for _, hook := range s.opts.ResponseHooks {
+		if err := hook.AfterResponse(&result, body); err != nil {
+			result.Err = fmt.Errorf("response hook failed: %w", err)
+			return result
+		}
+	}
+
+	return result
+}
+
+// create a new HTTP request and set the configured headers
+func (s *Scanner) prepareHTTPRequest(ctx context.Context, request Request) (*http.Request, error) {
+	var body io.Reader
+	if request.Body != nil {
+		body = bytes.NewReader(request.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go ignores a "Host" entry in req.Header, so the Host header has to be overridden via req.Host
+	if s.opts.HostHeader != "" {
+		req.Host = s.opts.HostHeader
+	}
+
+	// set the User-Agent first, so that a User-Agent provided via the headers takes precedence
+	if ua := s.userAgent(); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+
+	// Add custom headers to the request. If multiple cookies are provided, concatenate them.
+	for key, values := range s.opts.Headers {
+		if key == "Cookie" {
+			// Join multiple cookie values into a single header
+			req.Header.Set(key, strings.Join(values, "; "))
+		} else {
+			// For other headers, just set the first value (modify this as needed)
+			req.Header.Del(key)
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+	}
+
+	for key, values := range request.Header {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return req, nil
+}
+
+// reads the response body, but at most `maxBodyBytes` bytes (if set). The returned bool reports if the body was
+// truncated because it was larger than that
+func readResponseBody(body io.Reader, maxBodyBytes int64) ([]byte, bool, error) {
+	reader := body
+	if maxBodyBytes > 0 {
+		// read one more byte than allowed to find out if the body is larger
+		reader = io.LimitReader(body, maxBodyBytes+1)
+	}
+
+	bodyBytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if maxBodyBytes > 0 && int64(len(bodyBytes)) > maxBodyBytes {
+		return bodyBytes[:maxBodyBytes], true, nil
+	}
+
+	return bodyBytes, false, nil
+}
+
+func filterResponseByLengthAndRegex(statusCode int, bodyBytes []byte, compiledRegex *regexp.Regexp, excludedLengths map[int]bool) (int, int, bool) {
+	length := len(bodyBytes)
+
+	// If the length is in the excludedLengths map, exclude the response.
+	if excludedLengths[length] {
+		return statusCode, length, false
+	}
+
+	// If there's no regex provided, don't filter out any responses.
+	if compiledRegex == nil {
+		return statusCode, length, true
+	}
+
+	// If a regex is provided, only return true if the response does NOT match the regex
+	if !compiledRegex.Match(bodyBytes) {
+		return statusCode, length, true
+	}
+
+	return statusCode, length, false
+}
@@ -0,0 +1,43 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestNewUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := NewUUID()
+		if err != nil || !pattern.MatchString(id) {
+			t.Fatalf("Expected a version 4 UUID but got %q (err: %v)", id, err)
+		}
+		if seen[id] {
+			t.Fatalf("Expected unique UUIDs but got %s twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestCorrelationHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Scan-Id")))
+	}))
+	defer server.Close()
+
+	scanner := newTestScanner(t, Options{CorrelationHeader: "X-Scan-Id"})
+	first := scanner.Do(context.Background(), Request{Method: "GET", URL: server.URL})
+	second := scanner.Do(context.Background(), Request{Method: "GET", URL: server.URL})
+
+	if first.CorrelationID == "" || first.Length != len(first.CorrelationID) {
+		t.Errorf("Expected the correlation ID %q to be sent but got a length of %d", first.CorrelationID, first.Length)
+	}
+	if first.CorrelationID == second.CorrelationID {
+		t.Errorf("Expected a new correlation ID per request but got %s twice", first.CorrelationID)
+	}
+}
@@ -0,0 +1,221 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseResolveOverrides parses curl-style `host:port:ip` entries into a map of "host:port" to the "ip:port" that
+// should be dialed instead (see Options.ResolveOverrides). IPv6 addresses may be wrapped in brackets (e.g.
+// `host:443:[::1]`)
+func ParseResolveOverrides(entries []string) (map[string]string, error) {
+	overrides := make(map[string]string)
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid resolve entry (expected host:port:ip): %s", entry)
+		}
+
+		ip := strings.Trim(parts[2], "[]")
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid IP address in resolve entry: %s", entry)
+		}
+
+		overrides[net.JoinHostPort(strings.ToLower(parts[0]), parts[1])] = net.JoinHostPort(ip, parts[1])
+	}
+
+	return overrides, nil
+}
+
+// ParsePortMap parses `host:port=newport` entries into a map of "host:port" to the port that should be dialed instead
+// (see Options.PortMap). The host "*" applies to all hosts, e.g. `*:443=8443`
+func ParsePortMap(entries []string) (map[string]string, error) {
+	portMap := make(map[string]string)
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "=")
+		host, port, err := net.SplitHostPort(from)
+		if !ok || err != nil || host == "" || !isPort(port) || !isPort(to) {
+			return nil, fmt.Errorf("invalid port mapping (expected host:port=newport): %s", entry)
+		}
+
+		portMap[net.JoinHostPort(strings.ToLower(host), port)] = to
+	}
+
+	return portMap, nil
+}
+
+func isPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// returns the address with the port replaced according to the port map. Mappings of the specific host take precedence
+// over the ones of "*"
+func mapPort(portMap map[string]string, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	if to, ok := portMap[strings.ToLower(addr)]; ok {
+		return net.JoinHostPort(host, to)
+	}
+	if to, ok := portMap[net.JoinHostPort("*", port)]; ok {
+		return net.JoinHostPort(host, to)
+	}
+
+	return addr
+}
+
+// ReadResolveFile reads `host:port:ip` entries from a file, one per line. Empty lines and lines starting with `#`
+// are skipped
+func ReadResolveFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+
+	return entries, scanner.Err()
+}
+
+// creates the DialContext function of the HTTP transport, which applies the port map and then the resolve overrides
+// (so that an override can target the mapped port), and binds outgoing connections to the local address (if set).
+// The transport dials the proxy (at proxyAddr, if set) through it as well, which is connected to as given
+func newDialContext(resolveOverrides map[string]string, portMap map[string]string, localAddr *net.TCPAddr, proxyAddr string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	// a nil *net.TCPAddr must not be assigned to the net.Addr interface, since the dialer would then use it
+	if localAddr != nil {
+		dialer.LocalAddr = localAddr
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == proxyAddr {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		addr = mapPort(portMap, addr)
+		if override, ok := resolveOverrides[strings.ToLower(addr)]; ok {
+			addr = override
+		}
+
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// returns the address the transport dials for the proxy, i.e. its host and port (the default port of its scheme if
+// there's none)
+func proxyDialAddr(proxyURL *neturl.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		switch proxyURL.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// wraps a DialContext function, so that connections to an HTTPS proxy (at proxyAddr) are TLS connections
+func newTLSProxyDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error), proxyAddr string, config *tls.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil || addr != proxyAddr {
+			return conn, err
+		}
+
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with the proxy failed: %w", err)
+		}
+
+		return tlsConn, nil
+	}
+}
+
+// ResolveLocalAddr determines the local address to bind outgoing connections to (see Options.LocalAddr). The source
+// IP takes precedence over the interface, for which the first IPv4 address is used (or the first IPv6 address if
+// there is none). If neither is provided, nil is returned
+func ResolveLocalAddr(sourceIP string, iface string) (*net.TCPAddr, error) {
+	if sourceIP != "" {
+		ip := net.ParseIP(sourceIP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source IP: %s", sourceIP)
+		}
+		return &net.TCPAddr{IP: ip}, nil
+	}
+
+	if iface == "" {
+		return nil, nil
+	}
+
+	netInterface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	addrs, err := netInterface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses of interface %s: %w", iface, err)
+	}
+
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return &net.TCPAddr{IP: ipNet.IP}, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+
+	if fallback != nil {
+		return &net.TCPAddr{IP: fallback}, nil
+	}
+
+	return nil, fmt.Errorf("interface %s has no IP address", iface)
+}
@@ -0,0 +1,30 @@
+package probe
+
+import "net/http"
+
+// RequestHook is called before every request is sent and may modify it, e.g. to add a custom signature. Returning an
+// error aborts the request, and the error is reported in Result.Err
+type RequestHook interface {
+	BeforeRequest(req *http.Request) error
+}
+
+// ResponseHook is called for every response once its body was read (body is nil if NoBody is set). It may enrich
+// the result, e.g. by adding labels, or drop it from the output by setting Matched to false. Returning an error
+// marks the result as failed
+type ResponseHook interface {
+	AfterResponse(result *Result, body []byte) error
+}
+
+// RequestHookFunc allows using an ordinary function as RequestHook
+type RequestHookFunc func(req *http.Request) error
+
+func (f RequestHookFunc) BeforeRequest(req *http.Request) error {
+	return f(req)
+}
+
+// ResponseHookFunc allows using an ordinary function as ResponseHook
+type ResponseHookFunc func(result *Result, body []byte) error
+
+func (f ResponseHookFunc) AfterResponse(result *Result, body []byte) error {
+	return f(result, body)
+}
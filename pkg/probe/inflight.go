@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InFlightRequest is a request of Run that was sent but hasn't been answered yet
+type InFlightRequest struct {
+	Method string
+	URL    string
+	Start  time.Time
+}
+
+// the requests the workers of Run are currently waiting for
+type inFlightRequests struct {
+	mu       sync.Mutex
+	next     uint64
+	requests map[uint64]InFlightRequest
+}
+
+// records a request that's being sent and returns the ID to remove it with once it's done
+func (r *inFlightRequests) add(method string, url string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requests == nil {
+		r.requests = make(map[uint64]InFlightRequest)
+	}
+	r.next++
+	r.requests[r.next] = InFlightRequest{Method: method, URL: url, Start: time.Now()}
+
+	return r.next
+}
+
+func (r *inFlightRequests) remove(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.requests, id)
+}
+
+// InFlight returns the requests of Run that haven't been answered yet, the longest-running ones first. It's safe to
+// call while the scan is running, e.g. to find out why a scan seems stuck
+func (s *Scanner) InFlight() []InFlightRequest {
+	s.inFlight.mu.Lock()
+	requests := make([]InFlightRequest, 0, len(s.inFlight.requests))
+	for _, request := range s.inFlight.requests {
+		requests = append(requests, request)
+	}
+	s.inFlight.mu.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Start.Before(requests[j].Start)
+	})
+
+	return requests
+}
@@ -0,0 +1,42 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScanner_InFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	scanner := newTestScanner(t, Options{URLs: []string{server.URL + "/slow", server.URL + "/fast"}, Threads: 2})
+	results := scanner.Run(context.Background())
+
+	// the fast request is answered while the slow one is still in flight
+	<-results
+
+	inFlight := scanner.InFlight()
+	if len(inFlight) != 1 || inFlight[0].URL != server.URL+"/slow" || inFlight[0].Method != "GET" {
+		t.Fatalf("Expected only GET %s/slow to be in flight but got %v", server.URL, inFlight)
+	}
+	if inFlight[0].Start.IsZero() || inFlight[0].Start.After(time.Now()) {
+		t.Errorf("Expected the start of the request to be set but got %s", inFlight[0].Start)
+	}
+
+	release <- struct{}{}
+	for range results {
+	}
+
+	if inFlight := scanner.InFlight(); len(inFlight) != 0 {
+		t.Errorf("Expected no requests in flight after the scan but got %v", inFlight)
+	}
+}
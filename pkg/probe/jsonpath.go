@@ -0,0 +1,229 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the comparison operators of JSONPath conditions, two-character ones first so that "<=" isn't parsed as "<"
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// JSONPathCondition is a condition over a JSON body such as `$.user.role == "admin"`. The path supports member names
+// (`.name` or `['name']`), array indices (`[0]`) and wildcards (`.*` or `[*]`). The operator is followed by a JSON
+// value; without an operator, the condition is true if the path exists. If the path selects several values (via a
+// wildcard), any of them has to fulfil the condition
+type JSONPathCondition struct {
+	path     []jsonPathSegment
+	operator string
+	value    interface{}
+}
+
+type jsonPathSegment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParseJSONPathCondition parses an expression such as `$.items[*].price > 100`
+func ParseJSONPathCondition(expression string) (*JSONPathCondition, error) {
+	pathExpr, operator, valueExpr := splitJSONPathExpression(expression)
+
+	path, err := parseJSONPath(strings.TrimSpace(pathExpr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expression, err)
+	}
+
+	condition := &JSONPathCondition{path: path, operator: operator}
+	if operator != "" {
+		if err := json.Unmarshal([]byte(strings.TrimSpace(valueExpr)), &condition.value); err != nil {
+			return nil, fmt.Errorf("invalid value in JSONPath %q (expected a JSON value, e.g. \"admin\" in quotes): %w", expression, err)
+		}
+	}
+
+	return condition, nil
+}
+
+// splits the expression at the first operator that's not inside quotes or brackets
+func splitJSONPathExpression(expression string) (string, string, string) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			for _, operator := range jsonPathOperators {
+				if strings.HasPrefix(expression[i:], operator) {
+					return expression[:i], operator, expression[i+len(operator):]
+				}
+			}
+		}
+	}
+
+	return expression, "", ""
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("the path has to start with \"$\"")
+	}
+
+	var segments []jsonPathSegment
+	for rest := path[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("empty member name")
+			}
+			segments = append(segments, jsonPathSegment{name: name, wildcard: name == "*"})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing \"]\"")
+			}
+			segment, err := parseJSONPathBracket(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+
+	return segments, nil
+}
+
+// parses the content of `[...]`, i.e. a quoted member name, an index or a wildcard
+func parseJSONPathBracket(content string) (jsonPathSegment, error) {
+	if content == "*" {
+		return jsonPathSegment{wildcard: true}, nil
+	}
+
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return jsonPathSegment{name: content[1 : len(content)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathSegment{}, fmt.Errorf("invalid index %q", content)
+	}
+
+	return jsonPathSegment{index: index, isIndex: true}, nil
+}
+
+// Match reports if the body is JSON and fulfils the condition
+func (c *JSONPathCondition) Match(body []byte) bool {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return false
+	}
+
+	return c.matchDocument(document)
+}
+
+func (c *JSONPathCondition) matchDocument(document interface{}) bool {
+	for _, value := range selectJSONPath(document, c.path) {
+		if c.operator == "" || compareJSONValues(value, c.operator, c.value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns all values of the document the path selects
+func selectJSONPath(document interface{}, path []jsonPathSegment) []interface{} {
+	values := []interface{}{document}
+	for _, segment := range path {
+		var next []interface{}
+		for _, value := range values {
+			switch node := value.(type) {
+			case map[string]interface{}:
+				if segment.wildcard {
+					for _, child := range node {
+						next = append(next, child)
+					}
+				} else if child, ok := node[segment.name]; ok && !segment.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				index := segment.index
+				if index < 0 {
+					index += len(node)
+				}
+				if segment.wildcard {
+					next = append(next, node...)
+				} else if segment.isIndex && index >= 0 && index < len(node) {
+					next = append(next, node[index])
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// compares two JSON values. Numbers and strings can be ordered, all values can be checked for (in)equality
+func compareJSONValues(actual interface{}, operator string, expected interface{}) bool {
+	switch operator {
+	case "==":
+		return reflect.DeepEqual(actual, expected)
+	case "!=":
+		return !reflect.DeepEqual(actual, expected)
+	}
+
+	var cmp int
+	switch a := actual.(type) {
+	case float64:
+		b, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	case string:
+		b, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(a, b)
+	default:
+		return false
+	}
+
+	switch operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
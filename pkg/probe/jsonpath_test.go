@@ -0,0 +1,50 @@
+package probe
+
+import "testing"
+
+func TestJSONPathCondition(t *testing.T) {
+	body := []byte(`{"user": {"role": "admin", "id": 42, "active": true, "tags": ["a", "b"]}, "items": [{"price": 50}, {"price": 150}], "first name": "Alice"}`)
+
+	tests := map[string]bool{
+		`$.user.role == "admin"`:     true,
+		`$.user.role == "user"`:      false,
+		`$.user.role != "user"`:      true,
+		`$.user.id == 42`:            true,
+		`$.user.id >= 42`:            true,
+		`$.user.id < 42`:             false,
+		`$.user.active == true`:      true,
+		`$.user.tags[1] == "b"`:      true,
+		`$.user.tags[-1] == "b"`:     true,
+		`$.user.tags[2]`:             false,
+		`$.items[*].price > 100`:     true,
+		`$.items[*].price > 200`:     false,
+		`$.*.role == "admin"`:        true,
+		`$['first name'] == "Alice"`: true,
+		`$.user.email`:               false,
+		`$.user`:                     true,
+		`$.user.role > 5`:            false,
+	}
+
+	for expression, expected := range tests {
+		condition, err := ParseJSONPathCondition(expression)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", expression, err)
+		}
+		if actual := condition.Match(body); actual != expected {
+			t.Errorf("Expected %v for %s but got %v", expected, expression, actual)
+		}
+	}
+
+	condition, _ := ParseJSONPathCondition(`$.role == "admin"`)
+	if condition.Match([]byte("<html>role admin</html>")) {
+		t.Errorf("Expected a body that isn't JSON not to match")
+	}
+}
+
+func TestParseJSONPathCondition_Invalid(t *testing.T) {
+	for _, expression := range []string{`user.role == "admin"`, `$.user.role == admin`, `$.items[x]`, `$.items[0`, `$..role`} {
+		if _, err := ParseJSONPathCondition(expression); err == nil {
+			t.Errorf("Expected an error for %s", expression)
+		}
+	}
+}
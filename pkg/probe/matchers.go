@@ -0,0 +1,283 @@
+package probe
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// all conditions of a matcher must be true
+	ConditionAnd = "and"
+	// at least one condition of a matcher must be true
+	ConditionOr = "or"
+)
+
+// Matcher labels responses that fulfil its conditions. Every non-empty field is a condition, e.g.
+//
+//	{"label": "admin", "condition": "and", "status": [200], "body": ["(?i)admin dashboard"]}
+//
+// labels all 200 responses whose body contains "admin dashboard". Within a single condition, any of the listed values
+// has to match (e.g. `"status": [200, 201]`), while Condition decides how the conditions are combined
+type Matcher struct {
+	// the label attached to matching results
+	Label string `json:"label"`
+	// how the conditions are combined: "and" (default) or "or"
+	Condition string `json:"condition,omitempty"`
+	// status codes of which one must match
+	Status []int `json:"status,omitempty"`
+	// lengths of which one must match, as reported in the results (e.g. the Content-Length with NoBody)
+	Lengths []int `json:"lengths,omitempty"`
+	// regexes of which one must match the body
+	Body []string `json:"body,omitempty"`
+	// maps header names to regexes that must match one of the header's values
+	Headers map[string]string `json:"headers,omitempty"`
+	// JSONPath conditions (see JSONPathCondition) of which one must hold for the JSON body
+	JSONPath []string `json:"jsonpath,omitempty"`
+	// XPath conditions (see XPathCondition) of which one must hold for the XML body
+	XPath []string `json:"xpath,omitempty"`
+
+	bodyRegexes   []*regexp.Regexp
+	headerRegexes map[string]*regexp.Regexp
+	jsonPaths     []*JSONPathCondition
+	xpaths        []*XPathCondition
+}
+
+// LoadMatchers reads a JSON file containing a list of matchers and compiles them
+func LoadMatchers(path string) ([]Matcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchers []Matcher
+	if err := json.Unmarshal(data, &matchers); err != nil {
+		return nil, fmt.Errorf("failed to parse matchers file: %w", err)
+	}
+
+	for i := range matchers {
+		if err := matchers[i].Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return matchers, nil
+}
+
+// LoadRules reads a file of simple body rules, one `<regex> => <label>` per line, e.g.
+//
+//	(?i)access denied => denied
+//	Dashboard => authenticated-content
+//
+// and turns every rule into a Matcher with a single body regex. Empty lines and lines starting with "#" are ignored
+func LoadRules(path string) ([]Matcher, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var matchers []Matcher
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// the regex itself may contain "=>", the label can't
+		i := strings.LastIndex(line, "=>")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rule in line %d (expected \"<regex> => <label>\"): %s", lineNumber, line)
+		}
+
+		matcher := Matcher{
+			Label: strings.TrimSpace(line[i+2:]),
+			Body:  []string{strings.TrimSpace(line[:i])},
+		}
+		if err := matcher.Compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule in line %d: %w", lineNumber, err)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers, scanner.Err()
+}
+
+// JSONPathMatcher creates a Matcher from a single JSONPath condition, optionally followed by its label, e.g.
+// `$.user.role == "admin" => admin`. Without a label, the expression itself is the label
+func JSONPathMatcher(expression string) (Matcher, error) {
+	expression, label := splitLabel(expression)
+	matcher := Matcher{Label: label, JSONPath: []string{expression}}
+
+	return matcher, matcher.Compile()
+}
+
+// XPathMatcher creates a Matcher from a single XPath condition, optionally followed by its label, e.g.
+// `//faultcode = 'soap:Client' => soap-fault`. Without a label, the expression itself is the label
+func XPathMatcher(expression string) (Matcher, error) {
+	expression, label := splitLabel(expression)
+	matcher := Matcher{Label: label, XPath: []string{expression}}
+
+	return matcher, matcher.Compile()
+}
+
+// splits `<expression> => <label>` into the expression and the label, which is the expression itself if there's none
+func splitLabel(expression string) (string, string) {
+	expression = strings.TrimSpace(expression)
+
+	// the expression itself may contain "=>" (e.g. in a string value), the label can't
+	if i := strings.LastIndex(expression, "=>"); i >= 0 && !strings.ContainsAny(expression[i:], "\"'") {
+		return strings.TrimSpace(expression[:i]), strings.TrimSpace(expression[i+2:])
+	}
+
+	return expression, expression
+}
+
+// Compile validates the matcher and compiles its regexes. It has to be called before the matcher is used, unless the
+// matcher was created via LoadMatchers
+func (m *Matcher) Compile() error {
+	if m.Label == "" {
+		return fmt.Errorf("matcher without label")
+	}
+
+	m.Condition = strings.ToLower(m.Condition)
+	if m.Condition == "" {
+		m.Condition = ConditionAnd
+	}
+	if m.Condition != ConditionAnd && m.Condition != ConditionOr {
+		return fmt.Errorf("invalid condition of matcher %s: %s", m.Label, m.Condition)
+	}
+
+	m.bodyRegexes = nil
+	for _, expr := range m.Body {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid body regex of matcher %s: %w", m.Label, err)
+		}
+		m.bodyRegexes = append(m.bodyRegexes, regex)
+	}
+
+	m.headerRegexes = make(map[string]*regexp.Regexp)
+	for name, expr := range m.Headers {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid header regex of matcher %s: %w", m.Label, err)
+		}
+		m.headerRegexes[name] = regex
+	}
+
+	m.jsonPaths = nil
+	for _, expr := range m.JSONPath {
+		condition, err := ParseJSONPathCondition(expr)
+		if err != nil {
+			return fmt.Errorf("invalid JSONPath of matcher %s: %w", m.Label, err)
+		}
+		m.jsonPaths = append(m.jsonPaths, condition)
+	}
+
+	m.xpaths = nil
+	for _, expr := range m.XPath {
+		condition, err := ParseXPathCondition(expr)
+		if err != nil {
+			return fmt.Errorf("invalid XPath of matcher %s: %w", m.Label, err)
+		}
+		m.xpaths = append(m.xpaths, condition)
+	}
+
+	return nil
+}
+
+// Match checks the conditions of the matcher against a response. The length is the one of the result, which differs
+// from the body's with NoBody (no body) and BodySample (a part of the body). A matcher without conditions never matches
+func (m *Matcher) Match(statusCode int, header http.Header, length int, body []byte) bool {
+	var results []bool
+
+	if len(m.Status) > 0 {
+		results = append(results, containsInt(m.Status, statusCode))
+	}
+
+	if len(m.Lengths) > 0 {
+		results = append(results, containsInt(m.Lengths, length))
+	}
+
+	if len(m.bodyRegexes) > 0 {
+		matched := false
+		for _, regex := range m.bodyRegexes {
+			if regex.Match(body) {
+				matched = true
+				break
+			}
+		}
+		results = append(results, matched)
+	}
+
+	for name, regex := range m.headerRegexes {
+		matched := false
+		for _, value := range header.Values(name) {
+			if regex.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		results = append(results, matched)
+	}
+
+	if len(m.jsonPaths) > 0 {
+		// the body is only parsed once for all conditions, and a body that isn't JSON matches none of them
+		matched := false
+		var document interface{}
+		if json.Unmarshal(body, &document) == nil {
+			for _, condition := range m.jsonPaths {
+				if condition.matchDocument(document) {
+					matched = true
+					break
+				}
+			}
+		}
+		results = append(results, matched)
+	}
+
+	if len(m.xpaths) > 0 {
+		// the same goes for XML bodies
+		matched := false
+		if document, err := parseXMLDocument(body); err == nil {
+			for _, condition := range m.xpaths {
+				if condition.matchNode(document) {
+					matched = true
+					break
+				}
+			}
+		}
+		results = append(results, matched)
+	}
+
+	if len(results) == 0 {
+		return false
+	}
+
+	for _, result := range results {
+		if m.Condition == ConditionOr && result {
+			return true
+		}
+		if m.Condition != ConditionOr && !result {
+			return false
+		}
+	}
+
+	return m.Condition != ConditionOr
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
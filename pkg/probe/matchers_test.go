@@ -0,0 +1,134 @@
+package probe
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	body := []byte(`{"role": "admin"}`)
+
+	tests := []struct {
+		matcher  Matcher
+		expected bool
+	}{
+		{Matcher{Label: "status", Status: []int{200, 201}}, true},
+		{Matcher{Label: "status", Status: []int{403}}, false},
+		{Matcher{Label: "and", Status: []int{200}, Body: []string{`"role": "admin"`}}, true},
+		{Matcher{Label: "and", Status: []int{200}, Body: []string{`"role": "user"`}}, false},
+		{Matcher{Label: "or", Condition: "or", Status: []int{403}, Body: []string{"admin"}}, true},
+		{Matcher{Label: "or", Condition: "or", Status: []int{403}, Lengths: []int{1}}, false},
+		{Matcher{Label: "header", Headers: map[string]string{"content-type": "json"}}, true},
+		{Matcher{Label: "length", Lengths: []int{len(body)}}, true},
+		{Matcher{Label: "jsonpath", JSONPath: []string{`$.role == "user"`, `$.role == "admin"`}}, true},
+		{Matcher{Label: "jsonpath", Status: []int{200}, JSONPath: []string{`$.role == "user"`}}, false},
+		{Matcher{Label: "xpath", XPath: []string{"//role"}}, false},
+		{Matcher{Label: "empty"}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.matcher.Compile(); err != nil {
+			t.Fatalf("Failed to compile matcher: %v", err)
+		}
+		if actual := test.matcher.Match(200, header, len(body), body); actual != test.expected {
+			t.Errorf("Expected %v for matcher %+v but got %v", test.expected, test.matcher, actual)
+		}
+	}
+
+	// the length of the result is matched, not the one of the body (e.g. nil with NoBody)
+	matcher := Matcher{Label: "length", Lengths: []int{1234}}
+	if !matcher.Match(200, header, 1234, nil) || matcher.Match(200, header, 17, body) {
+		t.Errorf("Expected the length matcher to match the length of the result")
+	}
+}
+
+func TestLoadMatchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matchers.json")
+	os.WriteFile(path, []byte(`[{"label": "admin", "status": [200], "body": ["(?i)admin"]}]`), 0644)
+
+	matchers, err := LoadMatchers(path)
+	if err != nil || len(matchers) != 1 || !matchers[0].Match(200, nil, 5, []byte("Admin")) {
+		t.Errorf("Expected one working matcher but got %+v (err: %v)", matchers, err)
+	}
+
+	os.WriteFile(path, []byte(`[{"label": "broken", "body": ["("]}]`), 0644)
+	if _, err := LoadMatchers(path); err == nil {
+		t.Errorf("Expected an error for an invalid regex")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	os.WriteFile(path, []byte("# classification rules\n(?i)access denied => denied\n\n<h1>Dashboard</h1> => authenticated-content\n"), 0644)
+
+	rules, err := LoadRules(path)
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("Expected 2 rules but got %+v (err: %v)", rules, err)
+	}
+
+	tests := []struct {
+		body     string
+		expected []string
+	}{
+		{"Access Denied", []string{"denied"}},
+		{"<h1>Dashboard</h1>", []string{"authenticated-content"}},
+		{"Welcome", nil},
+	}
+
+	for _, test := range tests {
+		var labels []string
+		for i := range rules {
+			if rules[i].Match(200, nil, len(test.body), []byte(test.body)) {
+				labels = append(labels, rules[i].Label)
+			}
+		}
+		if strings.Join(labels, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Expected %v for %q but got %v", test.expected, test.body, labels)
+		}
+	}
+
+	for _, invalid := range []string{"no arrow", "( => broken", "regex =>"} {
+		os.WriteFile(path, []byte(invalid), 0644)
+		if _, err := LoadRules(path); err == nil {
+			t.Errorf("Expected an error for the rule %q", invalid)
+		}
+	}
+}
+
+func TestJSONPathMatcher(t *testing.T) {
+	tests := []struct {
+		expression string
+		label      string
+	}{
+		{`$.user.role == "admin" => admin`, "admin"},
+		{`$.user.role == "admin"`, `$.user.role == "admin"`},
+		{`$.redirect == "a=>b"`, `$.redirect == "a=>b"`},
+	}
+
+	for _, test := range tests {
+		matcher, err := JSONPathMatcher(test.expression)
+		if err != nil || matcher.Label != test.label {
+			t.Errorf("Expected the label %q for %s but got %q (err: %v)", test.label, test.expression, matcher.Label, err)
+		}
+	}
+
+	if _, err := JSONPathMatcher(`$.user.role == admin`); err == nil {
+		t.Errorf("Expected an error for an invalid value")
+	}
+}
+
+func TestXPathMatcher(t *testing.T) {
+	matcher, err := XPathMatcher(`//faultcode = 'soap:Client' => soap-fault`)
+	if err != nil || matcher.Label != "soap-fault" {
+		t.Fatalf("Expected the label soap-fault but got %q (err: %v)", matcher.Label, err)
+	}
+
+	body := []byte(`<Envelope><Body><Fault><faultcode>soap:Client</faultcode></Fault></Body></Envelope>`)
+	if !matcher.Match(500, nil, len(body), body) {
+		t.Errorf("Expected the matcher to match the SOAP fault")
+	}
+}
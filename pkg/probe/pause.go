@@ -0,0 +1,72 @@
+package probe
+
+import (
+	"context"
+	"sync"
+)
+
+// Pause pauses and resumes a running scan (see Options.Pause), e.g. when the owners of the target ask to halt the
+// traffic for a while. Requests in flight are finished, but no new ones are sent until the scan is resumed
+type Pause struct {
+	mu sync.Mutex
+	// closed when the scan is resumed (nil while it isn't paused)
+	resumed chan struct{}
+}
+
+// NewPause creates a Pause for a scan that's running
+func NewPause() *Pause {
+	return &Pause{}
+}
+
+// Pause pauses the scan. Returns false if it was already paused
+func (p *Pause) Pause() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.resumed != nil {
+		return false
+	}
+	p.resumed = make(chan struct{})
+
+	return true
+}
+
+// Resume resumes the scan. Returns false if it wasn't paused
+func (p *Pause) Resume() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.resumed == nil {
+		return false
+	}
+	close(p.resumed)
+	p.resumed = nil
+
+	return true
+}
+
+// Paused reports if the scan is paused
+func (p *Pause) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.resumed != nil
+}
+
+// blocks while the scan is paused. Returns false if the context was cancelled in the meantime
+func (p *Pause) wait(ctx context.Context) bool {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+
+	if resumed == nil {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,55 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScannerRun_Pause(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	pause := NewPause()
+	if !pause.Pause() || pause.Pause() || !pause.Paused() {
+		t.Fatalf("Expected the first Pause to pause the scan and the second one to do nothing")
+	}
+
+	scanner := newTestScanner(t, Options{URLs: []string{server.URL + "/a", server.URL + "/b"}, Pause: pause})
+	results := scanner.Run(context.Background())
+
+	time.Sleep(100 * time.Millisecond)
+	if actual := atomic.LoadInt32(&requests); actual != 0 {
+		t.Errorf("Expected no requests while paused but got %d", actual)
+	}
+
+	if !pause.Resume() || pause.Resume() || pause.Paused() {
+		t.Fatalf("Expected the first Resume to resume the scan and the second one to do nothing")
+	}
+
+	count := 0
+	for range results {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 results after resuming but got %d", count)
+	}
+}
+
+func TestPause_CancelledWhilePaused(t *testing.T) {
+	pause := NewPause()
+	pause.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if pause.wait(ctx) {
+		t.Errorf("Expected the wait to end when the context is cancelled")
+	}
+}
@@ -0,0 +1,355 @@
+// Package probe contains the probing engine of SessionProbe. It sends requests for a list of URLs (e.g. with the
+// session of a specific user) and reports the status code and length of every response, so that other Go tools can
+// embed it instead of shelling out to the `sessionprobe` binary.
+//
+// Example:
+//
+//	scanner, err := probe.NewScanner(probe.Options{
+//		URLs:    []string{"https://example.com/admin"},
+//		Headers: map[string][]string{"Cookie": {"session=..."}},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	for result := range scanner.Run(context.Background()) {
+//		fmt.Println(result.Method, result.URL, result.StatusCode, result.Length)
+//	}
+package probe
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Options configures a Scanner. Only URLs is required, all other fields have sensible zero values
+type Options struct {
+	// the URLs to be checked. They are checked as provided, i.e. deduplication is up to the caller
+	URLs []string
+	// URLs that are checked while they're being sent (after URLs), until the channel is closed. This allows inputs
+	// that are too large to be held in memory. The WarmUpRequests are only sent for the hosts of URLs
+	URLSource <-chan string
+	// the HTTP methods every URL is checked with (default: GET)
+	Methods []string
+	// HTTP headers to be set in every request. Multiple cookies are joined into a single Cookie header
+	Headers map[string][]string
+	// number of URLs that are checked concurrently (default: 10)
+	Threads int
+	// timeout per request (default: 10s)
+	Timeout time.Duration
+
+	// proxy URL for all requests (default: the proxy from the environment). With an "https://" URL, the connection to
+	// the proxy itself is TLS
+	Proxy string
+	// CA certificates the TLS connection to an HTTPS proxy is verified against (default: the system's CAs)
+	ProxyRootCAs *x509.CertPool
+	// skip verification of TLS certificates
+	SkipVerification bool
+	// server name (SNI) to send in the TLS handshake instead of the host of the URL
+	SNI string
+	// the minimum and maximum TLS versions, e.g. tls.VersionTLS10 for legacy servers (see ParseTLSVersion). 0 means
+	// Go's default
+	TLSMinVersion uint16
+	TLSMaxVersion uint16
+	// the cipher suites offered for TLS 1.2 and below (default: Go's secure defaults), see ParseCipherSuites
+	CipherSuites []uint16
+	// maps "host:port" to the "ip:port" that should be dialed instead, see ParseResolveOverrides
+	ResolveOverrides map[string]string
+	// maps "host:port" (or "*:port") to the port that should be dialed instead, see ParsePortMap. Like the resolve
+	// overrides, it only applies to direct connections (not via a proxy)
+	PortMap map[string]string
+	// local address outgoing connections are bound to, see ResolveLocalAddr
+	LocalAddr *net.TCPAddr
+
+	// Host header to be sent instead of the host of the URL
+	HostHeader string
+	// write the requests without Go's HTTP transport, which canonicalizes the header names and sorts the headers, so
+	// that the headers are sent with the casing and in the order of HeaderOrder. Every request gets its own connection
+	RawHeaders bool
+	// the names of the headers (with their casing) in the order they're sent in with RawHeaders. Headers that aren't
+	// in the list (e.g. ones set by hooks) follow them
+	HeaderOrder []string
+	// send HTTP/1.0 requests (without a Connection header) instead of HTTP/1.1, e.g. for middleware that applies
+	// different rules to them. Like with RawHeaders, every request gets its own connection
+	HTTP10 bool
+	// send "Connection: close" and open a new connection for every request instead of reusing connections
+	DisableKeepAlives bool
+	// User-Agent to be sent instead of Go's default User-Agent
+	UserAgent string
+	// send a random browser User-Agent (from UserAgents) with every request. Takes precedence over UserAgent
+	RandomAgent bool
+
+	// number of throwaway requests per host before the scan, so that the connection setup (e.g. TLS) doesn't distort
+	// the first results and load balancers with session affinity settle. Their responses aren't reported
+	WarmUpRequests int
+
+	// pauses and resumes the scan while it's running (optional)
+	Pause *Pause
+	// delay of a worker before each request
+	Delay time.Duration
+	// random extra delay (between 0 and Jitter) that is added to Delay
+	Jitter time.Duration
+
+	// maximum number of bytes read per response body (0 means unlimited)
+	MaxBodyBytes int64
+	// only request the first BodySample bytes of GET responses via a Range header (0 means the whole body). A 206 is
+	// reported as 200 with the length of the whole body, and bodies of servers that ignore the Range header are cut off
+	// after BodySample bytes
+	BodySample int64
+	// don't read response bodies at all and take the length from the Content-Length header instead
+	NoBody bool
+
+	// number of redirects that are followed per request (0 means none, i.e. the redirect itself is reported). A redirect
+	// back to an already requested URL fails with ErrRedirectLoop, one too many with ErrTooManyRedirects
+	MaxRedirects int
+
+	// responses whose body matches this regex are not matched (ignored if NoBody is set)
+	FilterRegex *regexp.Regexp
+	// responses with one of these lengths are not matched
+	ExcludedLengths map[int]bool
+
+	// matchers whose labels are attached to the results they match
+	Matchers []Matcher
+	// search response bodies for sensitive data (see SecretPatterns) and report it in Result.Secrets
+	ScanSecrets bool
+	// number of characters of the (whitespace-collapsed) body that are reported in Result.Snippet (0 means none)
+	SnippetLength int
+
+	// header (e.g. "X-Scan-Id") that is set to a unique ID per request, so that the requests can be found in the
+	// logs of the target. The ID is reported in Result.CorrelationID
+	CorrelationHeader string
+
+	// give every worker its own connection pool and cookie jar, so that cookies set by the responses (e.g. rolling
+	// session tokens) are sent with the following requests of the worker, like a browser would
+	StickySessions bool
+
+	// hooks that are called (in order) before every request is sent
+	RequestHooks []RequestHook
+	// hooks that are called (in order) for every response
+	ResponseHooks []ResponseHook
+}
+
+// Result is the outcome of a single request
+type Result struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// the headers of the response
+	Header http.Header
+	// length of the response body, or the Content-Length if NoBody is set (-1 if unknown)
+	Length int
+	// set if the body was larger than MaxBodyBytes (or BodySample) and only the first bytes were read
+	Truncated bool
+	// reports if the response passed the FilterRegex and ExcludedLengths filters
+	Matched bool
+	// time from sending the request until the response body was read
+	Duration time.Duration
+	// labels attached to the result by the Matchers or a ResponseHook
+	Labels []string
+	// severity (e.g. "high") assigned to the result by a ResponseHook
+	Severity string
+	// sensitive data found in the body if ScanSecrets is set
+	Secrets []Secret
+	// the beginning of the body if SnippetLength is set
+	Snippet string
+	// the ID sent in the CorrelationHeader (if set)
+	CorrelationID string
+	// set if the request failed, e.g. because of a network error
+	Err error
+}
+
+// Scanner checks a list of URLs as configured by its Options
+type Scanner struct {
+	opts     Options
+	client   *http.Client
+	inFlight inFlightRequests
+}
+
+// NewScanner creates a Scanner for the given options
+func NewScanner(opts Options) (*Scanner, error) {
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodGet}
+	}
+
+	if opts.Threads <= 0 {
+		opts.Threads = 10
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scanner{opts: opts, client: client}, nil
+}
+
+// Run checks every URL with every method and sends the results on the returned channel, which is closed once all
+// requests are done. Cancelling the context stops the scan, in which case not all results are sent
+func (s *Scanner) Run(ctx context.Context) <-chan Result {
+	results := make(chan Result)
+	urls := make(chan string)
+
+	// dispatch the URLs to the workers
+	go func() {
+		defer close(urls)
+
+		if s.opts.WarmUpRequests > 0 {
+			s.warmUp(ctx)
+		}
+
+		for _, url := range s.opts.URLs {
+			select {
+			case urls <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if s.opts.URLSource == nil {
+			return
+		}
+		for url := range s.opts.URLSource {
+			select {
+			case urls <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.Threads; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var sess *session
+			if s.opts.StickySessions {
+				// the options were already validated by NewScanner
+				sess, _ = newStickySession(s.opts)
+			}
+
+			for url := range urls {
+				for _, method := range s.opts.Methods {
+					if !s.waitBeforeRequest(ctx) {
+						return
+					}
+
+					start := time.Now()
+					id := s.inFlight.add(method, url)
+					result := s.checkURL(ctx, sess, method, url)
+					s.inFlight.remove(id)
+					result.Duration = time.Since(start)
+
+					select {
+					case results <- result:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// close the results channel once all workers are done
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// sends WarmUpRequests GET requests to the first URL of every host, with the hosts being warmed up concurrently. The
+// responses are discarded without running the Matchers or ResponseHooks
+func (s *Scanner) warmUp(ctx context.Context) {
+	var hostURLs []string
+	seen := make(map[string]bool)
+	for _, url := range s.opts.URLs {
+		parsed, err := neturl.Parse(url)
+		if err != nil || seen[parsed.Scheme+"://"+parsed.Host] {
+			continue
+		}
+		seen[parsed.Scheme+"://"+parsed.Host] = true
+		hostURLs = append(hostURLs, url)
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, s.opts.Threads)
+	for _, url := range hostURLs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			for i := 0; i < s.opts.WarmUpRequests && ctx.Err() == nil; i++ {
+				s.discardRequest(ctx, url)
+			}
+		}(url)
+	}
+	wg.Wait()
+}
+
+// sends a GET request (with the RequestHooks applied) and reads its body, so that the connection can be reused
+func (s *Scanner) discardRequest(ctx context.Context, url string) {
+	req, err := s.prepareHTTPRequest(ctx, Request{Method: http.MethodGet, URL: url})
+	if err != nil {
+		return
+	}
+
+	for _, hook := range s.opts.RequestHooks {
+		if hook.BeforeRequest(req) != nil {
+			return
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+}
+
+// waits while the scan is paused and then sleeps for Delay plus a random duration of up to Jitter. Returns false if the
+// context was cancelled in the meantime
+func (s *Scanner) waitBeforeRequest(ctx context.Context) bool {
+	if s.opts.Pause != nil && !s.opts.Pause.wait(ctx) {
+		return false
+	}
+
+	wait := s.opts.Delay
+	if s.opts.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(s.opts.Jitter) + 1))
+	}
+
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
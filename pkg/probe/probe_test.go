@@ -0,0 +1,486 @@
+package probe
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// creates a Scanner for the tests, failing the test if the options are invalid
+func newTestScanner(t *testing.T, opts Options) *Scanner {
+	scanner, err := NewScanner(opts)
+	if err != nil {
+		t.Fatalf("Failed to create scanner: %v", err)
+	}
+
+	return scanner
+}
+
+func TestProcessResponse_MatchesRegex(t *testing.T) {
+	compiledRegex, _ := regexp.Compile("World")
+	statusCode := 200
+	body := []byte("Hello, World!")
+	expectedStatus, expectedLength, expectedMatched := 200, len(body), false
+	excludedLengths := make(map[int]bool)
+
+	actualStatus, actualLength, actualMatched := filterResponseByLengthAndRegex(statusCode, body, compiledRegex, excludedLengths)
+
+	if actualStatus != expectedStatus || actualLength != expectedLength || actualMatched != expectedMatched {
+		t.Errorf("Expected status %d, length %d, matched %v but got status %d, length %d, matched %v",
+			expectedStatus, expectedLength, expectedMatched, actualStatus, actualLength, actualMatched)
+	}
+}
+
+func TestProcessResponse_DoesNotMatchRegex(t *testing.T) {
+	compiledRegex, _ := regexp.Compile("Bye")
+	statusCode := 200
+	body := []byte("Hello, World!")
+	expectedStatus, expectedLength, expectedMatched := 200, len(body), true
+	excludedLengths := make(map[int]bool)
+
+	actualStatus, actualLength, actualMatched := filterResponseByLengthAndRegex(statusCode, body, compiledRegex, excludedLengths)
+
+	if actualStatus != expectedStatus || actualLength != expectedLength || actualMatched != expectedMatched {
+		t.Errorf("Expected status %d, length %d, matched %v but got status %d, length %d, matched %v",
+			expectedStatus, expectedLength, expectedMatched, actualStatus, actualLength, actualMatched)
+	}
+}
+
+func TestCheckURL_MatchesRegex(t *testing.T) {
+	// Mock HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer server.Close()
+
+	compiledRegex, _ := regexp.Compile("World")   // Matching regex
+	expectedStatus, expectedMatched := 200, false // It should filter out the response because it matches
+
+	scanner := newTestScanner(t, Options{FilterRegex: compiledRegex})
+	result := scanner.checkURL(context.Background(), nil, "GET", server.URL)
+
+	if result.StatusCode != expectedStatus || result.Matched != expectedMatched {
+		t.Errorf("Expected status %d, matched %v but got status %d, matched %v",
+			expectedStatus, expectedMatched, result.StatusCode, result.Matched)
+	}
+}
+
+func TestCheckURL_DoesNotMatchRegex(t *testing.T) {
+	// Mock HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer server.Close()
+
+	compiledRegex, _ := regexp.Compile("Bye")    // Non-matching regex
+	expectedStatus, expectedMatched := 200, true // It should not filter out the response because it doesn't match
+
+	scanner := newTestScanner(t, Options{FilterRegex: compiledRegex})
+	result := scanner.checkURL(context.Background(), nil, "GET", server.URL)
+
+	if result.StatusCode != expectedStatus || result.Matched != expectedMatched {
+		t.Errorf("Expected status %d, matched %v but got status %d, matched %v",
+			expectedStatus, expectedMatched, result.StatusCode, result.Matched)
+	}
+}
+
+func TestCheckURL_ExcludedLength(t *testing.T) {
+	// Mock HTTP server with a fixed response length
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Hello, World!")) // Length is 13
+	}))
+	defer server.Close()
+
+	compiledRegex, _ := regexp.Compile(".*")      // Matching any string
+	expectedStatus, expectedMatched := 200, false // It should filter out the response because of its length
+	excludedLengths := map[int]bool{
+		13: true, // Excluding the length 13
+	}
+
+	scanner := newTestScanner(t, Options{FilterRegex: compiledRegex, ExcludedLengths: excludedLengths})
+	result := scanner.checkURL(context.Background(), nil, "GET", server.URL)
+
+	if result.StatusCode != expectedStatus || result.Matched != expectedMatched {
+		t.Errorf("Expected status %d, matched %v but got status %d, matched %v",
+			expectedStatus, expectedMatched, result.StatusCode, result.Matched)
+	}
+}
+
+func TestReadResponseBody_MaxBodySize(t *testing.T) {
+	body, truncated, err := readResponseBody(strings.NewReader("Hello, World!"), 5)
+	if err != nil || !truncated || string(body) != "Hello" {
+		t.Errorf("Expected truncated body \"Hello\" but got %q (truncated: %v, err: %v)", body, truncated, err)
+	}
+
+	body, truncated, err = readResponseBody(strings.NewReader("Hello"), 5)
+	if err != nil || truncated || string(body) != "Hello" {
+		t.Errorf("Expected untruncated body \"Hello\" but got %q (truncated: %v, err: %v)", body, truncated, err)
+	}
+}
+
+func TestCheckURL_NoBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "13")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer server.Close()
+
+	// the regex is ignored with NoBody
+	compiledRegex, _ := regexp.Compile("World")
+
+	result := newTestScanner(t, Options{NoBody: true, FilterRegex: compiledRegex}).checkURL(context.Background(), nil, "GET", server.URL)
+	if result.StatusCode != 200 || result.Length != 13 || !result.Matched {
+		t.Errorf("Expected status 200, length 13, matched true but got status %d, length %d, matched %v", result.StatusCode, result.Length, result.Matched)
+	}
+
+	result = newTestScanner(t, Options{NoBody: true, ExcludedLengths: map[int]bool{13: true}}).checkURL(context.Background(), nil, "GET", server.URL)
+	if result.Matched {
+		t.Errorf("Expected the response to be filtered by its Content-Length")
+	}
+}
+
+func TestPrepareHTTPRequest_UserAgent(t *testing.T) {
+	scanner := newTestScanner(t, Options{UserAgent: "custom-agent"})
+	req, _ := scanner.prepareHTTPRequest(context.Background(), Request{Method: "GET", URL: "http://example.com"})
+	if ua := req.Header.Get("User-Agent"); ua != "custom-agent" {
+		t.Errorf("Expected User-Agent custom-agent but got %s", ua)
+	}
+
+	// a User-Agent provided via the headers takes precedence
+	scanner = newTestScanner(t, Options{UserAgent: "custom-agent", Headers: map[string][]string{"User-Agent": {"header-agent"}}})
+	req, _ = scanner.prepareHTTPRequest(context.Background(), Request{Method: "GET", URL: "http://example.com"})
+	if ua := req.Header.Values("User-Agent"); len(ua) != 1 || ua[0] != "header-agent" {
+		t.Errorf("Expected User-Agent header-agent but got %v", ua)
+	}
+}
+
+func TestCheckURL_HostHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "internal.example.com" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	result := newTestScanner(t, Options{HostHeader: "internal.example.com"}).checkURL(context.Background(), nil, "GET", server.URL)
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for the overridden Host header but got %d", result.StatusCode)
+	}
+}
+
+func TestParseResolveOverrides(t *testing.T) {
+	overrides, err := ParseResolveOverrides([]string{"Staging.Example.com:443:10.0.0.5", "v6.example.com:80:[::1]"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"staging.example.com:443": "10.0.0.5:443",
+		"v6.example.com:80":       "[::1]:80",
+	}
+	for host, addr := range expected {
+		if overrides[host] != addr {
+			t.Errorf("Expected %s to resolve to %s but got %s", host, addr, overrides[host])
+		}
+	}
+
+	if _, err := ParseResolveOverrides([]string{"example.com:443"}); err == nil {
+		t.Errorf("Expected an error for an entry without IP")
+	}
+	if _, err := ParseResolveOverrides([]string{"example.com:443:not-an-ip"}); err == nil {
+		t.Errorf("Expected an error for an invalid IP")
+	}
+}
+
+func TestCheckURL_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	resolveOverrides, _ := ParseResolveOverrides([]string{"staging.invalid:" + port + ":127.0.0.1"})
+
+	result := newTestScanner(t, Options{ResolveOverrides: resolveOverrides}).checkURL(context.Background(), nil, "GET", "http://staging.invalid:"+port+"/")
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 via the resolve override but got %d", result.StatusCode)
+	}
+}
+
+func TestParsePortMap(t *testing.T) {
+	portMap, err := ParsePortMap([]string{"Staging.Example.com:443=8443", "*:80=8080"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := map[string]string{
+		"staging.example.com:443": "staging.example.com:8443",
+		"other.example.com:443":   "other.example.com:443",
+		"other.example.com:80":    "other.example.com:8080",
+	}
+	for addr, expected := range tests {
+		if actual := mapPort(portMap, addr); actual != expected {
+			t.Errorf("Expected %s to be mapped to %s but got %s", addr, expected, actual)
+		}
+	}
+
+	for _, invalid := range []string{"example.com=8443", "example.com:443", "example.com:443=http", ":443=8443"} {
+		if _, err := ParsePortMap([]string{invalid}); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestCheckURL_PortMap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	// the URL of "production" is sent to the port of the test server, with the original Host header
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	portMap, _ := ParsePortMap([]string{"127.0.0.1:80=" + port})
+
+	result := newTestScanner(t, Options{PortMap: portMap}).checkURL(context.Background(), nil, "GET", "http://127.0.0.1/")
+	if result.Err != nil || result.Length != len("127.0.0.1") {
+		t.Errorf("Expected the request to be sent to port %s but got %+v", port, result)
+	}
+}
+
+func TestCheckURL_PortMapProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	// the port map and the resolve overrides only apply to the targets, so the proxy is still reached
+	proxyAddr := strings.TrimPrefix(proxy.URL, "http://")
+	portMap, _ := ParsePortMap([]string{proxyAddr + "=1"})
+	resolveOverrides, _ := ParseResolveOverrides([]string{proxyAddr + ":[::1]"})
+
+	scanner := newTestScanner(t, Options{Proxy: proxy.URL, PortMap: portMap, ResolveOverrides: resolveOverrides})
+	result := scanner.checkURL(context.Background(), nil, "GET", "http://target.example/admin")
+	if expected := len("proxied http://target.example/admin"); result.Err != nil || result.Length != expected {
+		t.Errorf("Expected a length of %d via the proxy but got %d (err: %v)", expected, result.Length, result.Err)
+	}
+}
+
+func TestCheckURL_HTTPSProxy(t *testing.T) {
+	// a TLS proxy that answers absolute-form requests itself
+	proxy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(proxy.Certificate())
+
+	// the SNI of the targets must not be used for the proxy
+	scanner := newTestScanner(t, Options{Proxy: proxy.URL, ProxyRootCAs: roots, SNI: "target.example"})
+	result := scanner.checkURL(context.Background(), nil, "GET", "http://target.example/admin")
+
+	if expected := len("proxied http://target.example/admin"); result.Err != nil || result.Length != expected {
+		t.Errorf("Expected a length of %d via the HTTPS proxy but got %d (err: %v)", expected, result.Length, result.Err)
+	}
+
+	// without the proxy's CA, the handshake fails
+	scanner = newTestScanner(t, Options{Proxy: proxy.URL})
+	if result := scanner.checkURL(context.Background(), nil, "GET", "http://target.example/admin"); result.Err == nil {
+		t.Errorf("Expected the untrusted proxy certificate to be rejected")
+	}
+}
+
+func TestResolveLocalAddr(t *testing.T) {
+	addr, err := ResolveLocalAddr("127.0.0.1", "")
+	if err != nil || addr == nil || !addr.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("Expected local address 127.0.0.1 but got %v (err: %v)", addr, err)
+	}
+
+	if addr, err := ResolveLocalAddr("", ""); err != nil || addr != nil {
+		t.Errorf("Expected no local address but got %v (err: %v)", addr, err)
+	}
+
+	if _, err := ResolveLocalAddr("not-an-ip", ""); err == nil {
+		t.Errorf("Expected an error for an invalid source IP")
+	}
+
+	if _, err := ResolveLocalAddr("", "does-not-exist0"); err == nil {
+		t.Errorf("Expected an error for an unknown interface")
+	}
+}
+
+func TestCheckURL_Hooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(r.Header.Get("X-Signature")))
+	}))
+	defer server.Close()
+
+	scanner := newTestScanner(t, Options{
+		RequestHooks: []RequestHook{RequestHookFunc(func(req *http.Request) error {
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		})},
+		ResponseHooks: []ResponseHook{ResponseHookFunc(func(result *Result, body []byte) error {
+			result.Labels = append(result.Labels, "body:"+string(body))
+			return nil
+		})},
+	})
+
+	result := scanner.checkURL(context.Background(), nil, "GET", server.URL)
+	if result.Err != nil || len(result.Labels) != 1 || result.Labels[0] != "body:signed" {
+		t.Errorf("Expected the label body:signed but got %v (err: %v)", result.Labels, result.Err)
+	}
+}
+
+func TestScannerDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(r.Header.Get("Content-Type") + " " + string(body)))
+	}))
+	defer server.Close()
+
+	scanner := newTestScanner(t, Options{})
+	result := scanner.Do(context.Background(), Request{
+		Method: "POST",
+		URL:    server.URL,
+		Body:   []byte(`{"query":"{__typename}"}`),
+		Header: http.Header{"Content-Type": {"application/json"}},
+	})
+
+	if expected := len(`application/json {"query":"{__typename}"}`); result.Err != nil || result.Length != expected {
+		t.Errorf("Expected a length of %d but got %d (err: %v)", expected, result.Length, result.Err)
+	}
+}
+
+func TestScannerRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(r.Method))
+	}))
+	defer server.Close()
+
+	scanner := newTestScanner(t, Options{
+		URLs:    []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"},
+		Methods: []string{"GET", "POST"},
+		Threads: 2,
+	})
+
+	count := 0
+	for result := range scanner.Run(context.Background()) {
+		if result.Err != nil || result.StatusCode != http.StatusOK || result.Length != len(result.Method) {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		count++
+	}
+
+	if count != 6 {
+		t.Errorf("Expected 6 results but got %d", count)
+	}
+}
+
+func TestScannerRun_WarmUp(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	hookCalls := 0
+	scanner := newTestScanner(t, Options{
+		URLs:           []string{server.URL + "/a", server.URL + "/b"},
+		WarmUpRequests: 2,
+		ResponseHooks: []ResponseHook{ResponseHookFunc(func(result *Result, body []byte) error {
+			hookCalls++
+			return nil
+		})},
+		Threads: 1,
+	})
+
+	count := 0
+	for range scanner.Run(context.Background()) {
+		count++
+	}
+
+	// the warm-up requests go to the first URL of the host and aren't reported
+	if count != 2 || hookCalls != 2 || requests["/a"] != 3 || requests["/b"] != 1 {
+		t.Errorf("Expected 2 warm-up requests to /a and 2 results but got %d results, %d hook calls and requests %v", count, hookCalls, requests)
+	}
+}
+
+func TestCheckURL_ConnectionSemantics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the status code reports the protocol and whether the client asked to close the connection
+		status := http.StatusOK
+		if r.Proto == "HTTP/1.0" {
+			status = http.StatusAccepted
+		}
+		if r.Close {
+			status += 100
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		opts     Options
+		expected int
+	}{
+		{Options{}, http.StatusOK},
+		{Options{DisableKeepAlives: true}, http.StatusOK + 100},
+		{Options{HTTP10: true}, http.StatusAccepted + 100},
+	}
+
+	for _, test := range tests {
+		scanner := newTestScanner(t, test.opts)
+		result := scanner.checkURL(context.Background(), nil, "GET", server.URL)
+		if result.Err != nil || result.StatusCode != test.expected {
+			t.Errorf("Expected status %d with HTTP10=%v, DisableKeepAlives=%v but got %d (err: %v)",
+				test.expected, test.opts.HTTP10, test.opts.DisableKeepAlives, result.StatusCode, result.Err)
+		}
+	}
+}
+
+func TestScannerRun_URLSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	source := make(chan string)
+	go func() {
+		defer close(source)
+		for _, path := range []string{"/b", "/c", "/d"} {
+			source <- server.URL + path
+		}
+	}()
+
+	scanner := newTestScanner(t, Options{URLs: []string{server.URL + "/a"}, URLSource: source, Threads: 2})
+
+	checked := make(map[string]bool)
+	for result := range scanner.Run(context.Background()) {
+		checked[strings.TrimPrefix(result.URL, server.URL)] = true
+	}
+
+	if len(checked) != 4 || !checked["/a"] || !checked["/d"] {
+		t.Errorf("Expected the URLs and the ones of the source to be checked but got %v", checked)
+	}
+}
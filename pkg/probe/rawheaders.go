@@ -0,0 +1,238 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"sort"
+)
+
+// rawTransport sends every request over a new connection and writes the request itself instead of leaving it to
+// http.Transport, which canonicalizes the header names (e.g. "x-api-key" => "X-Api-Key") and sorts the headers. The
+// headers named in headerOrder are written first, in that order and with that casing, followed by all others (e.g.
+// the ones set by hooks). Apart from Host, Content-Length and "Connection: close" (not for HTTP/1.0), no headers are
+// added
+type rawTransport struct {
+	proxy       func(*http.Request) (*neturl.URL, error)
+	dial        func(ctx context.Context, network, addr string) (net.Conn, error)
+	tlsConfig   *tls.Config
+	headerOrder []string
+	// send HTTP/1.0 instead of HTTP/1.1 requests
+	http10 bool
+}
+
+// the body of a response of the rawTransport, which closes the connection when it's closed
+type rawBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+func (b *rawBody) Close() error {
+	b.stop()
+	b.ReadCloser.Close()
+	return b.conn.Close()
+}
+
+func (t *rawTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	addr := hostPort(req.URL)
+
+	proxyURL, err := t.proxy(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dialAddr := addr
+	if proxyURL != nil {
+		dialAddr = hostPort(proxyURL)
+	}
+	conn, err := t.dial(ctx, "tcp", dialAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the connection is closed if the request is canceled (e.g. because of the timeout)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		conn.Close()
+		return nil, err
+	}
+
+	if req.URL.Scheme == "https" {
+		if proxyURL != nil {
+			if err := connectTunnel(conn, addr, proxyURL); err != nil {
+				return fail(err)
+			}
+		}
+
+		config := t.tlsConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = req.URL.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fail(err)
+		}
+		conn = tlsConn
+	}
+
+	// plain HTTP requests are sent to the proxy with the absolute URL instead of through a tunnel
+	var viaProxy *neturl.URL
+	if req.URL.Scheme == "http" {
+		viaProxy = proxyURL
+	}
+	if err := t.writeRequest(conn, req, viaProxy); err != nil {
+		return fail(err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fail(err)
+	}
+	resp.Body = &rawBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+
+	return resp, nil
+}
+
+// writes the request line, the headers and the body
+func (t *rawTransport) writeRequest(w io.Writer, req *http.Request, proxyURL *neturl.URL) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		req.Body.Close()
+	}
+
+	target := req.URL.RequestURI()
+	if proxyURL != nil {
+		absolute := *req.URL
+		absolute.Fragment = ""
+		target = absolute.String()
+	}
+
+	var buf bytes.Buffer
+	proto := "HTTP/1.1"
+	if t.http10 {
+		proto = "HTTP/1.0"
+	}
+	fmt.Fprintf(&buf, "%s %s %s\r\n", req.Method, target, proto)
+
+	fields := rawHeaderFields(req.Header, t.headerOrder)
+	if req.Header.Get("Host") == "" {
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		fields = append([][2]string{{"Host", host}}, fields...)
+	}
+	if req.Header.Get("Content-Length") == "" && (len(body) > 0 || methodWithBody(req.Method)) {
+		fields = append(fields, [2]string{"Content-Length", fmt.Sprint(len(body))})
+	}
+	// HTTP/1.0 connections are closed after the response anyway, unless the client asks for keep-alive
+	if req.Header.Get("Connection") == "" && !t.http10 {
+		fields = append(fields, [2]string{"Connection", "close"})
+	}
+	if proxyURL != nil && proxyURL.User != nil {
+		fields = append(fields, [2]string{"Proxy-Authorization", proxyAuthorization(proxyURL)})
+	}
+
+	for _, field := range fields {
+		fmt.Fprintf(&buf, "%s: %s\r\n", field[0], field[1])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// returns the headers as name/value pairs. The names of the order come first, with their casing. If a name appears
+// several times in the order, it gets the next value of the header each time. The remaining values follow, sorted by
+// name
+func rawHeaderFields(header http.Header, order []string) [][2]string {
+	used := make(map[string]int)
+	var fields [][2]string
+	for _, name := range order {
+		key := http.CanonicalHeaderKey(name)
+		if values := header[key]; used[key] < len(values) {
+			fields = append(fields, [2]string{name, values[used[key]]})
+			used[key]++
+		}
+	}
+
+	var keys []string
+	for key := range header {
+		if used[key] < len(header[key]) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key][used[key]:] {
+			fields = append(fields, [2]string{key, value})
+		}
+	}
+
+	return fields
+}
+
+// opens a tunnel to the address through the proxy via CONNECT
+func connectTunnel(conn net.Conn, addr string, proxyURL *neturl.URL) error {
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", addr, addr)
+	if proxyURL.User != nil {
+		request += "Proxy-Authorization: " + proxyAuthorization(proxyURL) + "\r\n"
+	}
+	if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return fmt.Errorf("CONNECT to the proxy failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CONNECT to the proxy failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func proxyAuthorization(proxyURL *neturl.URL) string {
+	password, _ := proxyURL.User.Password()
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username()+":"+password))
+}
+
+// returns the "host:port" of the URL, with the default port of the scheme if it has none
+func hostPort(url *neturl.URL) string {
+	if url.Port() != "" {
+		return url.Host
+	}
+
+	if url.Scheme == "https" {
+		return net.JoinHostPort(url.Hostname(), "443")
+	}
+
+	return net.JoinHostPort(url.Hostname(), "80")
+}
+
+// reports if requests of the method are expected to have a body, so that they're sent with a Content-Length even if
+// the body is empty (like http.Transport does)
+func methodWithBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
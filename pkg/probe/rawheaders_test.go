@@ -0,0 +1,79 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckURL_RawHeaders(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// the request head as it was received, since an http.Server would canonicalize the headers
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		received <- lines
+
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello"))
+	}()
+
+	scanner := newTestScanner(t, Options{
+		Headers:     map[string][]string{"x-api-key": {"secret"}, "cookie": {"a=1"}, "X-FORWARDED-FOR": {"127.0.0.1"}},
+		UserAgent:   "probe",
+		RawHeaders:  true,
+		HeaderOrder: []string{"x-api-key", "X-FORWARDED-FOR", "cookie"},
+	})
+	result := scanner.checkURL(context.Background(), nil, "GET", "http://"+listener.Addr().String()+"/path?q=1")
+	if result.Err != nil || result.StatusCode != 200 || result.Length != 5 {
+		t.Fatalf("Expected status 200 and length 5 but got status %d, length %d (err: %v)", result.StatusCode, result.Length, result.Err)
+	}
+
+	expected := []string{
+		"GET /path?q=1 HTTP/1.1",
+		"Host: " + listener.Addr().String(),
+		"x-api-key: secret",
+		"X-FORWARDED-FOR: 127.0.0.1",
+		"cookie: a=1",
+		"User-Agent: probe",
+		"Connection: close",
+	}
+	if actual := <-received; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected the request\n%s\nbut got\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func TestRawHeaderFields(t *testing.T) {
+	header := http.Header{
+		"Cookie":    {"a=1", "b=2"},
+		"X-Api-Key": {"secret"},
+		"Accept":    {"*/*"},
+	}
+
+	expected := [][2]string{{"cookie", "a=1"}, {"x-api-key", "secret"}, {"COOKIE", "b=2"}, {"Accept", "*/*"}}
+	if actual := rawHeaderFields(header, []string{"cookie", "x-api-key", "X-Missing", "COOKIE", "cookie"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
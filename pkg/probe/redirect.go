@@ -0,0 +1,36 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrRedirectLoop is reported (wrapped) if a redirect leads back to a URL that was already requested
+	ErrRedirectLoop = errors.New("redirect loop")
+	// ErrTooManyRedirects is reported (wrapped) if a request was redirected more than MaxRedirects times
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// returns the redirect policy of the client. Without MaxRedirects, redirects aren't followed at all, so that the
+// redirect itself is reported (e.g. a 302 to the login page)
+func redirectPolicy(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects <= 0 {
+			return http.ErrUseLastResponse
+		}
+
+		for _, previous := range via {
+			if previous.Method == req.Method && previous.URL.String() == req.URL.String() {
+				return fmt.Errorf("%w: %s was requested again after %d redirects", ErrRedirectLoop, req.URL, len(via))
+			}
+		}
+
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, maxRedirects)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckURL_Redirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/middle", http.StatusFound)
+		case "/middle":
+			http.Redirect(w, r, "/end", http.StatusFound)
+		case "/end":
+			w.Write([]byte("end"))
+		case "/loop-a":
+			http.Redirect(w, r, "/loop-b", http.StatusFound)
+		case "/loop-b":
+			http.Redirect(w, r, "/loop-a", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		maxRedirects int
+		path         string
+		status       int
+		err          error
+	}{
+		{0, "/start", http.StatusFound, nil},
+		{2, "/start", http.StatusOK, nil},
+		{1, "/start", 0, ErrTooManyRedirects},
+		{10, "/loop-a", 0, ErrRedirectLoop},
+	}
+
+	for _, test := range tests {
+		scanner := newTestScanner(t, Options{MaxRedirects: test.maxRedirects})
+		result := scanner.checkURL(context.Background(), nil, "GET", server.URL+test.path)
+		if result.StatusCode != test.status || !errors.Is(result.Err, test.err) {
+			t.Errorf("Expected status %d and error %v for %s with %d redirects but got status %d and error %v",
+				test.status, test.err, test.path, test.maxRedirects, result.StatusCode, result.Err)
+		}
+	}
+}
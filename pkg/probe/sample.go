@@ -0,0 +1,49 @@
+package probe
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// asks for the first BodySample bytes of the body via a Range header. Only GET requests without a Range header of
+// their own are sampled, and none if NoBody is set (as the body isn't read anyway)
+func (s *Scanner) sampleRange(req *http.Request) bool {
+	if s.opts.BodySample <= 0 || s.opts.NoBody || req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return false
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", s.opts.BodySample-1))
+	return true
+}
+
+// turns the response to a sampled request into the one the request without the Range header would have gotten, i.e.
+// a 206 is reported as 200. Returns the length of the whole body (-1 if unknown), which is taken from the
+// Content-Range of a 206 and from the Content-Length otherwise
+func sampledResponse(resp *http.Response) int64 {
+	if resp.StatusCode != http.StatusPartialContent {
+		// the server ignored the Range header, so the body is cut off while it's read instead
+		return resp.ContentLength
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Status = "200 OK"
+
+	return contentRangeTotal(resp.Header.Get("Content-Range"))
+}
+
+// returns the complete length of a Content-Range header such as "bytes 0-4095/123456" (-1 if it's unknown, i.e. "*")
+func contentRangeTotal(contentRange string) int64 {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found {
+		return -1
+	}
+
+	length, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil || length < 0 {
+		return -1
+	}
+
+	return length
+}
@@ -0,0 +1,65 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckURL_BodySample(t *testing.T) {
+	content := strings.Repeat("a", 10000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ranged":
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+		case "/ignored":
+			w.Header().Set("Content-Length", "10000")
+			w.Write([]byte(content))
+		case "/unsatisfiable":
+			if r.Header.Get("Range") != "" {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Write([]byte("empty"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path      string
+		length    int
+		truncated bool
+	}{
+		{"/ranged", 10000, true},
+		{"/ignored", 10000, true},
+		{"/unsatisfiable", 5, false},
+	}
+
+	scanner := newTestScanner(t, Options{BodySample: 100, ExcludedLengths: map[int]bool{100: true}})
+	for _, test := range tests {
+		result := scanner.checkURL(context.Background(), nil, "GET", server.URL+test.path)
+		if result.Err != nil || result.StatusCode != 200 || result.Length != test.length || result.Truncated != test.truncated || !result.Matched {
+			t.Errorf("Expected status 200, length %d, truncated %v, matched true for %s but got status %d, length %d, truncated %v, matched %v (err: %v)",
+				test.length, test.truncated, test.path, result.StatusCode, result.Length, result.Truncated, result.Matched, result.Err)
+		}
+	}
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	tests := map[string]int64{
+		"bytes 0-4095/123456": 123456,
+		"bytes 0-99/100":      100,
+		"bytes 0-4095/*":      -1,
+		"":                    -1,
+	}
+
+	for contentRange, expected := range tests {
+		if actual := contentRangeTotal(contentRange); actual != expected {
+			t.Errorf("Expected %d for %q but got %d", expected, contentRange, actual)
+		}
+	}
+}
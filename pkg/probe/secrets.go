@@ -0,0 +1,53 @@
+package probe
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Secret is a piece of sensitive data that was found in a response body
+type Secret struct {
+	// the kind of data, e.g. "aws-access-key" or "jwt"
+	Kind string
+	// the matched value
+	Value string
+}
+
+// maximum number of matches reported per kind and response, so that e.g. a user list doesn't flood the output
+const maxSecretsPerKind = 5
+
+// SecretPatterns maps the kinds of sensitive data that are detected if ScanSecrets is set to their regexes
+var SecretPatterns = map[string]*regexp.Regexp{
+	"aws-access-key": regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"jwt":            regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+	"email":          regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	"internal-ip":    regexp.MustCompile(`\b(?:10\.(?:\d{1,3}\.){2}\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3})\b`),
+	"private-key":    regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`),
+}
+
+// FindSecrets runs all SecretPatterns over the body and returns the distinct matches, sorted by kind
+func FindSecrets(body []byte) []Secret {
+	var kinds []string
+	for kind := range SecretPatterns {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var secrets []Secret
+	for _, kind := range kinds {
+		seen := make(map[string]bool)
+		for _, match := range SecretPatterns[kind].FindAll(body, -1) {
+			if seen[string(match)] {
+				continue
+			}
+			seen[string(match)] = true
+			secrets = append(secrets, Secret{Kind: kind, Value: string(match)})
+
+			if len(seen) == maxSecretsPerKind {
+				break
+			}
+		}
+	}
+
+	return secrets
+}
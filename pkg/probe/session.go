@@ -0,0 +1,119 @@
+package probe
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// the HTTP client and cookies a request is sent with. By default, all workers share a single session without cookie
+// jar, while with StickySessions every worker gets its own
+type session struct {
+	client *http.Client
+	// the cookies set by the responses of this session (nil if cookies aren't kept)
+	jar http.CookieJar
+	// set while the client sends a request whose Cookie header already contains the cookies of the jar (see
+	// redirectJar). A session is only used by a single worker, so this needs no lock
+	merged bool
+}
+
+// the jar of the client of a sticky session. It stores the cookies of every response, including the ones of redirect
+// hops, but leaves out the cookies of the initial request, which addCookies already merged into its Cookie header
+// (before the RequestHooks, so that e.g. a signature covers them)
+type redirectJar struct {
+	sess *session
+}
+
+func (j redirectJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.sess.jar.SetCookies(u, cookies)
+}
+
+func (j redirectJar) Cookies(u *url.URL) []*http.Cookie {
+	if j.sess.merged {
+		j.sess.merged = false
+		return nil
+	}
+
+	return j.sess.jar.Cookies(u)
+}
+
+// creates a session with its own connection pool and cookie jar
+func newStickySession(opts Options) (*session, error) {
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &session{client: client, jar: jar}
+	client.Jar = redirectJar{sess}
+
+	// the client copies the Cookie header of the initial request to the redirect hops, where the jar then adds its
+	// cookies once more, so the copied ones are dropped
+	policy := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := policy(req, via); err != nil {
+			return err
+		}
+		sess.removeJarCookies(req)
+		return nil
+	}
+
+	return sess, nil
+}
+
+// sends the request with the client of the session
+func (s *session) do(req *http.Request) (*http.Response, error) {
+	s.merged = s.jar != nil
+	return s.client.Do(req)
+}
+
+// adds the cookies of the jar to the Cookie header, replacing configured cookies with the same name (e.g. a rolling
+// session token)
+func (s *session) addCookies(req *http.Request) {
+	if s.jar == nil {
+		return
+	}
+
+	jarCookies := s.jar.Cookies(req.URL)
+	if len(jarCookies) == 0 {
+		return
+	}
+
+	pairs := s.removeJarCookies(req)
+	for _, cookie := range jarCookies {
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+
+	req.Header.Set("Cookie", strings.Join(pairs, "; "))
+}
+
+// removes the cookies the jar has for the URL from the Cookie header and returns the remaining pairs
+func (s *session) removeJarCookies(req *http.Request) []string {
+	replaced := make(map[string]bool)
+	for _, cookie := range s.jar.Cookies(req.URL) {
+		replaced[cookie.Name] = true
+	}
+
+	var pairs []string
+	for _, pair := range strings.Split(req.Header.Get("Cookie"), ";") {
+		pair = strings.TrimSpace(pair)
+		name, _, _ := strings.Cut(pair, "=")
+		if pair != "" && !replaced[name] {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	if len(pairs) == 0 {
+		req.Header.Del("Cookie")
+	} else {
+		req.Header.Set("Cookie", strings.Join(pairs, "; "))
+	}
+
+	return pairs
+}
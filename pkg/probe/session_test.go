@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestScannerRun_StickySessions(t *testing.T) {
+	// a rolling session token: every response issues the next token, which has to be sent with the next request
+	var mu sync.Mutex
+	next := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		cookie, err := r.Cookie("token")
+		if err != nil || cookie.Value != strconv.Itoa(next-1) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "token", Value: strconv.Itoa(next), Path: "/"})
+		next++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		sticky   bool
+		expected int
+	}{
+		{false, 1},
+		{true, 3},
+	}
+
+	for _, test := range tests {
+		mu.Lock()
+		next = 1
+		mu.Unlock()
+
+		scanner := newTestScanner(t, Options{
+			URLs:           []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"},
+			Headers:        map[string][]string{"Cookie": {"token=0", "lang=en"}},
+			Threads:        1,
+			StickySessions: test.sticky,
+		})
+
+		ok := 0
+		for result := range scanner.Run(context.Background()) {
+			if result.StatusCode == http.StatusOK {
+				ok++
+			}
+		}
+
+		if ok != test.expected {
+			t.Errorf("Expected %d successful requests with sticky=%v but got %d", test.expected, test.sticky, ok)
+		}
+	}
+}
+
+func TestSessionAddCookies(t *testing.T) {
+	sess, err := newStickySession(Options{})
+	if err != nil {
+		t.Fatalf("Failed to create the session: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("Cookie", "token=old; lang=en")
+	sess.jar.SetCookies(req.URL, []*http.Cookie{{Name: "token", Value: "new"}})
+
+	sess.addCookies(req)
+	if cookie := req.Header.Get("Cookie"); cookie != "lang=en; token=new" {
+		t.Errorf("Expected the token to be replaced but got %q", cookie)
+	}
+}
+
+func TestScannerRun_StickySessionsSignedRedirects(t *testing.T) {
+	// the login sets the session cookie on a redirect hop, which has to be sent to the redirect target
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			if cookies := r.Header.Values("Cookie"); len(cookies) != 1 || cookies[0] != "lang=en; session=s1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			// the request hook has to see the sticky cookies
+			if r.Header.Get("X-Signed-Cookie") != r.Header.Get("Cookie") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	signer := RequestHookFunc(func(req *http.Request) error {
+		req.Header.Set("X-Signed-Cookie", req.Header.Get("Cookie"))
+		return nil
+	})
+
+	scanner := newTestScanner(t, Options{
+		URLs:           []string{server.URL + "/login", server.URL + "/home", server.URL + "/signed"},
+		Headers:        map[string][]string{"Cookie": {"lang=en"}},
+		Threads:        1,
+		MaxRedirects:   5,
+		StickySessions: true,
+		RequestHooks:   []RequestHook{signer},
+	})
+
+	for result := range scanner.Run(context.Background()) {
+		if result.Err != nil || result.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200 for %s but got %d (err: %v)", result.URL, result.StatusCode, result.Err)
+		}
+	}
+}
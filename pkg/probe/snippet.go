@@ -0,0 +1,17 @@
+package probe
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// returns the first `length` characters of the body with all whitespace (e.g. the indentation of HTML) collapsed into
+// single spaces. A cut off snippet ends with "..."
+func bodySnippet(body []byte, length int) string {
+	collapsed := strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+	if utf8.RuneCountInString(collapsed) <= length {
+		return collapsed
+	}
+
+	return string([]rune(collapsed)[:length]) + "..."
+}
@@ -0,0 +1,44 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBodySnippet(t *testing.T) {
+	tests := []struct {
+		body     string
+		length   int
+		expected string
+	}{
+		{"<html>\n  <title>Login</title>\n</html>", 100, "<html> <title>Login</title> </html>"},
+		{"<html>\n  <title>Login</title>\n</html>", 13, "<html> <title..."},
+		{"  Grüße,\tWelt  ", 5, "Grüße..."},
+		{"", 10, ""},
+	}
+
+	for _, test := range tests {
+		if actual := bodySnippet([]byte(test.body), test.length); actual != test.expected {
+			t.Errorf("Expected %q for %q but got %q", test.expected, test.body, actual)
+		}
+	}
+}
+
+func TestCheckURL_Snippet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h1>\n  Admin Panel\n</h1>"))
+	}))
+	defer server.Close()
+
+	result := newTestScanner(t, Options{SnippetLength: 20}).checkURL(context.Background(), nil, "GET", server.URL)
+	if result.Snippet != "<h1> Admin Panel </h..." {
+		t.Errorf("Expected the snippet \"<h1> Admin Panel </h...\" but got %q", result.Snippet)
+	}
+
+	result = newTestScanner(t, Options{}).checkURL(context.Background(), nil, "GET", server.URL)
+	if result.Snippet != "" {
+		t.Errorf("Expected no snippet but got %q", result.Snippet)
+	}
+}
@@ -0,0 +1,78 @@
+package probe
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses a TLS version like "1.2" (see Options.TLSMinVersion). An empty version returns 0, i.e. Go's
+// default
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	parsed, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("invalid TLS version: %s (valid versions: 1.0, 1.1, 1.2, 1.3)", version)
+	}
+
+	return parsed, nil
+}
+
+// ParseTLSVersionRange parses the minimum and the maximum TLS version (see ParseTLSVersion) and checks that the minimum
+// isn't above the maximum, which would make every handshake fail
+func ParseTLSVersionRange(min string, max string) (uint16, uint16, error) {
+	minVersion, err := ParseTLSVersion(min)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxVersion, err := ParseTLSVersion(max)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		return 0, 0, fmt.Errorf("the minimum TLS version %s is above the maximum TLS version %s", min, max)
+	}
+
+	return minVersion, maxVersion, nil
+}
+
+// ParseCipherSuites parses a comma-separated list of cipher suite names (e.g. "TLS_RSA_WITH_AES_128_CBC_SHA"). Unlike
+// Go's defaults, the list may contain insecure suites, which legacy servers may require. Note that the suites of TLS
+// 1.3 can't be configured
+func ParseCipherSuites(names string) ([]uint16, error) {
+	if names == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.Name] = suite.ID
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite: %s", name)
+		}
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
@@ -0,0 +1,74 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := map[string]uint16{"": 0, "1.0": tls.VersionTLS10, "TLS1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+	for version, expected := range tests {
+		if actual, err := ParseTLSVersion(version); err != nil || actual != expected {
+			t.Errorf("Expected %x for %q but got %x (err: %v)", expected, version, actual, err)
+		}
+	}
+
+	if _, err := ParseTLSVersion("1.4"); err == nil {
+		t.Errorf("Expected an error for an invalid version")
+	}
+}
+
+func TestParseTLSVersionRange(t *testing.T) {
+	if min, max, err := ParseTLSVersionRange("1.2", "1.3"); err != nil || min != tls.VersionTLS12 || max != tls.VersionTLS13 {
+		t.Errorf("Expected the range 1.2-1.3 but got %x-%x (err: %v)", min, max, err)
+	}
+	if _, _, err := ParseTLSVersionRange("1.2", "1.2"); err != nil {
+		t.Errorf("Expected a single version to be valid but got %v", err)
+	}
+	if _, _, err := ParseTLSVersionRange("1.3", ""); err != nil {
+		t.Errorf("Expected a minimum without a maximum to be valid but got %v", err)
+	}
+	if _, _, err := ParseTLSVersionRange("1.3", "1.2"); err == nil {
+		t.Errorf("Expected an error for a minimum above the maximum")
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	suites, err := ParseCipherSuites("TLS_RSA_WITH_AES_128_CBC_SHA, tls_ecdhe_rsa_with_aes_128_gcm_sha256")
+	if err != nil || len(suites) != 2 || suites[0] != tls.TLS_RSA_WITH_AES_128_CBC_SHA || suites[1] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("Expected the two cipher suites but got %v (err: %v)", suites, err)
+	}
+
+	if _, err := ParseCipherSuites("TLS_NOT_A_SUITE"); err == nil {
+		t.Errorf("Expected an error for an unknown cipher suite")
+	}
+}
+
+func TestCheckURL_TLSVersions(t *testing.T) {
+	// a "legacy" server that doesn't support TLS 1.3
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		opts    Options
+		success bool
+	}{
+		{Options{SkipVerification: true, TLSMaxVersion: tls.VersionTLS12}, true},
+		{Options{SkipVerification: true, TLSMinVersion: tls.VersionTLS13}, false},
+		{Options{SkipVerification: true, TLSMaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}}, true},
+	}
+
+	for _, test := range tests {
+		result := newTestScanner(t, test.opts).checkURL(context.Background(), nil, "GET", server.URL)
+		if (result.Err == nil) != test.success {
+			t.Errorf("Expected success=%v for %+v but got %v", test.success, test.opts, result.Err)
+		}
+	}
+}
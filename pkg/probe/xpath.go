@@ -0,0 +1,418 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the comparison operators of XPath conditions, two-character ones first so that "<=" isn't parsed as "<"
+var xpathOperators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// XPathCondition is a condition over an XML body (e.g. of a SOAP API) such as `//faultcode = 'soap:Client'`. The
+// supported subset of XPath consists of child (`/`) and descendant (`//`) steps, element names (namespace prefixes
+// are ignored, i.e. `soap:Body` matches any `Body` element), `*`, `@attribute`, `text()` and the predicates `[2]`,
+// `[@attribute]`, `[@attribute='value']` and `[child='value']`. The operator is followed by a quoted string or a
+// number; without an operator, the condition is true if the path selects anything. If the path selects several
+// values, any of them has to fulfil the condition
+type XPathCondition struct {
+	path     []xpathStep
+	operator string
+	value    string
+}
+
+type xpathStep struct {
+	// descendant-or-self (`//`) instead of child (`/`)
+	descendant bool
+	// the element name, "*", "@attribute" or "text()"
+	test       string
+	predicates []xpathPredicate
+}
+
+type xpathPredicate struct {
+	// the 1-based position if the predicate is a number (0 otherwise)
+	position  int
+	condition *XPathCondition
+}
+
+// an element of the parsed XML document
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	// the text directly inside the element
+	text string
+}
+
+// a value selected by an XPath, i.e. an element or the string of an attribute or text()
+type xpathValue struct {
+	node  *xmlNode
+	value string
+}
+
+// ParseXPathCondition parses an expression such as `//user[@id='1']/role = 'admin'`
+func ParseXPathCondition(expression string) (*XPathCondition, error) {
+	condition, err := parseXPathCondition(strings.TrimSpace(expression), false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %w", expression, err)
+	}
+
+	return condition, nil
+}
+
+// parses a condition. Relative ones (in predicates) start at the context node, absolute ones at the document
+func parseXPathCondition(expression string, relative bool) (*XPathCondition, error) {
+	pathExpr, operator, valueExpr := splitXPathExpression(expression)
+
+	path, err := parseXPath(strings.TrimSpace(pathExpr), relative)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := &XPathCondition{path: path, operator: operator}
+	if operator != "" {
+		value := strings.TrimSpace(valueExpr)
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+			condition.value = value[1 : len(value)-1]
+		} else if _, err := strconv.ParseFloat(value, 64); err == nil {
+			condition.value = value
+		} else {
+			return nil, fmt.Errorf("invalid value %s (expected a quoted string or a number)", value)
+		}
+	}
+
+	return condition, nil
+}
+
+// splits the expression at the first operator that's not inside quotes or brackets
+func splitXPathExpression(expression string) (string, string, string) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			for _, operator := range xpathOperators {
+				if strings.HasPrefix(expression[i:], operator) {
+					return expression[:i], operator, expression[i+len(operator):]
+				}
+			}
+		}
+	}
+
+	return expression, "", ""
+}
+
+func parseXPath(path string, relative bool) ([]xpathStep, error) {
+	if relative && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("the path has to start with \"/\" or \"//\"")
+	}
+
+	var steps []xpathStep
+	for rest := path; rest != ""; {
+		step := xpathStep{}
+		if strings.HasPrefix(rest, "//") {
+			step.descendant = true
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+
+		end := xpathStepEnd(rest)
+		stepExpr := rest[:end]
+		rest = rest[end:]
+
+		// the predicates follow the node test, e.g. "user[@id='1'][2]"
+		test := stepExpr
+		if i := strings.Index(stepExpr, "["); i >= 0 {
+			test = stepExpr[:i]
+			predicates, err := parseXPathPredicates(stepExpr[i:])
+			if err != nil {
+				return nil, err
+			}
+			step.predicates = predicates
+		}
+
+		step.test = strings.TrimSpace(test)
+		if step.test == "" {
+			return nil, fmt.Errorf("empty step")
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// returns the index of the "/" that ends the step (ignoring the ones inside predicates)
+func xpathStepEnd(rest string) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			return i
+		}
+	}
+
+	return len(rest)
+}
+
+// parses a sequence of predicates such as "[@id='1'][2]"
+func parseXPathPredicates(expression string) ([]xpathPredicate, error) {
+	var predicates []xpathPredicate
+	for rest := expression; rest != ""; {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+
+		// find the matching "]", ignoring the ones inside quotes
+		var quote byte
+		end := -1
+		for i := 1; i < len(rest) && end < 0; i++ {
+			switch c := rest[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == ']':
+				end = i
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("missing \"]\"")
+		}
+
+		content := strings.TrimSpace(rest[1:end])
+		if position, err := strconv.Atoi(content); err == nil {
+			if position < 1 {
+				return nil, fmt.Errorf("invalid position %d", position)
+			}
+			predicates = append(predicates, xpathPredicate{position: position})
+		} else {
+			condition, err := parseXPathCondition(content, true)
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, xpathPredicate{condition: condition})
+		}
+		rest = rest[end+1:]
+	}
+
+	return predicates, nil
+}
+
+// Match reports if the body is XML and fulfils the condition
+func (c *XPathCondition) Match(body []byte) bool {
+	document, err := parseXMLDocument(body)
+	if err != nil {
+		return false
+	}
+
+	return c.matchNode(document)
+}
+
+// evaluates the condition with the node as starting point
+func (c *XPathCondition) matchNode(node *xmlNode) bool {
+	for _, value := range selectXPath(node, c.path) {
+		if c.operator == "" || compareXPathValue(value.String(), c.operator, c.value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns all values the path selects, starting at the node
+func selectXPath(node *xmlNode, path []xpathStep) []xpathValue {
+	values := []xpathValue{{node: node}}
+	for _, step := range path {
+		var next []xpathValue
+		for _, value := range values {
+			if value.node == nil {
+				// attributes and text have no children
+				continue
+			}
+
+			contexts := []*xmlNode{value.node}
+			if step.descendant {
+				contexts = value.node.descendantsOrSelf()
+			}
+			for _, context := range contexts {
+				next = append(next, step.apply(context)...)
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// returns the values the step selects from the context node, filtered by the predicates
+func (s xpathStep) apply(context *xmlNode) []xpathValue {
+	var values []xpathValue
+	switch {
+	case s.test == "text()":
+		if text := strings.TrimSpace(context.text); text != "" {
+			values = append(values, xpathValue{value: text})
+		}
+	case strings.HasPrefix(s.test, "@"):
+		if value, ok := context.attrs[localName(s.test[1:])]; ok {
+			values = append(values, xpathValue{value: value})
+		}
+	default:
+		for _, child := range context.children {
+			if s.test == "*" || child.name == localName(s.test) {
+				values = append(values, xpathValue{node: child})
+			}
+		}
+	}
+
+	for _, predicate := range s.predicates {
+		var filtered []xpathValue
+		for i, value := range values {
+			if predicate.position > 0 && i+1 == predicate.position {
+				filtered = append(filtered, value)
+			}
+			if predicate.condition != nil && value.node != nil && predicate.condition.matchNode(value.node) {
+				filtered = append(filtered, value)
+			}
+		}
+		values = filtered
+	}
+
+	return values
+}
+
+// returns the string value of the selected element (all text inside it) or the attribute/text
+func (v xpathValue) String() string {
+	if v.node == nil {
+		return v.value
+	}
+
+	var builder strings.Builder
+	var collect func(*xmlNode)
+	collect = func(node *xmlNode) {
+		builder.WriteString(node.text)
+		for _, child := range node.children {
+			collect(child)
+		}
+	}
+	collect(v.node)
+
+	return strings.TrimSpace(builder.String())
+}
+
+func (n *xmlNode) descendantsOrSelf() []*xmlNode {
+	nodes := []*xmlNode{n}
+	for _, child := range n.children {
+		nodes = append(nodes, child.descendantsOrSelf()...)
+	}
+
+	return nodes
+}
+
+// compares the selected value to the expected one, numerically if the expected value is a number
+func compareXPathValue(actual string, operator string, expected string) bool {
+	cmp := strings.Compare(actual, expected)
+	if b, err := strconv.ParseFloat(expected, 64); err == nil {
+		a, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+		if err != nil {
+			return operator == "!="
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// strips the namespace prefix of a name, e.g. "soap:Body" => "Body"
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+
+	return name
+}
+
+// parses the body into a tree of elements below a virtual root node (so that "/Envelope" selects the root element)
+func parseXMLDocument(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if len(root.children) == 0 {
+				return nil, fmt.Errorf("no XML document: %w", err)
+			}
+			return root, nil
+		}
+
+		current := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			current.children = append(current.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			current.text += string(t)
+		}
+	}
+}
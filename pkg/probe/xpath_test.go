@@ -0,0 +1,56 @@
+package probe
+
+import "testing"
+
+func TestXPathCondition(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <GetUsersResponse>
+      <user id="1" role="admin"><name>Alice</name><balance>1500</balance></user>
+      <user id="2" role="user"><name>Bob</name><balance>20</balance></user>
+    </GetUsersResponse>
+  </soap:Body>
+</soap:Envelope>`)
+
+	tests := map[string]bool{
+		`/Envelope/Body`: true,
+		`/soap:Envelope/soap:Body/GetUsersResponse`: true,
+		`/Body`:                                false,
+		`//user[@role='admin']/name = 'Alice'`: true,
+		`//user[@role='admin']/name = 'Bob'`:   false,
+		`//user[name='Bob']/@id = '2'`:         true,
+		`//user[2]/name/text() = "Bob"`:        true,
+		`//user[3]`:                            false,
+		`//user/@role != 'admin'`:              true,
+		`//balance > 1000`:                     true,
+		`//balance > 2000`:                     false,
+		`//user[@id='1'][@role='user']`:        false,
+		`//*[@role]`:                           true,
+		`//faultcode`:                          false,
+		`//user[balance < 100]/name = 'Bob'`:   true,
+	}
+
+	for expression, expected := range tests {
+		condition, err := ParseXPathCondition(expression)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", expression, err)
+		}
+		if actual := condition.Match(body); actual != expected {
+			t.Errorf("Expected %v for %s but got %v", expected, expression, actual)
+		}
+	}
+
+	condition, _ := ParseXPathCondition(`//role`)
+	if condition.Match([]byte(`{"role": "admin"}`)) {
+		t.Errorf("Expected a body that isn't XML not to match")
+	}
+}
+
+func TestParseXPathCondition_Invalid(t *testing.T) {
+	for _, expression := range []string{`user/name = 'Alice'`, `//user = Alice`, `//user[0]`, `//user[@id='1'`, `//user//`} {
+		if _, err := ParseXPathCondition(expression); err == nil {
+			t.Errorf("Expected an error for %s", expression)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// serves the net/http/pprof endpoints (under /debug/pprof/) on the address while the scan is running, so that e.g.
+// the memory usage of a huge scan can be profiled via `go tool pprof http://localhost:6060/debug/pprof/heap`. Close
+// the returned listener to stop serving. An address without a host (e.g. ":6060") is served on the loopback interface
+// only, as the profiles reveal internals of the scan. /debug/pprof/cmdline isn't served at all, as the command line
+// contains the session headers and tokens
+func startPprof(addr string) (net.Listener, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// a mux of its own instead of the default one, which the pprof package registers itself on
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		// fails once the listener is closed at the end of the scan
+		_ = http.Serve(listener, mux)
+	}()
+
+	return listener, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStartPprof(t *testing.T) {
+	listener, err := startPprof("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start pprof: %v", err)
+	}
+	defer listener.Close()
+
+	tests := map[string]string{
+		"/debug/pprof/":             "heap",
+		"/debug/pprof/heap?debug=1": "heap profile",
+	}
+
+	for path, expected := range tests {
+		resp, err := http.Get("http://" + listener.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), expected) {
+			t.Errorf("Expected status 200 and %q for %s but got %d: %.100s", expected, path, resp.StatusCode, body)
+		}
+	}
+
+	// the command line contains the secrets of the scan
+	resp, err := http.Get("http://" + listener.Addr().String() + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatalf("Failed to request the command line: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the command line but got %d", resp.StatusCode)
+	}
+
+	// without a host, only the loopback interface is listened on
+	loopback, err := startPprof(":0")
+	if err != nil {
+		t.Fatalf("Failed to start pprof: %v", err)
+	}
+	defer loopback.Close()
+	if host, _, _ := net.SplitHostPort(loopback.Addr().String()); host != "127.0.0.1" {
+		t.Errorf("Expected the loopback address but got %s", loopback.Addr())
+	}
+
+	if _, err := startPprof(listener.Addr().String()); err == nil {
+		t.Errorf("Expected an error for an address that's already in use")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// timeout per step (DNS, TCP and TLS) of the pre-flight check
+const preflightTimeout = 5 * time.Second
+
+// the outcome of the pre-flight check of a single host
+type preflightResult struct {
+	// e.g. "https://example.com:443"
+	Host string
+	IPs  []string
+	// the step that failed ("DNS", "TCP" or "TLS"), or "" if the host is reachable
+	FailedStep string
+	Err        error
+	// a TLS certificate that isn't trusted (the host is reachable nonetheless, e.g. with --skip-verification)
+	CertErr  error
+	Duration time.Duration
+}
+
+func newPreflightCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check that the hosts of the URLs file are reachable",
+		Long: `Resolves every unique host of the URLs file (--urls) and connects to it via TCP (and TLS for https URLs), so that
+unreachable hosts are found before the real scan wastes time on them. Exits with 1 if a host is unreachable.`,
+		Example: `./sessionprobe preflight -u ./urls.txt`,
+		Args:    cobra.NoArgs,
+		Run:     runPreflight,
+	}
+	cmd.Flags().StringVarP(&urls, "urls", "u", "", "file containing the URLs to be checked (required)")
+	cmd.Flags().IntVarP(&threads, "threads", "t", 10, "number of threads")
+	return cmd
+}
+
+func runPreflight(cmd *cobra.Command, args []string) {
+	if urls == "" {
+		Error("Please provide a URLs file using the '-urls <path_to_urls_file>' argument.")
+		return
+	}
+
+	data, err := os.ReadFile(urls)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	hosts := preflightHosts(strings.Split(string(data), "\n"))
+	Info("Checking %d unique hosts", len(hosts))
+
+	results := checkHosts(hosts, threads)
+	unreachable := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			unreachable++
+			color.Red("[UNREACHABLE] %s: %s failed: %s", result.Host, result.FailedStep, result.Err)
+		case result.CertErr != nil:
+			color.Yellow("[REACHABLE] %s (%s) in %s, but the certificate isn't trusted: %s", result.Host, strings.Join(result.IPs, ", "), result.Duration.Round(time.Millisecond), result.CertErr)
+		default:
+			fmt.Printf("[REACHABLE] %s (%s) in %s\n", result.Host, strings.Join(result.IPs, ", "), result.Duration.Round(time.Millisecond))
+		}
+	}
+
+	if unreachable > 0 {
+		Error("%d of %d hosts are unreachable", unreachable, len(results))
+		os.Exit(1)
+	}
+	Info("All %d hosts are reachable", len(results))
+}
+
+// returns the unique hosts of the URLs as "scheme://host:port", sorted
+func preflightHosts(lines []string) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, line := range lines {
+		parsed, err := neturl.Parse(strings.TrimSpace(line))
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+
+		port := parsed.Port()
+		if port == "" {
+			port = "80"
+			if parsed.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		host := parsed.Scheme + "://" + net.JoinHostPort(parsed.Hostname(), port)
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// checks the hosts concurrently and returns the results in the order of the hosts
+func checkHosts(hosts []string, workers int) []preflightResult {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	results := make([]preflightResult, len(hosts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = checkHost(hosts[index])
+			}
+		}()
+	}
+
+	for i := range hosts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// resolves the host, connects to it and, for https, does a TLS handshake
+func checkHost(host string) preflightResult {
+	result := preflightResult{Host: host}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	scheme, addr, _ := strings.Cut(host, "://")
+	hostname, port, _ := net.SplitHostPort(addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	if err != nil {
+		result.FailedStep, result.Err = "DNS", err
+		return result
+	}
+	result.IPs = ips
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ips[0], port), preflightTimeout)
+	if err != nil {
+		result.FailedStep, result.Err = "TCP", err
+		return result
+	}
+	defer conn.Close()
+
+	if scheme != "https" {
+		return result
+	}
+
+	conn.SetDeadline(time.Now().Add(preflightTimeout))
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: hostname, InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		result.FailedStep, result.Err = "TLS", err
+		return result
+	}
+
+	// the certificate is verified separately, since an untrusted certificate doesn't make the host unreachable
+	state := tlsConn.ConnectionState()
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{DNSName: hostname, Intermediates: intermediates}); err != nil {
+		result.CertErr = err
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflightHosts(t *testing.T) {
+	hosts := preflightHosts([]string{
+		"https://example.com/a",
+		"https://example.com:443/b",
+		"http://example.com/c",
+		"http://127.0.0.1:8080/d",
+		"not a url",
+		"",
+	})
+
+	expected := "http://127.0.0.1:8080,http://example.com:80,https://example.com:443"
+	if strings.Join(hosts, ",") != expected {
+		t.Errorf("Expected %s but got %v", expected, hosts)
+	}
+}
+
+func TestCheckHosts(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer secure.Close()
+
+	// a port that nothing listens on
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := "http://" + listener.Addr().String()
+	listener.Close()
+
+	results := checkHosts([]string{plain.URL, secure.URL, closed, "http://doesnotexist.invalid:80"}, 2)
+
+	if results[0].Err != nil {
+		t.Errorf("Expected %s to be reachable but got %v", plain.URL, results[0].Err)
+	}
+	// the self-signed certificate of the test server isn't trusted, but the host is reachable
+	if results[1].Err != nil || results[1].CertErr == nil {
+		t.Errorf("Expected %s to be reachable with an untrusted certificate but got %v / %v", secure.URL, results[1].Err, results[1].CertErr)
+	}
+	if results[2].FailedStep != "TCP" {
+		t.Errorf("Expected the TCP connect to %s to fail but got %q (%v)", closed, results[2].FailedStep, results[2].Err)
+	}
+	if results[3].FailedStep != "DNS" {
+		t.Errorf("Expected the DNS lookup to fail but got %q (%v)", results[3].FailedStep, results[3].Err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// collects the responses of the requests that are sent multiple times via `--repeat`
+var repeats *repeatSet
+
+type repeatSet struct {
+	mu      sync.Mutex
+	results map[string][]probe.Result
+}
+
+func newRepeatSet() *repeatSet {
+	return &repeatSet{results: make(map[string][]probe.Result)}
+}
+
+// records a response and reports whether it's the first one of its request, i.e. the one that goes into the output
+func (r *repeatSet) add(result probe.Result) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := result.Method + " " + result.URL
+	r.results[key] = append(r.results[key], result)
+
+	return len(r.results[key]) == 1
+}
+
+// the aggregated responses of a repeated request
+type repeatSummary struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Requests     int         `json:"requests"`
+	Errors       int         `json:"errors"`
+	StatusCounts map[int]int `json:"status_counts"`
+	MinLength    int         `json:"min_length"`
+	MaxLength    int         `json:"max_length"`
+	// whether all requests succeeded with the same status code and length
+	Consistent bool    `json:"consistent"`
+	MinLatency float64 `json:"min_latency_ms"`
+	AvgLatency float64 `json:"avg_latency_ms"`
+	MaxLatency float64 `json:"max_latency_ms"`
+}
+
+// aggregates the responses per request, with the inconsistent ones first
+func (r *repeatSet) summaries() []repeatSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var summaries []repeatSummary
+	for _, results := range r.results {
+		summaries = append(summaries, summarizeRepeats(results))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Consistent != b.Consistent {
+			return !a.Consistent
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Method < b.Method
+	})
+
+	return summaries
+}
+
+func summarizeRepeats(results []probe.Result) repeatSummary {
+	summary := repeatSummary{
+		Method:       results[0].Method,
+		URL:          results[0].URL,
+		Requests:     len(results),
+		StatusCounts: make(map[int]int),
+		MinLength:    -1,
+	}
+
+	var total, min, max time.Duration
+	var responses int
+	for _, result := range results {
+		if result.Err != nil {
+			summary.Errors++
+			continue
+		}
+
+		summary.StatusCounts[result.StatusCode]++
+		if summary.MinLength < 0 || result.Length < summary.MinLength {
+			summary.MinLength = result.Length
+		}
+		if result.Length > summary.MaxLength {
+			summary.MaxLength = result.Length
+		}
+
+		if responses == 0 || result.Duration < min {
+			min = result.Duration
+		}
+		if result.Duration > max {
+			max = result.Duration
+		}
+		total += result.Duration
+		responses++
+	}
+
+	summary.Consistent = summary.Errors == 0 && len(summary.StatusCounts) == 1 && summary.MinLength == summary.MaxLength
+	if responses > 0 {
+		summary.MinLatency = milliseconds(min)
+		summary.AvgLatency = milliseconds(total / time.Duration(responses))
+		summary.MaxLatency = milliseconds(max)
+	}
+
+	return summary
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// formats the status code counts as e.g. "200 (9x), 500 (1x)"
+func (s repeatSummary) formatStatusCounts() string {
+	var codes []int
+	for code := range s.StatusCounts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var counts []string
+	for _, code := range codes {
+		counts = append(counts, fmt.Sprintf("%d (%dx)", code, s.StatusCounts[code]))
+	}
+
+	return strings.Join(counts, ", ")
+}
+
+func writeRepeatSummaries(writer *bufio.Writer, summaries []repeatSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Repeated Requests\n\n")
+	for _, s := range summaries {
+		consistency := "consistent"
+		if !s.Consistent {
+			consistency = "INCONSISTENT"
+		}
+
+		length := formatLength(s.MinLength)
+		if s.MaxLength != s.MinLength {
+			length += "-" + formatLength(s.MaxLength)
+		}
+
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s => %s, %d requests, Errors: %d, Status: %s, Length: %s, Latency: min %.0fms, avg %.0fms, max %.0fms\n",
+			s.Method, s.URL, consistency, s.Requests, s.Errors, s.formatStatusCounts(), length, s.MinLatency, s.AvgLatency, s.MaxLatency))
+	}
+	_, _ = writer.WriteString("\n")
+}
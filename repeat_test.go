@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestRepeatSet(t *testing.T) {
+	set := newRepeatSet()
+
+	flaky := []probe.Result{
+		{Method: "GET", URL: "https://example.com/flaky", StatusCode: 200, Length: 10, Duration: 10 * time.Millisecond},
+		{Method: "GET", URL: "https://example.com/flaky", StatusCode: 500, Length: 3, Duration: 30 * time.Millisecond},
+		{Method: "GET", URL: "https://example.com/flaky", Err: errors.New("timeout")},
+	}
+	stable := []probe.Result{
+		{Method: "GET", URL: "https://example.com/a", StatusCode: 200, Length: 5, Duration: 20 * time.Millisecond},
+		{Method: "GET", URL: "https://example.com/a", StatusCode: 200, Length: 5, Duration: 40 * time.Millisecond},
+	}
+
+	for i, result := range append(flaky, stable...) {
+		first := set.add(result)
+		if expected := i == 0 || i == len(flaky); first != expected {
+			t.Errorf("Expected first=%v for result %d but got %v", expected, i, first)
+		}
+	}
+
+	summaries := set.summaries()
+	if len(summaries) != 2 || summaries[0].URL != "https://example.com/flaky" {
+		t.Fatalf("Expected the inconsistent request first but got %+v", summaries)
+	}
+
+	flakySummary, stableSummary := summaries[0], summaries[1]
+	if flakySummary.Consistent || flakySummary.Errors != 1 || flakySummary.formatStatusCounts() != "200 (1x), 500 (1x)" || flakySummary.MinLength != 3 || flakySummary.MaxLength != 10 {
+		t.Errorf("Unexpected summary of the flaky request: %+v", flakySummary)
+	}
+	if !stableSummary.Consistent || stableSummary.MinLatency != 20 || stableSummary.AvgLatency != 30 || stableSummary.MaxLatency != 40 {
+		t.Errorf("Unexpected summary of the stable request: %+v", stableSummary)
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writeRepeatSummaries(writer, summaries)
+	writer.Flush()
+
+	expected := "| GET | https://example.com/flaky => INCONSISTENT, 3 requests, Errors: 1, Status: 200 (1x), 500 (1x), Length: 3-10, Latency: min 10ms, avg 20ms, max 30ms"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected the output to contain %q but got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <results.json>",
+		Short: "Send the requests of a previous scan again",
+		Long: `Checks the URLs of a result file written via --out-json (including the failed requests) again, with the
+methods of that scan and the flags given now, e.g. with the session of another user or after a fix. As with a
+normal scan, every URL is checked with every method.`,
+		Example: `./sessionprobe replay ./results.json -H "Cookie: session=<other-user>" --out-json ./replayed.json
+./sessionprobe diff ./results.json ./replayed.json`,
+		Args: cobra.ExactArgs(1),
+		Run:  runReplay,
+	}
+	addScanFlags(cmd.Flags())
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	if retryFile != "" {
+		Error("replay can't be combined with --retry-file")
+		return
+	}
+
+	report, err := readJSONFile(args[0])
+	if err != nil {
+		Error("Failed to read %s: %s", args[0], err)
+		return
+	}
+
+	replayURLs, methods := replayRequests(report)
+	if len(replayURLs) == 0 {
+		Error("%s doesn't contain any requests", args[0])
+		return
+	}
+
+	for _, method := range methods {
+		if !enableMethod(method) {
+			Warn("Requests with the method %s can't be replayed and are skipped", method)
+		}
+	}
+
+	// the scan reads the URLs from a file, so they're written to a temporary one
+	file, err := os.CreateTemp("", "sessionprobe-replay-*.txt")
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	defer os.Remove(file.Name())
+
+	_, err = fmt.Fprintln(file, strings.Join(replayURLs, "\n"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	Info("Replaying %d URLs from %s", len(replayURLs), args[0])
+	urls = file.Name()
+	run(cmd, nil)
+}
+
+// returns the URLs (in their original form for internationalized domain names) and the methods of the results and
+// failed requests of a scan, in the order of their first occurrence
+func replayRequests(report *jsonReport) ([]string, []string) {
+	var replayURLs, methods []string
+	seenURLs := make(map[string]bool)
+	seenMethods := make(map[string]bool)
+
+	add := func(method string, url string) {
+		if !seenURLs[url] {
+			seenURLs[url] = true
+			replayURLs = append(replayURLs, url)
+		}
+		if !seenMethods[method] {
+			seenMethods[method] = true
+			methods = append(methods, method)
+		}
+	}
+
+	for _, result := range report.Results {
+		url := result.URL
+		if result.IDNURL != "" {
+			url = result.IDNURL
+		}
+		add(result.Method, url)
+	}
+	for _, failed := range report.Errors {
+		add(failed.Method, failed.URL)
+	}
+
+	return replayURLs, methods
+}
+
+// enables the `--check-*` flag of the method. Returns false if there's none (GET is always checked)
+func enableMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET":
+	case "POST":
+		methodPOST = true
+	case "PUT":
+		methodPUT = true
+	case "PATCH":
+		methodPATCH = true
+	case "DELETE":
+		methodDELETE = true
+	default:
+		return false
+	}
+
+	return true
+}
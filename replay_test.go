@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplayRequests(t *testing.T) {
+	report := &jsonReport{
+		Results: []jsonResult{
+			{Method: "GET", URL: "https://example.com/admin"},
+			{Method: "POST", URL: "https://example.com/admin"},
+			{Method: "GET", URL: "https://xn--mnchen-3ya.de/", IDNURL: "https://münchen.de/"},
+		},
+		Errors: []failedRequest{{Method: "OPTIONS", URL: "https://example.com/slow"}},
+	}
+
+	urls, methods := replayRequests(report)
+
+	expectedURLs := []string{"https://example.com/admin", "https://münchen.de/", "https://example.com/slow"}
+	if !reflect.DeepEqual(urls, expectedURLs) {
+		t.Errorf("Expected the URLs %v but got %v", expectedURLs, urls)
+	}
+	expectedMethods := []string{"GET", "POST", "OPTIONS"}
+	if !reflect.DeepEqual(methods, expectedMethods) {
+		t.Errorf("Expected the methods %v but got %v", expectedMethods, methods)
+	}
+}
+
+func TestEnableMethod(t *testing.T) {
+	defer func() {
+		methodPOST, methodDELETE = false, false
+	}()
+
+	tests := map[string]bool{
+		"GET":     true,
+		"post":    true,
+		"DELETE":  true,
+		"OPTIONS": false,
+	}
+
+	for method, expected := range tests {
+		if actual := enableMethod(method); actual != expected {
+			t.Errorf("Expected %v for %s but got %v", expected, method, actual)
+		}
+	}
+
+	if !methodPOST || !methodDELETE || methodPUT || methodPATCH {
+		t.Errorf("Expected only POST and DELETE to be enabled")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"sessionprobe/pkg/probe"
+
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report <results.json>",
+		Short: "Render the reports of a previous scan from its JSON results",
+		Long: `Renders the output file (--out, in the --output-encoding) and, if they are given, the --out-html and
+--export-defectdojo reports from a result file written via --out-json, without sending any requests. The
+--notes file and the --group-by setting are applied, so that e.g. triage notes added after the scan end up in
+the reports.`,
+		Example: `./sessionprobe report ./results.json --notes ./notes.txt --out ./report.txt --out-html ./report.html`,
+		Args:    cobra.ExactArgs(1),
+		Run:     runReport,
+	}
+	addReportFlags(cmd.Flags())
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	report, err := readJSONFile(args[0])
+	if err != nil {
+		Error("Failed to read %s: %s", args[0], err)
+		return
+	}
+
+	if !isValidGroupBy(groupBy) {
+		Error("Invalid group-by: %s", groupBy)
+		return
+	}
+
+	encoding, err := parseOutputEncoding(outputEncArg)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+
+	if err := loadNotesFile(); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	urlStatuses := restoreReport(report)
+
+	outFile, err := os.Create(out)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	defer outFile.Close()
+
+	outWriter, err := encoding.writer(outFile)
+	if err != nil {
+		Error("%s", err)
+		return
+	}
+	writeToFile(urlStatuses, report.Stats, outWriter)
+	Info("Wrote the report of %d results to %s", len(report.Results), out)
+
+	if outHTML != "" {
+		if err := writeHTMLFile(urlStatuses, report.Stats, outHTML); err != nil {
+			Error("Failed to write HTML output: %s", err)
+		}
+	}
+
+	if exportDefectDojo != "" {
+		if exported, err := writeDefectDojoFile(urlStatuses, exportDefectDojo); err != nil {
+			Error("Failed to export the findings to DefectDojo: %s", err)
+		} else {
+			Info("Exported %d findings to %s", exported, exportDefectDojo)
+		}
+	}
+}
+
+// turns the results of a JSON output file back into results by status code, and restores the findings the reports
+// are rendered from. What isn't part of the JSON file (e.g. the response bodies or latencies) stays empty
+func restoreReport(report *jsonReport) map[int][]probe.Result {
+	urlStatuses := make(map[int][]probe.Result)
+	for _, r := range report.Results {
+		result := probe.Result{
+			Method:        r.Method,
+			URL:           r.URL,
+			StatusCode:    r.StatusCode,
+			Header:        http.Header(r.Headers),
+			Length:        r.Length,
+			Truncated:     r.Truncated,
+			Matched:       true,
+			Labels:        r.Labels,
+			Severity:      r.Severity,
+			Snippet:       r.Snippet,
+			CorrelationID: r.CorrelationID,
+		}
+		for _, secret := range r.Secrets {
+			result.Secrets = append(result.Secrets, probe.Secret{Kind: secret.Kind, Value: secret.Value})
+		}
+		if r.IDNURL != "" {
+			idnURLs[r.URL] = r.IDNURL
+		}
+
+		urlStatuses[r.StatusCode] = append(urlStatuses[r.StatusCode], result)
+	}
+
+	scanTags = report.Tags
+	idorCandidates = report.IDOR
+	graphqlResults = report.GraphQL
+	headerAudits = report.HeaderAudits
+	corsFindings = report.CORS
+	bypassFindings = report.Bypasses
+	failedRequests = report.Errors
+
+	return urlStatuses
+}
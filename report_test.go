@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRestoreReport(t *testing.T) {
+	defer func() {
+		scanTags = nil
+		failedRequests = nil
+		delete(idnURLs, "https://xn--mnchen-3ya.de/")
+	}()
+
+	report := &jsonReport{
+		Tags: map[string]string{"engagement": "acme"},
+		Results: []jsonResult{
+			{Method: "GET", URL: "https://example.com/admin", StatusCode: 200, Length: 12, Labels: []string{"admin"}, Secrets: []jsonSecret{{Kind: "jwt", Value: "eyJ"}}},
+			{Method: "POST", URL: "https://example.com/admin", StatusCode: 403, Headers: map[string][]string{"Server": {"nginx"}}},
+			{Method: "GET", URL: "https://xn--mnchen-3ya.de/", StatusCode: 200, IDNURL: "https://münchen.de/"},
+		},
+		Errors: []failedRequest{{Method: "GET", URL: "https://example.com/slow", Kind: "timeout"}},
+	}
+
+	urlStatuses := restoreReport(report)
+
+	if len(urlStatuses[200]) != 2 || len(urlStatuses[403]) != 1 {
+		t.Fatalf("Expected 2 results with 200 and 1 with 403 but got %v", urlStatuses)
+	}
+
+	admin := urlStatuses[200][0]
+	if !admin.Matched || admin.Length != 12 || len(admin.Labels) != 1 || len(admin.Secrets) != 1 || admin.Secrets[0].Kind != "jwt" {
+		t.Errorf("Expected the result to be restored with its length, labels and secrets but got %+v", admin)
+	}
+	if server := urlStatuses[403][0].Header.Get("Server"); server != "nginx" {
+		t.Errorf("Expected the Server header to be restored but got %q", server)
+	}
+	if idn := idnURLs["https://xn--mnchen-3ya.de/"]; idn != "https://münchen.de/" {
+		t.Errorf("Expected the IDN form of the URL to be restored but got %q", idn)
+	}
+	if scanTags["engagement"] != "acme" || len(failedRequests) != 1 {
+		t.Errorf("Expected the tags and failed requests to be restored but got %v and %v", scanTags, failedRequests)
+	}
+}
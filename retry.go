@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the label of results that were sent because another method returned 405 with an Allow header
+const allowRetryLabel = "405-retry"
+
+// returns the methods of the Allow header of a 405 response that weren't checked yet. HEAD, OPTIONS, TRACE and
+// CONNECT aren't worth retrying, and DELETE is only retried with `--allow-dangerous`
+func allowedRetryMethods(result probe.Result, checked []string) []string {
+	if result.StatusCode != http.StatusMethodNotAllowed || result.Header == nil {
+		return nil
+	}
+
+	skip := map[string]bool{http.MethodHead: true, http.MethodOptions: true, http.MethodTrace: true, http.MethodConnect: true}
+	if !allowDangerous {
+		skip[http.MethodDelete] = true
+	}
+	for _, method := range checked {
+		skip[method] = true
+	}
+
+	var methods []string
+	for _, value := range result.Header.Values("Allow") {
+		for _, method := range strings.Split(value, ",") {
+			method = strings.ToUpper(strings.TrimSpace(method))
+			if method != "" && !skip[method] {
+				methods = append(methods, method)
+				skip[method] = true
+			}
+		}
+	}
+
+	return methods
+}
+
+// retries a 405 response with the methods hinted by its Allow header (even if they weren't enabled via the --check-*
+// flags) and returns the results, labeled with the method that returned 405
+func retryAllowedMethods(scanner *probe.Scanner, result probe.Result, checked []string) []probe.Result {
+	var results []probe.Result
+	for _, method := range allowedRetryMethods(result, checked) {
+		retry := scanner.Do(context.Background(), probe.Request{Method: method, URL: result.URL})
+		retry.Labels = append(retry.Labels, allowRetryLabel+" ("+result.Method+")")
+		results = append(results, retry)
+
+		if retry.Err == nil && retry.StatusCode < 400 {
+			Info("%s %s returned 405, but %s succeeded with %d", result.Method, result.URL, method, retry.StatusCode)
+		}
+	}
+
+	return results
+}
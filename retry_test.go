@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestAllowedRetryMethods(t *testing.T) {
+	result := probe.Result{
+		Method:     "GET",
+		StatusCode: http.StatusMethodNotAllowed,
+		Header:     http.Header{"Allow": {"OPTIONS, post, PUT", "DELETE, HEAD, POST"}},
+	}
+
+	if actual := allowedRetryMethods(result, []string{"GET", "PUT"}); fmt.Sprint(actual) != "[POST]" {
+		t.Errorf("Expected [POST] but got %v", actual)
+	}
+
+	allowDangerous = true
+	defer func() {
+		allowDangerous = false
+	}()
+	if actual := allowedRetryMethods(result, []string{"GET"}); fmt.Sprint(actual) != "[POST PUT DELETE]" {
+		t.Errorf("Expected [POST PUT DELETE] but got %v", actual)
+	}
+
+	result.StatusCode = http.StatusOK
+	if actual := allowedRetryMethods(result, nil); actual != nil {
+		t.Errorf("Expected no retries for a 200 but got %v", actual)
+	}
+}
+
+func TestRetryAllowedMethods(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer ts.Close()
+
+	scanner, err := probe.NewScanner(probe.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create scanner: %v", err)
+	}
+
+	result := scanner.Do(context.Background(), probe.Request{Method: "GET", URL: ts.URL})
+	retries := retryAllowedMethods(scanner, result, []string{"GET"})
+	if len(retries) != 1 || retries[0].Method != "POST" || retries[0].StatusCode != http.StatusOK || retries[0].Labels[0] != "405-retry (GET)" {
+		t.Errorf("Expected a successful POST retry but got %+v", retries)
+	}
+}
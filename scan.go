@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// set by `sessionprobe validate`, which stops the scan right before the first request
+var validateOnly bool
+
+func newScanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Check the URLs (the same as running sessionprobe without a subcommand)",
+		Long: `Checks every URL of the --urls file with the configured session and methods and writes the responses to the
+output files. This is what sessionprobe does without a subcommand, which is kept for existing scripts.`,
+		Example: `./sessionprobe scan -u ./urls.txt -H "Cookie: session=<cookie>" --out-json ./results.json`,
+		Args:    cobra.NoArgs,
+		Run:     run,
+	}
+	addScanFlags(cmd.Flags())
+	return cmd
+}
+
+func newValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the flags and input files without sending any requests",
+		Long: `Checks the flags of a scan and reads all of its input files (URLs, headers, cookies, notes, expectations,
+...) the same way the scan does, but stops before the first request. Use it e.g. in CI before a long scan.
+It doesn't open any connections (e.g. to the --proxy) and doesn't create the output directory or files, but
+secret:// references and the credentials store are resolved, which may run pass or secret-tool.`,
+		Example: `./sessionprobe validate -u ./urls.txt --headers-file ./headers.txt --expect ./expected.txt`,
+		Args:    cobra.NoArgs,
+		Run:     runValidate,
+	}
+	addScanFlags(cmd.Flags())
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	validateOnly = true
+	defer func() { validateOnly = false }()
+
+	run(cmd, args)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the number of runs that are kept per schedule if no retention is provided
+const defaultRetention = 10
+
+// the JSON body of `POST /schedules`
+type scheduleRequest struct {
+	Name string `json:"name"`
+	// cron expression, e.g. "0 2 * * *" or "@every 6h"
+	Schedule string `json:"schedule"`
+	// the number of runs (and their results) that are kept
+	Retention int         `json:"retention,omitempty"`
+	Scan      scanRequest `json:"scan"`
+}
+
+// a single run of a schedule
+type scheduledRun struct {
+	ScanID  string    `json:"scan_id"`
+	Started time.Time `json:"started"`
+	Status  string    `json:"status"`
+	// the differences to the previous run (see `sessionprobe diff`)
+	Changes []string `json:"changes"`
+}
+
+// a recurring scan
+type scanSchedule struct {
+	mu        sync.Mutex
+	id        string
+	name      string
+	expr      string
+	cron      *cronSchedule
+	retention int
+	req       scanRequest
+	next      time.Time
+	runs      []*scheduledRun
+	stop      chan struct{}
+}
+
+// handles `/schedules`
+func (m *scanManager) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		m.mu.Lock()
+		var schedules []*scanSchedule
+		for _, schedule := range m.schedules {
+			schedules = append(schedules, schedule)
+		}
+		m.mu.Unlock()
+
+		sort.Slice(schedules, func(i, j int) bool {
+			return schedules[i].id < schedules[j].id
+		})
+
+		var out []map[string]interface{}
+		for _, schedule := range schedules {
+			out = append(out, schedule.snapshot(false))
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		if !requireJSON(w, r) {
+			return
+		}
+
+		var req scheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+			return
+		}
+
+		schedule, err := m.addSchedule(req)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, schedule.snapshot(true))
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handles `/schedules/<id>`
+func (m *scanManager) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/schedules/")
+
+	m.mu.Lock()
+	schedule, ok := m.schedules[id]
+	if ok && r.Method == http.MethodDelete {
+		delete(m.schedules, id)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, schedule.snapshot(true))
+	case http.MethodDelete:
+		close(schedule.stop)
+		writeJSON(w, http.StatusOK, schedule.snapshot(false))
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validates the request and starts the scheduler of the new schedule in the background
+func (m *scanManager) addSchedule(req scheduleRequest) (*scanSchedule, error) {
+	cron, err := parseCron(req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	// e.g. "0 0 30 2 *" is valid, but February 30th never comes
+	next := cron.next(time.Now())
+	if next.IsZero() {
+		return nil, fmt.Errorf("the schedule %q never fires", req.Schedule)
+	}
+
+	if len(dedupeList(req.Scan.URLs)) == 0 {
+		return nil, fmt.Errorf("no URLs provided")
+	}
+	if _, err := compileOptionalRegex(req.Scan.FilterRegex); err != nil {
+		return nil, fmt.Errorf("invalid filter_regex: %w", err)
+	}
+
+	if req.Retention <= 0 {
+		req.Retention = defaultRetention
+	}
+
+	m.mu.Lock()
+	m.nextScheduleID++
+	schedule := &scanSchedule{
+		id:        fmt.Sprintf("%d", m.nextScheduleID),
+		name:      req.Name,
+		expr:      req.Schedule,
+		cron:      cron,
+		retention: req.Retention,
+		req:       req.Scan,
+		next:      next,
+		stop:      make(chan struct{}),
+	}
+	m.schedules[schedule.id] = schedule
+	m.mu.Unlock()
+
+	go m.runSchedule(schedule)
+
+	return schedule, nil
+}
+
+// starts a scan every time the schedule fires, until the schedule is deleted
+func (m *scanManager) runSchedule(schedule *scanSchedule) {
+	for {
+		schedule.mu.Lock()
+		next := schedule.next
+		schedule.mu.Unlock()
+
+		// without a next run, the timer would fire immediately and the scans would run back-to-back
+		if next.IsZero() {
+			Warn("The schedule %s (%s) doesn't fire anymore and is stopped", schedule.id, schedule.expr)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-schedule.stop:
+			timer.Stop()
+			return
+		}
+
+		m.runScheduledScan(schedule)
+
+		schedule.mu.Lock()
+		schedule.next = schedule.cron.next(time.Now())
+		schedule.mu.Unlock()
+	}
+}
+
+// runs a single scan of the schedule, diffs it against the previous run and applies the retention
+func (m *scanManager) runScheduledScan(schedule *scanSchedule) {
+	job, err := m.start(schedule.req)
+	if err != nil {
+		Error("Failed to start scheduled scan %s: %s", schedule.name, err)
+		return
+	}
+	Info("Started scan %s of schedule %s", job.id, schedule.name)
+
+	select {
+	case <-job.done:
+	case <-schedule.stop:
+		job.cancel()
+		<-job.done
+	}
+
+	job.mu.Lock()
+	run := &scheduledRun{ScanID: job.id, Started: job.created, Status: job.status, Changes: []string{}}
+	results := job.results
+	job.mu.Unlock()
+
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+
+	// diff against the previous run, if it still exists
+	if len(schedule.runs) > 0 {
+		m.mu.Lock()
+		previous, ok := m.scans[schedule.runs[len(schedule.runs)-1].ScanID]
+		m.mu.Unlock()
+
+		if ok {
+			previous.mu.Lock()
+			previousResults := previous.results
+			previous.mu.Unlock()
+
+			for _, d := range diffResults(previousResults, results) {
+				run.Changes = append(run.Changes, d.String())
+			}
+		}
+	}
+
+	schedule.runs = append(schedule.runs, run)
+
+	// drop the oldest runs (and their scans) beyond the retention
+	for len(schedule.runs) > schedule.retention {
+		m.mu.Lock()
+		delete(m.scans, schedule.runs[0].ScanID)
+		m.mu.Unlock()
+
+		schedule.runs = schedule.runs[1:]
+	}
+}
+
+// returns a consistent copy of the schedule's public fields, optionally including its runs
+func (s *scanSchedule) snapshot(withRuns bool) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]interface{}{
+		"id":        s.id,
+		"name":      s.name,
+		"schedule":  s.expr,
+		"retention": s.retention,
+		"next_run":  s.next,
+		"runs":      len(s.runs),
+	}
+	if withRuns {
+		out["runs"] = s.runs
+	}
+
+	return out
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScheduledScans(t *testing.T) {
+	var fixed atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fixed.Load() {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	m := newScanManager()
+	schedule, err := m.addSchedule(scheduleRequest{
+		Name:      "nightly",
+		Schedule:  "@every 1h",
+		Retention: 2,
+		Scan:      scanRequest{URLs: []string{target.URL + "/admin"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add schedule: %v", err)
+	}
+	defer close(schedule.stop)
+
+	m.runScheduledScan(schedule)
+	fixed.Store(true)
+	m.runScheduledScan(schedule)
+	m.runScheduledScan(schedule)
+
+	if len(schedule.runs) != 2 {
+		t.Fatalf("Expected 2 retained runs but got %d", len(schedule.runs))
+	}
+	if len(m.scans) != 2 {
+		t.Errorf("Expected the scan of the dropped run to be deleted, but %d scans are left", len(m.scans))
+	}
+
+	// the first retained run is the one after the fix, which differs from the (dropped) first run
+	if changes := schedule.runs[0].Changes; len(changes) != 1 || !strings.Contains(changes[0], diffStatusChanged) {
+		t.Errorf("Expected a status change but got %v", changes)
+	}
+	if changes := schedule.runs[1].Changes; len(changes) != 0 {
+		t.Errorf("Expected no changes between identical runs but got %v", changes)
+	}
+}
+
+func TestAddSchedule_Invalid(t *testing.T) {
+	m := newScanManager()
+
+	if _, err := m.addSchedule(scheduleRequest{Schedule: "not cron", Scan: scanRequest{URLs: []string{"http://example.com"}}}); err == nil {
+		t.Errorf("Expected an error for an invalid cron expression")
+	}
+	if _, err := m.addSchedule(scheduleRequest{Schedule: "0 0 30 2 *", Scan: scanRequest{URLs: []string{"http://example.com"}}}); err == nil {
+		t.Errorf("Expected an error for a cron expression that never fires")
+	}
+	if _, err := m.addSchedule(scheduleRequest{Schedule: "@daily"}); err == nil {
+		t.Errorf("Expected an error for a schedule without URLs")
+	}
+}
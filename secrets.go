@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+
+	"sessionprobe/pkg/probe"
+)
+
+// writes the sensitive data found via `--scan-secrets` per URL. Nothing is written if no secrets were found
+func writeSecrets(writer *bufio.Writer, urlStatuses map[int][]probe.Result) {
+	var results []probe.Result
+	for _, statusResults := range urlStatuses {
+		for _, result := range statusResults {
+			if len(result.Secrets) > 0 {
+				results = append(results, result)
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		return
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].URL != results[j].URL {
+			return results[i].URL < results[j].URL
+		}
+		return results[i].Method < results[j].Method
+	})
+
+	_, _ = writer.WriteString("Sensitive Data\n\n")
+	for _, result := range results {
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s\n", result.Method, result.URL))
+		for _, secret := range result.Secrets {
+			_, _ = writer.WriteString(fmt.Sprintf("    %s: %s\n", secret.Kind, secret.Value))
+		}
+	}
+	_, _ = writer.WriteString("\n")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// references to secrets in header values, e.g. "Authorization: Bearer secret://env/API_TOKEN". A reference ends at
+// the first character that can't be part of a name, so that e.g. the ";" between cookies isn't part of it
+var secretURIRegex = regexp.MustCompile(`secret://[A-Za-z0-9_.\-/]+`)
+
+// runs an external command (e.g. `pass`) and returns its output. It's a variable, so that the tests can replace it
+var runSecretCommand = func(name string, args ...string) ([]byte, error) {
+	output, err := exec.Command(name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("%s failed: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return output, err
+}
+
+// resolves a single secret reference:
+//
+//	secret://env/NAME                 the environment variable NAME
+//	secret://pass/path/to/entry       the first line of the `pass` entry
+//	secret://keychain/service/account the OS keychain (macOS Keychain or the Secret Service on Linux)
+func resolveSecretURI(uri string) (string, error) {
+	backend, path, ok := strings.Cut(strings.TrimPrefix(uri, "secret://"), "/")
+	if !ok || path == "" {
+		return "", fmt.Errorf("invalid secret reference: %s", uri)
+	}
+
+	switch backend {
+	case "env":
+		value, ok := os.LookupEnv(path)
+		if !ok {
+			return "", fmt.Errorf("the environment variable %s of %s isn't set", path, uri)
+		}
+		return value, nil
+	case "pass":
+		output, err := runSecretCommand("pass", "show", path)
+		if err != nil {
+			return "", err
+		}
+		value, _, _ := strings.Cut(string(output), "\n")
+		return strings.TrimRight(value, "\r"), nil
+	case "keychain":
+		service, account, ok := strings.Cut(path, "/")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("invalid keychain reference (expected secret://keychain/<service>/<account>): %s", uri)
+		}
+		return readKeychain(service, account)
+	default:
+		return "", fmt.Errorf("unsupported secret backend %s in %s (supported: env, pass, keychain)", backend, uri)
+	}
+}
+
+// reads a password from the OS keychain
+func readKeychain(service string, account string) (string, error) {
+	var output []byte
+	var err error
+
+	switch runtime.GOOS {
+	case "darwin":
+		output, err = runSecretCommand("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		output, err = runSecretCommand("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", fmt.Errorf("the keychain isn't supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// replaces all secret references in the header values with the secrets
+func resolveHeaderSecrets(headers map[string][]string) error {
+	for name, values := range headers {
+		for i, value := range values {
+			var resolveErr error
+			values[i] = secretURIRegex.ReplaceAllStringFunc(value, func(uri string) string {
+				secret, err := resolveSecretURI(uri)
+				if err != nil && resolveErr == nil {
+					resolveErr = fmt.Errorf("failed to resolve the secret of the %s header: %w", name, err)
+				}
+				return secret
+			})
+			if resolveErr != nil {
+				return resolveErr
+			}
+		}
+	}
+
+	return nil
+}
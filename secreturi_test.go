@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResolveHeaderSecrets(t *testing.T) {
+	t.Setenv("SESSIONPROBE_TEST_TOKEN", "env-token")
+
+	original := runSecretCommand
+	defer func() {
+		runSecretCommand = original
+	}()
+	runSecretCommand = func(name string, args ...string) ([]byte, error) {
+		if name == "pass" && strings.Join(args, " ") == "show apps/session" {
+			return []byte("pass-session\nuser: admin\n"), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s %v", name, args)
+	}
+
+	headers := map[string][]string{
+		"Authorization": {"Bearer secret://env/SESSIONPROBE_TEST_TOKEN"},
+		"Cookie":        {"session=secret://pass/apps/session", "static=1"},
+	}
+	if err := resolveHeaderSecrets(headers); err != nil {
+		t.Fatalf("Failed to resolve the secrets: %v", err)
+	}
+
+	if headers["Authorization"][0] != "Bearer env-token" {
+		t.Errorf("Expected the token from the environment but got %s", headers["Authorization"][0])
+	}
+	if strings.Join(headers["Cookie"], "; ") != "session=pass-session; static=1" {
+		t.Errorf("Expected the session from pass but got %v", headers["Cookie"])
+	}
+}
+
+func TestResolveHeaderSecrets_MultipleCookies(t *testing.T) {
+	t.Setenv("SESSIONPROBE_TEST_A", "first")
+	t.Setenv("SESSIONPROBE_TEST_B", "second")
+
+	headers := map[string][]string{
+		"Cookie": {"a=secret://env/SESSIONPROBE_TEST_A; b=secret://env/SESSIONPROBE_TEST_B;c=1"},
+	}
+	if err := resolveHeaderSecrets(headers); err != nil {
+		t.Fatalf("Failed to resolve the secrets: %v", err)
+	}
+
+	if headers["Cookie"][0] != "a=first; b=second;c=1" {
+		t.Errorf("Expected both cookies to be resolved but got %s", headers["Cookie"][0])
+	}
+}
+
+func TestResolveSecretURIErrors(t *testing.T) {
+	for _, uri := range []string{
+		"secret://env/SESSIONPROBE_TEST_UNSET",
+		"secret://vault/path",
+		"secret://env",
+		"secret://keychain/service-only",
+	} {
+		if _, err := resolveSecretURI(uri); err == nil {
+			t.Errorf("Expected an error for %s", uri)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionprobe/pkg/probe"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenAddr string
+	apiToken   string
+)
+
+// the web UI dashboard served on `/`
+//
+//go:embed web/index.html
+var dashboardHTML []byte
+
+const (
+	scanRunning   = "running"
+	scanCompleted = "completed"
+	scanCancelled = "cancelled"
+)
+
+// the JSON body of `POST /scans`
+type scanRequest struct {
+	URLs          []string            `json:"urls"`
+	Methods       []string            `json:"methods,omitempty"`
+	Headers       map[string][]string `json:"headers,omitempty"`
+	Threads       int                 `json:"threads,omitempty"`
+	Proxy         string              `json:"proxy,omitempty"`
+	SkipVerify    bool                `json:"skip_verification,omitempty"`
+	FilterRegex   string              `json:"filter_regex,omitempty"`
+	FilterLengths []int               `json:"filter_lengths,omitempty"`
+}
+
+// a scan submitted via the API
+type scanJob struct {
+	mu        sync.Mutex
+	id        string
+	status    string
+	created   time.Time
+	total     int
+	processed int
+	stats     *scanStats
+	results   []jsonResult
+	cancel    context.CancelFunc
+	// closed once the scan is done
+	done chan struct{}
+	// the requests that failed, so that a coordinator can list them in its output
+	failed []failedRequest
+}
+
+// keeps track of all scans submitted via the API
+type scanManager struct {
+	mu             sync.Mutex
+	scans          map[string]*scanJob
+	nextID         int
+	schedules      map[string]*scanSchedule
+	nextScheduleID int
+}
+
+func newScanManager() *scanManager {
+	return &scanManager{scans: make(map[string]*scanJob), schedules: make(map[string]*scanSchedule)}
+}
+
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run SessionProbe as a daemon with a REST API",
+		Long: `Runs SessionProbe as a daemon that exposes a REST API to submit scans, query their progress and fetch their results:
+
+  POST   /scans              submit a scan, e.g. {"urls": ["https://example.com/admin"], "headers": {"Cookie": ["session=..."]}}
+  GET    /scans              list all scans
+  GET    /scans/<id>         get the status and progress of a scan
+  GET    /scans/<id>/results get the results of a scan
+  GET    /scans/<id>/errors  get the failed requests of a scan (e.g. timeouts)
+  DELETE /scans/<id>         cancel a running scan, or remove a finished one along with its results
+
+  POST   /schedules          create a recurring scan, e.g. {"name": "nightly", "schedule": "0 2 * * *", "scan": {"urls": [...]}}
+  GET    /schedules          list all schedules
+  GET    /schedules/<id>     get a schedule with its past runs and their changes compared to the previous run
+  DELETE /schedules/<id>     delete a schedule
+
+The request bodies have to be sent with "Content-Type: application/json". A web UI dashboard showing the scans, their
+progress and a filterable results table is served on "/".`,
+		Example: `./sessionprobe serve --listen 127.0.0.1:8080 --api-token <token>`,
+		Run:     runServe,
+	}
+
+	cmd.Flags().StringVar(&listenAddr, "listen", "127.0.0.1:8080", "address the API listens on")
+	cmd.Flags().StringVar(&apiToken, "api-token", "", "require this token as \"Authorization: Bearer <token>\" header for all API requests")
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	printIntro()
+
+	if apiToken == "" {
+		Warn("No --api-token provided, so everyone who can reach %s can start scans", listenAddr)
+	}
+
+	Info("Listening on %s (open http://%s/ in a browser for the dashboard)", listenAddr, listenAddr)
+	if err := http.ListenAndServe(listenAddr, newScanManager().handler(apiToken)); err != nil {
+		Error("%s", err)
+	}
+}
+
+// returns the HTTP handler of the API and the web UI. The web UI itself is served without authentication, since it
+// only contains static content and sends the token along with its API requests
+func (m *scanManager) handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/scans", requireToken(token, http.HandlerFunc(m.handleScans)))
+	mux.Handle("/scans/", requireToken(token, http.HandlerFunc(m.handleScan)))
+	mux.Handle("/schedules", requireToken(token, http.HandlerFunc(m.handleSchedules)))
+	mux.Handle("/schedules/", requireToken(token, http.HandlerFunc(m.handleSchedule)))
+	mux.HandleFunc("/", handleDashboard)
+
+	return mux
+}
+
+// wraps the handler so that it requires the API token (if set)
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "invalid or missing API token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rejects request bodies that aren't JSON. Browsers send JSON to another origin only after a CORS preflight, which
+// the API doesn't allow, so a website opened by the operator can't submit scans via a simple cross-origin POST (e.g.
+// with "Content-Type: text/plain") to a daemon without an API token
+func requireJSON(w http.ResponseWriter, r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "the request body has to be sent with \"Content-Type: application/json\"")
+		return false
+	}
+
+	return true
+}
+
+func handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(dashboardHTML)
+}
+
+// handles `/scans`
+func (m *scanManager) handleScans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		m.mu.Lock()
+		var jobs []*scanJob
+		for _, job := range m.scans {
+			jobs = append(jobs, job)
+		}
+		m.mu.Unlock()
+
+		sort.Slice(jobs, func(i, j int) bool {
+			return jobs[i].created.Before(jobs[j].created)
+		})
+
+		var out []map[string]interface{}
+		for _, job := range jobs {
+			out = append(out, job.snapshot())
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		if !requireJSON(w, r) {
+			return
+		}
+
+		var req scanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+			return
+		}
+
+		job, err := m.start(req)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, job.snapshot())
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handles `/scans/<id>`, `/scans/<id>/results` and `/scans/<id>/errors`
+func (m *scanManager) handleScan(w http.ResponseWriter, r *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/scans/"), "/")
+
+	m.mu.Lock()
+	job, ok := m.scans[id]
+	m.mu.Unlock()
+
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, job.snapshot())
+	case sub == "" && r.Method == http.MethodDelete:
+		// a running scan is cancelled, a finished one is removed, so that a long-running daemon doesn't keep the
+		// results of every scan forever
+		select {
+		case <-job.done:
+			m.mu.Lock()
+			delete(m.scans, id)
+			m.mu.Unlock()
+		default:
+			job.cancel()
+		}
+		writeJSON(w, http.StatusOK, job.snapshot())
+	case sub == "results" && r.Method == http.MethodGet:
+		job.mu.Lock()
+		results := append([]jsonResult{}, job.results...)
+		job.mu.Unlock()
+		writeJSON(w, http.StatusOK, results)
+	case sub == "errors" && r.Method == http.MethodGet:
+		job.mu.Lock()
+		failed := append([]failedRequest{}, job.failed...)
+		job.mu.Unlock()
+		writeJSON(w, http.StatusOK, failed)
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// validates the request and starts the scan in the background
+func (m *scanManager) start(req scanRequest) (*scanJob, error) {
+	urls := dedupeList(req.URLs)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no URLs provided")
+	}
+
+	compiledRegex, err := compileOptionalRegex(req.FilterRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter_regex: %w", err)
+	}
+
+	excludedLengths := make(map[int]bool)
+	for _, length := range req.FilterLengths {
+		excludedLengths[length] = true
+	}
+
+	opts := probe.Options{
+		URLs:             urls,
+		Methods:          req.Methods,
+		Headers:          req.Headers,
+		Threads:          req.Threads,
+		Proxy:            req.Proxy,
+		SkipVerification: req.SkipVerify,
+		FilterRegex:      compiledRegex,
+		ExcludedLengths:  excludedLengths,
+	}
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := len(req.Methods)
+	if methods == 0 {
+		methods = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.mu.Lock()
+	m.nextID++
+	job := &scanJob{
+		id:      fmt.Sprintf("%d", m.nextID),
+		status:  scanRunning,
+		created: time.Now(),
+		total:   len(urls) * methods,
+		stats:   newScanStats(len(urls)),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	m.scans[job.id] = job
+	m.mu.Unlock()
+
+	go job.run(ctx, scanner)
+
+	return job, nil
+}
+
+func (j *scanJob) run(ctx context.Context, scanner *probe.Scanner) {
+	defer close(j.done)
+
+	for result := range scanner.Run(ctx) {
+		j.mu.Lock()
+		j.processed++
+		j.stats.add(result)
+		if result.Err != nil {
+			j.failed = append(j.failed, newFailedRequest(result))
+		} else if result.Matched {
+			j.results = append(j.results, newJSONResult(result))
+		}
+		j.mu.Unlock()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stats.finish()
+	if ctx.Err() != nil {
+		j.status = scanCancelled
+	} else {
+		j.status = scanCompleted
+	}
+	j.cancel()
+}
+
+// returns a consistent copy of the job's public fields
+func (j *scanJob) snapshot() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out := map[string]interface{}{
+		"id":                 j.id,
+		"status":             j.status,
+		"created":            j.created,
+		"total_requests":     j.total,
+		"processed_requests": j.processed,
+		"results":            len(j.results),
+	}
+	if j.status != scanRunning {
+		out["stats"] = j.stats
+	}
+
+	return out
+}
+
+// removes duplicate and empty entries while keeping the order
+func dedupeList(values []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		out = append(out, value)
+	}
+
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
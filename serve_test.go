@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeAPI(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer target.Close()
+
+	api := httptest.NewServer(newScanManager().handler("secret"))
+	defer api.Close()
+
+	do := func(method string, path string, body string) (int, []byte) {
+		req, _ := http.NewRequest(method, api.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("API request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var raw json.RawMessage
+		json.NewDecoder(resp.Body).Decode(&raw)
+		return resp.StatusCode, raw
+	}
+
+	// requests without the token are rejected
+	resp, _ := http.Get(api.URL + "/scans")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token but got %d", resp.StatusCode)
+	}
+
+	// the dashboard is served without the token
+	resp, _ = http.Get(api.URL + "/")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected the dashboard with status 200 but got %d (%s)", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	status, body := do("POST", "/scans", `{"urls": ["`+target.URL+`/a", "`+target.URL+`/b", "`+target.URL+`/a"]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status 201 but got %d: %s", status, body)
+	}
+
+	var job map[string]interface{}
+	json.Unmarshal(body, &job)
+	id := job["id"].(string)
+
+	// wait for the scan to complete
+	for i := 0; i < 50 && job["status"] != scanCompleted; i++ {
+		time.Sleep(50 * time.Millisecond)
+		_, body = do("GET", "/scans/"+id, "")
+		json.Unmarshal(body, &job)
+	}
+	if job["status"] != scanCompleted || job["processed_requests"] != float64(2) {
+		t.Fatalf("Expected a completed scan with 2 requests but got %v", job)
+	}
+
+	_, body = do("GET", "/scans/"+id+"/results", "")
+	var results []jsonResult
+	json.Unmarshal(body, &results)
+	if len(results) != 2 || results[0].StatusCode != 200 {
+		t.Errorf("Expected 2 results with status 200 but got %+v", results)
+	}
+
+	if status, _ := do("GET", "/scans/does-not-exist", ""); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown scan but got %d", status)
+	}
+
+	if status, _ := do("POST", "/scans", `{"urls": []}`); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a scan without URLs but got %d", status)
+	}
+
+	// bodies that a website could send cross-origin without a preflight are rejected
+	req, _ := http.NewRequest("POST", api.URL+"/scans", strings.NewReader(`{"urls": ["`+target.URL+`"]}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "text/plain")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415 for a text/plain body but got %v (err: %v)", resp, err)
+	}
+
+	// deleting a finished scan removes it
+	if status, _ := do("DELETE", "/scans/"+id, ""); status != http.StatusOK {
+		t.Errorf("Expected status 200 for deleting the scan but got %d", status)
+	}
+	if status, _ := do("GET", "/scans/"+id, ""); status != http.StatusNotFound {
+		t.Errorf("Expected the deleted scan to be gone but got %d", status)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// names of cookies that look like they hold a session or an authentication token
+var sessionCookieRegex = regexp.MustCompile(`(?i)sess|sid|auth|token|jwt|login|remember|identity|\.aspxauth`)
+
+// the label prefix of results whose response issued a session cookie
+const sessionCookieLabel = "session-cookie:"
+
+// the session cookies that were issued to unauthenticated requests
+var sessionCookieFindings = &sessionCookieSet{}
+
+type sessionCookieSet struct {
+	mu       sync.Mutex
+	findings []sessionCookieFinding
+}
+
+// a response to an unauthenticated request that issued a fresh session cookie
+type sessionCookieFinding struct {
+	Method     string   `json:"method"`
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code"`
+	Cookies    []string `json:"cookies"`
+}
+
+// returns the names of the session cookies the response sets. Cookies that are deleted (e.g. on logout) are ignored
+func sessionCookies(header http.Header) []string {
+	var names []string
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		if cookie.Value == "" || cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+			continue
+		}
+		if sessionCookieRegex.MatchString(cookie.Name) {
+			names = append(names, cookie.Name)
+		}
+	}
+
+	return names
+}
+
+// reports if the headers carry no credentials, i.e. neither cookies nor an Authorization header
+func isUnauthenticated(headers map[string][]string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, "Cookie") || strings.EqualFold(key, "Authorization") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// returns a ResponseHook that labels results whose response issues a session cookie. If the requests are
+// unauthenticated, these results are also recorded as findings, since handing out an authenticated-looking cookie
+// without credentials is a strong signal of broken session handling
+func (s *sessionCookieSet) hook(unauthenticated bool) probe.ResponseHook {
+	return probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		names := sessionCookies(result.Header)
+		if len(names) == 0 {
+			return nil
+		}
+
+		for _, name := range names {
+			result.Labels = append(result.Labels, sessionCookieLabel+name)
+		}
+
+		if unauthenticated {
+			s.mu.Lock()
+			s.findings = append(s.findings, sessionCookieFinding{Method: result.Method, URL: result.URL, StatusCode: result.StatusCode, Cookies: names})
+			s.mu.Unlock()
+
+			Warn("Unauthenticated request to %s %s was issued the session cookie(s) %s", result.Method, result.URL, strings.Join(names, ", "))
+		}
+
+		return nil
+	})
+}
+
+// returns the findings sorted by URL and method
+func (s *sessionCookieSet) sorted() []sessionCookieFinding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	findings := append([]sessionCookieFinding{}, s.findings...)
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].URL != findings[j].URL {
+			return findings[i].URL < findings[j].URL
+		}
+		return findings[i].Method < findings[j].Method
+	})
+
+	return findings
+}
+
+// writes the session cookies issued to unauthenticated requests. Nothing is written if there are none
+func writeSessionCookieFindings(writer *bufio.Writer, findings []sessionCookieFinding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	_, _ = writer.WriteString("Session Cookies Issued to Unauthenticated Requests\n\n")
+	for _, f := range findings {
+		_, _ = writer.WriteString(fmt.Sprintf("| %s | %s => Status: %d, Cookies: %s\n", f.Method, f.URL, f.StatusCode, strings.Join(f.Cookies, ", ")))
+	}
+	_, _ = writer.WriteString("\n")
+}
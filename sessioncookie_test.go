@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestSessionCookies(t *testing.T) {
+	header := http.Header{}
+	header.Add("Set-Cookie", "PHPSESSID=abc123; Path=/; HttpOnly")
+	header.Add("Set-Cookie", "theme=dark; Path=/")
+	header.Add("Set-Cookie", "auth_token=; Max-Age=0")
+	header.Add("Set-Cookie", "remember_me=1; Expires=Thu, 01 Jan 1970 00:00:00 GMT")
+
+	if actual := sessionCookies(header); fmt.Sprint(actual) != "[PHPSESSID]" {
+		t.Errorf("Expected [PHPSESSID] but got %v", actual)
+	}
+}
+
+func TestSessionCookieHook(t *testing.T) {
+	set := &sessionCookieSet{}
+	header := http.Header{"Set-Cookie": {"session=abc"}}
+
+	result := probe.Result{Method: "GET", URL: "https://example.com/login", StatusCode: 200, Header: header}
+	if err := set.hook(false).AfterResponse(&result, nil); err != nil || len(set.sorted()) != 0 {
+		t.Errorf("Expected no finding for an authenticated request but got %v (err: %v)", set.sorted(), err)
+	}
+	if fmt.Sprint(result.Labels) != "[session-cookie:session]" {
+		t.Errorf("Expected the label session-cookie:session but got %v", result.Labels)
+	}
+
+	result = probe.Result{Method: "GET", URL: "https://example.com/login", StatusCode: 200, Header: header}
+	if err := set.hook(true).AfterResponse(&result, nil); err != nil || len(set.sorted()) != 1 {
+		t.Errorf("Expected a finding for an unauthenticated request but got %v (err: %v)", set.sorted(), err)
+	}
+}
+
+func TestIsUnauthenticated(t *testing.T) {
+	tests := map[string]bool{
+		"":                         true,
+		"X-Test: 1":                true,
+		"Cookie: a=1":              false,
+		"authorization: Bearer xy": false,
+	}
+
+	for headers, expected := range tests {
+		var headersMap map[string][]string
+		if headers != "" {
+			headersMap = parseHeaders(headers)
+		}
+
+		if actual := isUnauthenticated(headersMap); actual != expected {
+			t.Errorf("Expected %v for headers %q but got %v", expected, headers, actual)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// the slice of the URLs this instance checks with `--shard`, so that a scan can be split across several machines
+// without a coordinator
+var scanShard shard
+
+// the index-th of count shards (1-based). The zero value is a single shard with all URLs
+type shard struct {
+	index int
+	count int
+}
+
+// parses a shard such as "2/5"
+func parseShard(value string) (shard, error) {
+	if value == "" {
+		return shard{}, nil
+	}
+
+	index, count, found := strings.Cut(value, "/")
+	i, err1 := strconv.Atoi(strings.TrimSpace(index))
+	n, err2 := strconv.Atoi(strings.TrimSpace(count))
+	if !found || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return shard{}, fmt.Errorf("invalid shard: %s (expected \"<index>/<count>\" such as \"2/5\")", value)
+	}
+
+	return shard{index: i, count: n}, nil
+}
+
+// reports if the URL with the dedupe key belongs to the shard. The URLs are assigned by a hash of the key, so every
+// instance gets the same assignment (no matter the order of the URLs file) and duplicates end up in the same shard
+func (s shard) contains(key string) bool {
+	if s.count <= 1 {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+
+	return int(hash.Sum32()%uint32(s.count)) == s.index-1
+}
+
+func (s shard) String() string {
+	return fmt.Sprintf("%d/%d", s.index, s.count)
+}
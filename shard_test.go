@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	tests := map[string]bool{
+		"":     true,
+		"2/5":  true,
+		"1/1":  true,
+		"0/5":  false,
+		"6/5":  false,
+		"2":    false,
+		"a/b":  false,
+		"1/0":  false,
+		" 3/4": true,
+	}
+
+	for value, valid := range tests {
+		if _, err := parseShard(value); (err == nil) != valid {
+			t.Errorf("Expected %q to be valid: %v but got %v", value, valid, err)
+		}
+	}
+}
+
+func TestShardContains(t *testing.T) {
+	const count = 5
+
+	// every URL belongs to exactly one shard, and the shards are roughly equal in size
+	sizes := make([]int, count)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("https://example.com/item/%d", i)
+
+		shards := 0
+		for index := 1; index <= count; index++ {
+			if (shard{index: index, count: count}).contains(key) {
+				shards++
+				sizes[index-1]++
+			}
+		}
+		if shards != 1 {
+			t.Fatalf("Expected %s to belong to one shard but got %d", key, shards)
+		}
+	}
+
+	for index, size := range sizes {
+		if size < 150 || size > 250 {
+			t.Errorf("Expected about 200 URLs in shard %d but got %d", index+1, size)
+		}
+	}
+
+	if !(shard{}).contains("https://example.com/") {
+		t.Errorf("Expected the zero shard to contain all URLs")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the signer of `--sign`. It's a global, so that the requests of the second role (`--compare-*`) are signed as well
+var requestSigner *hmacSigner
+
+// the supported `--sign` algorithms
+var signAlgorithms = map[string]func() hash.Hash{
+	"hmac-sha1":   sha1.New,
+	"hmac-sha256": sha256.New,
+	"hmac-sha512": sha512.New,
+}
+
+// the parts of a request that can be signed via `--sign-input`. Any other part is taken as the name of a header
+var signInputParts = map[string]func(req *http.Request) (string, error){
+	"method": func(req *http.Request) (string, error) { return req.Method, nil },
+	"path":   func(req *http.Request) (string, error) { return req.URL.EscapedPath(), nil },
+	"query":  func(req *http.Request) (string, error) { return req.URL.RawQuery, nil },
+	"host":   func(req *http.Request) (string, error) { return req.Host, nil },
+	"url":    func(req *http.Request) (string, error) { return req.URL.String(), nil },
+	"date":   func(req *http.Request) (string, error) { return req.Header.Get("Date"), nil },
+	"body":   requestBody,
+}
+
+// signs every request with an HMAC over selected parts of it (e.g. "method+path+date"), which are joined by newlines
+type hmacSigner struct {
+	newHash  func() hash.Hash
+	key      []byte
+	header   string
+	parts    []string
+	encoding string
+}
+
+func newHMACSigner(algorithm, key, header, input, encoding string) (*hmacSigner, error) {
+	newHash, ok := signAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s (supported: hmac-sha1, hmac-sha256, hmac-sha512)", algorithm)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("--sign requires a --sign-key")
+	}
+	if encoding != "hex" && encoding != "base64" {
+		return nil, fmt.Errorf("invalid signature encoding: %s (valid encodings: hex, base64)", encoding)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(input, "+") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("--sign-input doesn't contain any parts")
+	}
+
+	return &hmacSigner{newHash: newHash, key: []byte(key), header: header, parts: parts, encoding: encoding}, nil
+}
+
+// sets the signature header. If the date is signed but the request doesn't have a Date header, it's set to now
+func (s *hmacSigner) BeforeRequest(req *http.Request) error {
+	if containsString(s.parts, "date") && req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	var values []string
+	for _, part := range s.parts {
+		value := req.Header.Get(part)
+		if extract, ok := signInputParts[part]; ok {
+			var err error
+			if value, err = extract(req); err != nil {
+				return err
+			}
+		}
+		values = append(values, value)
+	}
+
+	mac := hmac.New(s.newHash, s.key)
+	mac.Write([]byte(strings.Join(values, "\n")))
+	signature := mac.Sum(nil)
+
+	if s.encoding == "base64" {
+		req.Header.Set(s.header, base64.StdEncoding.EncodeToString(signature))
+	} else {
+		req.Header.Set(s.header, hex.EncodeToString(signature))
+	}
+
+	return nil
+}
+
+// reads the body of a request without consuming it
+func requestBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	return string(data), err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestHMACSigner(t *testing.T) {
+	signer, err := newHMACSigner("HMAC-SHA256", "secret", "X-Signature", "method+path+date+body+x-api-key", "hex")
+	if err != nil {
+		t.Fatalf("Failed to create the signer: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "https://example.com/api/users?id=1", bytes.NewReader([]byte(`{"a":1}`)))
+	req.Header.Set("X-Api-Key", "key")
+	if err := signer.BeforeRequest(req); err != nil {
+		t.Fatalf("Failed to sign the request: %v", err)
+	}
+
+	date := req.Header.Get("Date")
+	if date == "" {
+		t.Fatalf("Expected the Date header to be set")
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/api/users\n" + date + "\n{\"a\":1}\nkey"))
+	if expected := hex.EncodeToString(mac.Sum(nil)); req.Header.Get("X-Signature") != expected {
+		t.Errorf("Expected the signature %s but got %s", expected, req.Header.Get("X-Signature"))
+	}
+}
+
+func TestNewHMACSignerErrors(t *testing.T) {
+	tests := []struct {
+		algorithm, key, input, encoding string
+	}{
+		{"md5", "secret", "method", "hex"},
+		{"hmac-sha256", "", "method", "hex"},
+		{"hmac-sha256", "secret", " + ", "hex"},
+		{"hmac-sha256", "secret", "method", "base32"},
+	}
+
+	for _, test := range tests {
+		if _, err := newHMACSigner(test.algorithm, test.key, "X-Signature", test.input, test.encoding); err == nil {
+			t.Errorf("Expected an error for %+v", test)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+// statistics of a scan, e.g. for the notifications
+type scanStats struct {
+	Command      string      `json:"command"`
+	Start        time.Time   `json:"start"`
+	End          time.Time   `json:"end"`
+	InputURLs    int         `json:"input_urls"`
+	URLs         int         `json:"urls"`
+	Requests     int         `json:"requests"`
+	Errors       int         `json:"errors"`
+	StatusCounts map[int]int `json:"status_counts"`
+	// the responses per status class (e.g. "2xx") and per method and status class
+	StatusClasses map[string]int            `json:"status_classes"`
+	Methods       map[string]map[string]int `json:"methods"`
+
+	durations []time.Duration
+	hosts     map[string]*hostStats
+	// the requests and errors per second of the scan, for the timeline chart of the HTML report
+	timeline []timelineBucket
+}
+
+type timelineBucket struct {
+	requests int
+	errors   int
+}
+
+// request statistics of a single host
+type hostStats struct {
+	requests  int
+	errors    int
+	durations []time.Duration
+}
+
+func newScanStats(urls int) *scanStats {
+	return &scanStats{
+		Command:       redactCommand(os.Args),
+		Start:         time.Now(),
+		URLs:          urls,
+		StatusCounts:  make(map[int]int),
+		StatusClasses: make(map[string]int),
+		Methods:       make(map[string]map[string]int),
+		hosts:         make(map[string]*hostStats),
+	}
+}
+
+// the flags whose values may contain secrets (e.g. session cookies, tokens or credentials in URLs), which are
+// redacted in the recorded command line since it ends up in the reports and notifications
+var secretFlags = map[string]bool{
+	"-H": true, "--headers": true, "--compare-headers": true, "--basic": true, "--bearer": true, "--sign-key": true,
+	"--es-url": true, "--es-auth": true, "--worker-token": true, "--api-token": true, "--proxy": true, "-p": true,
+	"--notify-webhook": true, "--notify-slack": true, "--notify-discord": true, "--notify-teams": true,
+}
+
+// joins the command line with the values of the secretFlags replaced by "REDACTED". Both "--flag value" and
+// "--flag=value" (as well as "-Hvalue") are recognized
+func redactCommand(args []string) string {
+	redacted := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case secretFlags[arg] && i+1 < len(args):
+			redacted = append(redacted, arg, "REDACTED")
+			i++
+		case hasValue && strings.HasPrefix(name, "--") && secretFlags[name]:
+			redacted = append(redacted, name+"=REDACTED")
+		case len(arg) > 2 && !strings.HasPrefix(arg, "--") && secretFlags[arg[:2]]:
+			redacted = append(redacted, arg[:2]+"REDACTED")
+		default:
+			redacted = append(redacted, arg)
+		}
+	}
+
+	return strings.Join(redacted, " ")
+}
+
+// records a single result. Results that didn't pass the filters are counted as well
+func (s *scanStats) add(result probe.Result) {
+	s.Requests++
+
+	host := result.URL
+	if parsed, err := neturl.Parse(result.URL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	if s.hosts[host] == nil {
+		s.hosts[host] = &hostStats{}
+	}
+	s.hosts[host].requests++
+
+	second := int(time.Since(s.Start) / time.Second)
+	for len(s.timeline) <= second {
+		s.timeline = append(s.timeline, timelineBucket{})
+	}
+	s.timeline[second].requests++
+
+	if result.Err != nil {
+		s.Errors++
+		s.hosts[host].errors++
+		s.timeline[second].errors++
+		return
+	}
+
+	s.StatusCounts[result.StatusCode]++
+	class := statusClass(result.StatusCode)
+	s.StatusClasses[class]++
+	if s.Methods[result.Method] == nil {
+		s.Methods[result.Method] = make(map[string]int)
+	}
+	s.Methods[result.Method][class]++
+	s.durations = append(s.durations, result.Duration)
+	s.hosts[host].durations = append(s.hosts[host].durations, result.Duration)
+}
+
+// adds the counts of another scan (e.g. of a worker) to these statistics. Latencies are not merged, since they are
+// not part of the JSON representation
+func (s *scanStats) merge(other *scanStats) {
+	if other == nil {
+		return
+	}
+
+	s.Requests += other.Requests
+	s.Errors += other.Errors
+	for code, count := range other.StatusCounts {
+		s.StatusCounts[code] += count
+	}
+	for class, count := range other.StatusClasses {
+		s.StatusClasses[class] += count
+	}
+	for method, classes := range other.Methods {
+		if s.Methods[method] == nil {
+			s.Methods[method] = make(map[string]int)
+		}
+		for class, count := range classes {
+			s.Methods[method][class] += count
+		}
+	}
+}
+
+// marks the scan as done
+func (s *scanStats) finish() {
+	s.End = time.Now()
+}
+
+func (s *scanStats) duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// writes the metadata of the scan (command line, times, URL counts, status code counts and errors)
+func (s *scanStats) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "Command: %s\n", s.Command)
+	if len(scanTags) > 0 {
+		fmt.Fprintf(w, "Tags: %s\n", strings.Join(tagList(scanTags), ", "))
+	}
+	fmt.Fprintf(w, "Start: %s\n", s.Start.Format(time.RFC3339))
+	fmt.Fprintf(w, "End: %s\n", s.End.Format(time.RFC3339))
+	fmt.Fprintf(w, "URLs: %d read, %d unique after filtering and deduplication\n", s.InputURLs, s.URLs)
+	fmt.Fprintf(w, "Requests: %d, Errors: %d\n", s.Requests, s.Errors)
+	fmt.Fprintf(w, "Status Codes: %s\n", s.formatStatusCounts())
+	s.writeClasses(w)
+}
+
+// returns the class of the status code, e.g. "4xx" for 403
+func statusClass(statusCode int) string {
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// writes the counts and percentages per status class, overall and per method, e.g.
+//
+//	Status Classes: 2xx: 12 (60.0%), 4xx: 8 (40.0%)
+//	  GET: 15 (75.0%) => 2xx: 10 (66.7%), 4xx: 5 (33.3%)
+func (s *scanStats) writeClasses(w io.Writer) {
+	fmt.Fprintf(w, "Status Classes: %s\n", formatClassCounts(s.StatusClasses))
+
+	total := 0
+	for _, count := range s.StatusClasses {
+		total += count
+	}
+
+	var methods []string
+	for method := range s.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		count := 0
+		for _, c := range s.Methods[method] {
+			count += c
+		}
+		fmt.Fprintf(w, "  %s: %d (%.1f%%) => %s\n", method, count, percentage(count, total), formatClassCounts(s.Methods[method]))
+	}
+}
+
+// formats the counts per status class as e.g. "2xx: 12 (60.0%), 4xx: 8 (40.0%)"
+func formatClassCounts(counts map[string]int) string {
+	var classes []string
+	total := 0
+	for class, count := range counts {
+		classes = append(classes, class)
+		total += count
+	}
+	sort.Strings(classes)
+
+	var parts []string
+	for _, class := range classes {
+		parts = append(parts, fmt.Sprintf("%s: %d (%.1f%%)", class, counts[class], percentage(counts[class], total)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func percentage(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) * 100 / float64(total)
+}
+
+// formats the status code counts as e.g. "200: 12, 302: 3"
+func (s *scanStats) formatStatusCounts() string {
+	var codes []int
+	for code := range s.StatusCounts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var counts []string
+	for _, code := range codes {
+		counts = append(counts, fmt.Sprintf("%d: %d", code, s.StatusCounts[code]))
+	}
+
+	return strings.Join(counts, ", ")
+}
+
+// average number of requests per second
+func (s *scanStats) throughput() float64 {
+	seconds := s.duration().Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+
+	return float64(s.Requests) / seconds
+}
+
+// writes the latency statistics (overall and per host) in a human-readable format
+func (s *scanStats) writeLatency(w io.Writer) {
+	p50, p90, p99 := latencyPercentiles(s.durations)
+	fmt.Fprintf(w, "Duration: %s, Requests: %d, Errors: %d, Throughput: %.2f req/s\n",
+		s.duration().Round(time.Millisecond), s.Requests, s.Errors, s.throughput())
+	fmt.Fprintf(w, "Latency: p50 %s, p90 %s, p99 %s\n", p50, p90, p99)
+
+	var hosts []string
+	for host := range s.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		h := s.hosts[host]
+		p50, p90, p99 := latencyPercentiles(h.durations)
+		fmt.Fprintf(w, "  %s: %d requests, %d errors, p50 %s, p90 %s, p99 %s\n", host, h.requests, h.errors, p50, p90, p99)
+	}
+}
+
+// returns the p50, p90 and p99 of the durations (nearest-rank method)
+func latencyPercentiles(durations []time.Duration) (time.Duration, time.Duration, time.Duration) {
+	return percentile(durations, 50), percentile(durations, 90), percentile(durations, 99)
+}
+
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	// nearest rank, i.e. the smallest value that is greater than or equal to p percent of all values
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1].Round(time.Millisecond)
+}
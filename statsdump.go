@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+const (
+	// the current throughput of a snapshot is calculated over this many most recent seconds
+	snapshotWindow = 10
+	// number of in-flight requests a snapshot lists
+	snapshotSlowest = 5
+)
+
+// returns a channel that receives whenever a snapshot of the statistics should be printed, i.e. on SIGQUIT and, if
+// the interval is positive, periodically. The returned function stops the notifications
+func statsSnapshots(interval time.Duration) (<-chan struct{}, func()) {
+	snapshots := make(chan struct{}, 1)
+	signals := make(chan os.Signal, 1)
+	// SIGQUIT (Ctrl+\) would otherwise make Go exit with a dump of all goroutines
+	signal.Notify(signals, syscall.SIGQUIT)
+
+	var ticks <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		ticks = ticker.C
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+			case <-ticks:
+			case <-done:
+				return
+			}
+
+			// a snapshot that's already pending covers this one as well
+			select {
+			case snapshots <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return snapshots, func() {
+		signal.Stop(signals)
+		if ticker != nil {
+			ticker.Stop()
+		}
+		close(done)
+	}
+}
+
+// waits for the next result and prints a snapshot of the statistics whenever one is requested in the meantime. Returns
+// false once the results channel is closed
+func nextResult(results <-chan probe.Result, snapshots <-chan struct{}, stats *scanStats, scanner *probe.Scanner) (probe.Result, bool) {
+	for {
+		select {
+		case result, ok := <-results:
+			return result, ok
+		case <-snapshots:
+			for _, line := range stats.snapshot(time.Now(), scanner.InFlight()) {
+				Info("%s", line)
+			}
+		}
+	}
+}
+
+// describes the state of a running scan: the throughput, the responses per status code, the errors and the requests
+// that have been waiting for a response the longest, so that a scan that seems stuck can be diagnosed
+func (s *scanStats) snapshot(now time.Time, inFlight []probe.InFlightRequest) []string {
+	elapsed := now.Sub(s.Start)
+
+	average := 0.0
+	if elapsed > 0 {
+		average = float64(s.Requests) / elapsed.Seconds()
+	}
+
+	// the requests of the last complete seconds. The timeline only has buckets up to the second of the last result,
+	// so the seconds since are empty
+	seconds := int(elapsed / time.Second)
+	window := min(snapshotWindow, seconds)
+	recent := 0
+	for second := seconds - window; second < seconds && second < len(s.timeline); second++ {
+		recent += s.timeline[second].requests
+	}
+	current := 0.0
+	if window > 0 {
+		current = float64(recent) / float64(window)
+	}
+
+	statusCounts := s.formatStatusCounts()
+	if statusCounts == "" {
+		statusCounts = "none"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Stats after %s: %d requests (%.2f req/s now, %.2f req/s on average), %d errors",
+			elapsed.Round(time.Second), s.Requests, current, average, s.Errors),
+		fmt.Sprintf("Stats: status codes %s", statusCounts),
+		fmt.Sprintf("Stats: %d requests in flight", len(inFlight)),
+	}
+
+	for i, request := range inFlight {
+		if i == snapshotSlowest {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("Stats: waiting %s for %s %s", now.Sub(request.Start).Round(time.Millisecond), request.Method, request.URL))
+	}
+
+	return lines
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestScanStatsSnapshot(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := &scanStats{
+		Start:        start,
+		Requests:     30,
+		Errors:       2,
+		StatusCounts: map[int]int{200: 20, 403: 8},
+		// 10 requests in the first second, 20 in the last 10
+		timeline: []timelineBucket{{requests: 10}, {}, {}, {}, {}, {}, {}, {}, {}, {}, {requests: 20}},
+	}
+	now := start.Add(15 * time.Second)
+
+	var inFlight []probe.InFlightRequest
+	for i := 0; i < 7; i++ {
+		inFlight = append(inFlight, probe.InFlightRequest{Method: "GET", URL: "https://example.com/slow", Start: now.Add(-time.Duration(10-i) * time.Second)})
+	}
+
+	lines := stats.snapshot(now, inFlight)
+
+	expected := []string{
+		"Stats after 15s: 30 requests (2.00 req/s now, 2.00 req/s on average), 2 errors",
+		"Stats: status codes 200: 20, 403: 8",
+		"Stats: 7 requests in flight",
+		"Stats: waiting 10s for GET https://example.com/slow",
+	}
+	for i, line := range expected {
+		if i >= len(lines) || lines[i] != line {
+			t.Errorf("Expected line %d to be %q but got %q", i, line, strings.Join(lines, "\n"))
+		}
+	}
+
+	// only the slowest requests are listed
+	if len(lines) != 3+snapshotSlowest {
+		t.Errorf("Expected %d lines but got %d", 3+snapshotSlowest, len(lines))
+	}
+}
+
+func TestNextResult_Snapshot(t *testing.T) {
+	results := make(chan probe.Result)
+	snapshots := make(chan struct{}, 1)
+	snapshots <- struct{}{}
+
+	scanner, err := probe.NewScanner(probe.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create scanner: %v", err)
+	}
+
+	// the result is only sent once the snapshot was taken
+	go func() {
+		for len(snapshots) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		results <- probe.Result{URL: "https://example.com"}
+		close(results)
+	}()
+
+	result, ok := nextResult(results, snapshots, newScanStats(1), scanner)
+	if !ok || result.URL != "https://example.com" {
+		t.Errorf("Expected the result to be returned after the snapshot but got %v (ok: %v)", result, ok)
+	}
+	if _, ok := nextResult(results, snapshots, newScanStats(1), scanner); ok {
+		t.Errorf("Expected false once the results channel is closed")
+	}
+}
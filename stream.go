@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+)
+
+// with `--stream`, the URLs are read from the file while they're being checked instead of being loaded up front, so
+// that lists with millions of URLs don't have to fit into memory. Like readURLs, the stream filters and deduplicates
+// the URLs, but only keeps hashes of the dedupe keys (or a bloom filter with `--dedupe bloom`). The memory isn't
+// bounded though: the matched results and the latencies are still collected for the reports
+type urlStream struct {
+	file *os.File
+	seen urlDeduper
+	// the number of lines read from the file and of URLs sent to the scanner. They are final once wait returns
+	inputCount int
+	urlCount   int
+	// the GraphQL endpoints among the URLs, which are probed after the scan
+	graphqlURLs map[string]bool
+	// closed once the reader has stopped, after which it doesn't touch the fields above (or idnURLs) anymore
+	done chan struct{}
+}
+
+func newURLStream(file *os.File) *urlStream {
+	return &urlStream{file: file, seen: newURLDeduper(true), graphqlURLs: make(map[string]bool), done: make(chan struct{})}
+}
+
+// starts reading the URLs, which are sent on the returned channel until the file ends or the context is cancelled
+func (s *urlStream) start(ctx context.Context) <-chan string {
+	urls := make(chan string)
+
+	go func() {
+		defer close(s.done)
+		defer close(urls)
+
+		scanner := bufio.NewScanner(s.file)
+		for scanner.Scan() {
+			s.inputCount++
+
+			url, key, ok := prepareURL(scanner.Text())
+			if !ok || s.seen.seen(key) {
+				continue
+			}
+			s.urlCount++
+			if isGraphQLEndpoint(url) {
+				s.graphqlURLs[url] = true
+			}
+
+			select {
+			case urls <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if scanner.Err() != nil {
+			Error("%s", scanner.Err())
+		}
+	}()
+
+	return urls
+}
+
+// waits until the reader started by start has stopped. Cancel its context first if the scan was stopped early, as the
+// reader would otherwise wait for the scanner to take the next URL
+func (s *urlStream) wait() {
+	<-s.done
+}
+
+// returns the flags that can't be combined with `--stream`, because they need all URLs before (or after) the scan
+func streamConflicts() []string {
+	var conflicts []string
+	if order != orderAsGiven {
+		conflicts = append(conflicts, "--order")
+	}
+	if repeatCount > 1 {
+		conflicts = append(conflicts, "--repeat")
+	}
+	if warmUp > 0 {
+		conflicts = append(conflicts, "--warm-up")
+	}
+	if workers != "" {
+		conflicts = append(conflicts, "--workers")
+	}
+	if compareUnauth || compareHeaders != "" {
+		conflicts = append(conflicts, "--compare-unauth/--compare-headers")
+	}
+	if idorParams != "" {
+		conflicts = append(conflicts, "--idor-params")
+	}
+	if checkCORSFlag {
+		conflicts = append(conflicts, "--check-cors")
+	}
+
+	return conflicts
+}
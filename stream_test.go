@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestURLStream(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-stream-urls.txt")
+	content := "https://example.com/a\n" +
+		"https://example.com/b\n" +
+		"https://example.com/a\n" +
+		"https://example.com/logout\n" +
+		"https://example.com/graphql\n" +
+		"https://example.com/b\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write URLs file: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open URLs file: %v", err)
+	}
+	defer file.Close()
+
+	stream := newURLStream(file)
+	var urls []string
+	for url := range stream.start(context.Background()) {
+		urls = append(urls, url)
+	}
+
+	expected := []string{"https://example.com/a", "https://example.com/b", "https://example.com/graphql"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected %v but got %v", expected, urls)
+	}
+	if stream.inputCount != 6 || stream.urlCount != 3 {
+		t.Errorf("Expected 6 input URLs and 3 checked ones but got %d and %d", stream.inputCount, stream.urlCount)
+	}
+	if !stream.graphqlURLs["https://example.com/graphql"] || len(stream.graphqlURLs) != 1 {
+		t.Errorf("Expected the GraphQL endpoint to be recorded but got %v", stream.graphqlURLs)
+	}
+
+	// a scan that stops early only takes the first URL, after which the reader has to stop as well
+	file.Seek(0, 0)
+	stream = newURLStream(file)
+	ctx, cancel := context.WithCancel(context.Background())
+	<-stream.start(ctx)
+	cancel()
+	stream.wait()
+	if stream.urlCount > 2 {
+		t.Errorf("Expected the reader to stop after the cancellation but it sent %d URLs", stream.urlCount)
+	}
+}
+
+func TestStreamConflicts(t *testing.T) {
+	order, checkCORSFlag = orderRandom, true
+	defer func() {
+		order, checkCORSFlag = orderAsGiven, false
+	}()
+
+	expected := []string{"--order", "--check-cors"}
+	if conflicts := streamConflicts(); !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected %v but got %v", expected, conflicts)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the metadata of the scan (`--tag`, e.g. engagement=acme), which is recorded in all structured outputs so that the
+// results of many scans can be told apart
+var scanTags map[string]string
+
+// parses tags in the format "key=value". A later tag with the same key replaces an earlier one
+func parseTags(args []string) (map[string]string, error) {
+	var tags map[string]string
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid tag %q (expected \"key=value\")", arg)
+		}
+
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+
+	return tags, nil
+}
+
+// returns the tags as "key=value", sorted by key
+func tagList(tags map[string]string) []string {
+	var list []string
+	for key, value := range tags {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+
+	return list
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]string{"engagement=acme", "env = staging", "ticket=SEC-1=2", "env=prod"})
+	if err != nil {
+		t.Fatalf("Failed to parse tags: %v", err)
+	}
+
+	expected := map[string]string{"engagement": "acme", "env": "prod", "ticket": "SEC-1=2"}
+	if fmt.Sprint(tags) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v but got %v", expected, tags)
+	}
+	if list := strings.Join(tagList(tags), ", "); list != "engagement=acme, env=prod, ticket=SEC-1=2" {
+		t.Errorf("Expected the sorted tags but got %s", list)
+	}
+
+	for _, arg := range []string{"acme", "=acme"} {
+		if _, err := parseTags([]string{arg}); err == nil {
+			t.Errorf("Expected an error for %q", arg)
+		}
+	}
+}
+
+func TestTagsInOutputs(t *testing.T) {
+	scanTags = map[string]string{"engagement": "acme"}
+	defer func() {
+		scanTags = nil
+	}()
+
+	if report := buildJSONReport(nil, nil); report.Tags["engagement"] != "acme" {
+		t.Errorf("Expected the tags in the JSON report but got %v", report.Tags)
+	}
+
+	junit := buildJUnitReport(nil, nil)
+	if len(junit.Suites) == 0 || len(junit.Suites[0].Properties) != 1 || junit.Suites[0].Properties[0].Value != "acme" {
+		t.Errorf("Expected the tags as JUnit properties but got %+v", junit.Suites)
+	}
+
+	var b strings.Builder
+	newScanStats(0).writeHeader(&b)
+	if !strings.Contains(b.String(), "Tags: engagement=acme\n") {
+		t.Errorf("Expected the tags in the header but got: %s", b.String())
+	}
+}
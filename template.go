@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"sessionprobe/pkg/probe"
+)
+
+// the functions available in `--output-template` files in addition to Go's builtin ones
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// quotes a value for CSV if it contains a comma, a quote or a line break
+	"csv": func(value interface{}) string {
+		s := fmt.Sprint(value)
+		if strings.ContainsAny(s, ",\"\r\n") {
+			return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+		}
+		return s
+	},
+}
+
+// loads an output template. It must define a "result" template, which is rendered for every result, and may define
+// a "header" and a "summary" template, which are rendered once before and after the results
+func loadOutputTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output template: %w", err)
+	}
+
+	if tmpl.Lookup("result") == nil {
+		return nil, fmt.Errorf("the output template doesn't define a \"result\" template")
+	}
+
+	return tmpl, nil
+}
+
+// renders the output template: the "header" and the "summary" get the whole report (e.g. {{.Stats.Requests}}), while
+// the "result" is rendered for every result (e.g. {{.Method}},{{.URL}},{{.StatusCode}})
+func writeTemplateOutput(tmpl *template.Template, urlStatuses map[int][]probe.Result, stats *scanStats, outFile io.Writer) error {
+	writer := bufio.NewWriter(outFile)
+	report := buildJSONReport(urlStatuses, stats)
+
+	if tmpl.Lookup("header") != nil {
+		if err := tmpl.ExecuteTemplate(writer, "header", report); err != nil {
+			return err
+		}
+	}
+
+	for _, result := range report.Results {
+		if err := tmpl.ExecuteTemplate(writer, "result", result); err != nil {
+			return err
+		}
+	}
+
+	if tmpl.Lookup("summary") != nil {
+		if err := tmpl.ExecuteTemplate(writer, "summary", report); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestWriteTemplateOutput(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	templatePath := filepath.Join(".", "testing", "test-output.tmpl")
+	content := `{{define "header"}}method,url,status,labels
+{{end}}
+{{- define "result"}}{{.Method}},{{csv .URL}},{{.StatusCode}},{{csv (join .Labels ",")}}
+{{end}}
+{{- define "summary"}}# {{len .Results}} results
+{{end}}`
+	if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	tmpl, err := loadOutputTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+
+	urlStatuses := map[int][]probe.Result{
+		200: {{Method: "GET", URL: "https://example.com/a,b", StatusCode: 200, Labels: []string{"admin", "panel"}}},
+		403: {{Method: "POST", URL: "https://example.com/c", StatusCode: 403}},
+	}
+
+	outputPath := filepath.Join(".", "testing", "test-output-template.csv")
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	if err := writeTemplateOutput(tmpl, urlStatuses, nil, outFile); err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	outFile.Close()
+
+	output, _ := os.ReadFile(outputPath)
+	expected := `method,url,status,labels
+GET,"https://example.com/a,b",200,"admin,panel"
+POST,https://example.com/c,403,
+# 2 results
+`
+	if string(output) != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, output)
+	}
+}
+
+func TestLoadOutputTemplateWithoutResult(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-output-invalid.tmpl")
+	if err := os.WriteFile(path, []byte(`{{define "summary"}}done{{end}}`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	if _, err := loadOutputTemplate(path); err == nil {
+		t.Errorf("Expected an error for a template without a \"result\" template")
+	}
+}
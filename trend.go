@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	trendNewlyExposed = "NEWLY EXPOSED"
+	trendNewlyFixed   = "NEWLY FIXED"
+)
+
+// the status history of a single method and URL across several scans
+type urlTrend struct {
+	Method string
+	URL    string
+	// the status code per scan (0 if the URL wasn't part of the scan)
+	Statuses []int
+}
+
+// compares the latest scan to the previous one: a URL is newly exposed if it returns a 2xx status code now but
+// didn't before, and newly fixed if it's the other way round. Empty if neither is the case
+func (t urlTrend) change() string {
+	if len(t.Statuses) < 2 {
+		return ""
+	}
+
+	previous, latest := t.Statuses[len(t.Statuses)-2], t.Statuses[len(t.Statuses)-1]
+	switch {
+	case isSuccess(latest) && !isSuccess(previous):
+		return trendNewlyExposed
+	case isSuccess(previous) && !isSuccess(latest) && latest != 0:
+		return trendNewlyFixed
+	default:
+		return ""
+	}
+}
+
+// reports if the status code changed between any of the scans
+func (t urlTrend) changed() bool {
+	for _, status := range t.Statuses[1:] {
+		if status != t.Statuses[0] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t urlTrend) String() string {
+	var history []string
+	for _, status := range t.Statuses {
+		if status == 0 {
+			history = append(history, "-")
+		} else {
+			history = append(history, fmt.Sprint(status))
+		}
+	}
+
+	line := fmt.Sprintf("%s %s: %s", t.Method, t.URL, strings.Join(history, " => "))
+	if change := t.change(); change != "" {
+		line = fmt.Sprintf("[%s] %s", change, line)
+	}
+
+	return line
+}
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode <= 299
+}
+
+func newTrendCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trend <scan.json> <scan.json>...",
+		Short: "Show the status history of the URLs across several JSON result files",
+		Long: `Shows the status code history of every URL whose status changed across several result files written via
+--out-json (e.g. of recurring scans of the same application), ordered by the start of the scans. URLs that became
+accessible in the latest scan are marked as newly exposed, ones that aren't accessible anymore as newly fixed. URLs
+marked as false-positive in the --notes file are left out.`,
+		Example: `./sessionprobe trend ./scans/*.json`,
+		Args:    cobra.MinimumNArgs(2),
+		Run:     runTrend,
+	}
+}
+
+func runTrend(cmd *cobra.Command, args []string) {
+	if err := loadNotesFile(); err != nil {
+		Error("%s", err)
+		return
+	}
+
+	var reports []*jsonReport
+	for _, path := range args {
+		report, err := readJSONFile(path)
+		if err != nil {
+			Error("Failed to read %s: %s", path, err)
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	// the scans are ordered by their start, if known, and by the order of the arguments otherwise
+	order := make([]int, len(reports))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := reports[order[i]].Stats, reports[order[j]].Stats
+		return a != nil && b != nil && a.Start.Before(b.Start)
+	})
+
+	var sorted []*jsonReport
+	for i, index := range order {
+		if stats := reports[index].Stats; stats != nil {
+			Info("Scan %d: %s (%s)", i+1, args[index], stats.Start.Format("2006-01-02 15:04:05"))
+		} else {
+			Info("Scan %d: %s", i+1, args[index])
+		}
+		sorted = append(sorted, reports[index])
+	}
+
+	exposed, fixed, changed := 0, 0, 0
+	for _, trend := range buildTrends(sorted) {
+		if !trend.changed() {
+			continue
+		}
+
+		changed++
+		switch trend.change() {
+		case trendNewlyExposed:
+			exposed++
+			color.Red("%s", trend)
+		case trendNewlyFixed:
+			fixed++
+			color.Green("%s", trend)
+		default:
+			fmt.Println(trend)
+		}
+	}
+
+	Info("%d URLs changed their status, %d are newly exposed and %d newly fixed in the latest scan", changed, exposed, fixed)
+}
+
+// builds the status history of every method and URL across the (ordered) reports, sorted by URL and method
+func buildTrends(reports []*jsonReport) []urlTrend {
+	trends := make(map[string]*urlTrend)
+	for i, report := range reports {
+		for _, result := range report.Results {
+			if isFalsePositive(result.Method, result.URL) {
+				continue
+			}
+			key := result.Method + " " + result.URL
+			if trends[key] == nil {
+				trends[key] = &urlTrend{Method: result.Method, URL: result.URL, Statuses: make([]int, len(reports))}
+			}
+			trends[key].Statuses[i] = result.StatusCode
+		}
+	}
+
+	var sorted []urlTrend
+	for _, trend := range trends {
+		sorted = append(sorted, *trend)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].URL != sorted[j].URL {
+			return sorted[i].URL < sorted[j].URL
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	return sorted
+}
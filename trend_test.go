@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestBuildTrends(t *testing.T) {
+	reports := []*jsonReport{
+		{Results: []jsonResult{
+			{Method: "GET", URL: "https://example.com/admin", StatusCode: 403},
+			{Method: "GET", URL: "https://example.com/export", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/home", StatusCode: 200},
+		}},
+		{Results: []jsonResult{
+			{Method: "GET", URL: "https://example.com/admin", StatusCode: 403},
+			{Method: "GET", URL: "https://example.com/export", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/home", StatusCode: 200},
+		}},
+		{Results: []jsonResult{
+			{Method: "GET", URL: "https://example.com/admin", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/export", StatusCode: 401},
+			{Method: "GET", URL: "https://example.com/home", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/new", StatusCode: 200},
+		}},
+	}
+
+	expected := map[string]struct {
+		line    string
+		changed bool
+	}{
+		"https://example.com/admin":  {"[NEWLY EXPOSED] GET https://example.com/admin: 403 => 403 => 200", true},
+		"https://example.com/export": {"[NEWLY FIXED] GET https://example.com/export: 200 => 200 => 401", true},
+		"https://example.com/home":   {"GET https://example.com/home: 200 => 200 => 200", false},
+		"https://example.com/new":    {"[NEWLY EXPOSED] GET https://example.com/new: - => - => 200", true},
+	}
+
+	trends := buildTrends(reports)
+	if len(trends) != len(expected) {
+		t.Fatalf("Expected %d trends but got %d", len(expected), len(trends))
+	}
+	for _, trend := range trends {
+		if trend.String() != expected[trend.URL].line || trend.changed() != expected[trend.URL].changed {
+			t.Errorf("Expected %q (changed: %v) but got %q (changed: %v)", expected[trend.URL].line, expected[trend.URL].changed, trend.String(), trend.changed())
+		}
+	}
+}
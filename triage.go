@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"sessionprobe/pkg/probe"
+
+	"github.com/spf13/cobra"
+)
+
+// the triage states of a finding, which are recorded as the disposition in the `--notes` file
+const (
+	triageNew           = "new"
+	triageReviewed      = "reviewed"
+	triageFalsePositive = "false-positive"
+	triageConfirmed     = "confirmed"
+)
+
+var triageStates = []string{triageNew, triageReviewed, triageFalsePositive, triageConfirmed}
+
+func newTriageCmd() *cobra.Command {
+	triageCmd := &cobra.Command{
+		Use:   "triage <status> <URL>...",
+		Short: "Record the triage status of findings in the notes file",
+		Long: `Records the triage status (` + strings.Join(triageStates, ", ") + `) of the URLs in the --notes file,
+optionally with a comment. An existing note on a URL is replaced (without --method, the notes on the URL for a single
+method as well), all other lines of the file are kept. Scans, diffs
+and trends that are given the notes file leave out the URLs marked as ` + triageFalsePositive + `.`,
+		Example: `./sessionprobe triage false-positive https://example.com/profile --notes ./notes.txt --comment "public profile"
+./sessionprobe -u ./urls.txt --notes ./notes.txt`,
+		Args: cobra.MinimumNArgs(2),
+		Run:  runTriage,
+	}
+	triageCmd.Flags().String("method", "", "only record the status for this method (default: all methods)")
+	triageCmd.Flags().String("comment", "", "comment to record with the status, e.g. why it's a false positive")
+
+	return triageCmd
+}
+
+func runTriage(cmd *cobra.Command, args []string) {
+	if notesFile == "" {
+		Error("The notes file has to be provided via --notes")
+		return
+	}
+
+	status := strings.ToLower(args[0])
+	if !isTriageState(status) {
+		Error("Invalid status %s (expected one of %s)", args[0], strings.Join(triageStates, ", "))
+		return
+	}
+
+	method, _ := cmd.Flags().GetString("method")
+	comment, _ := cmd.Flags().GetString("comment")
+	note := annotation{Disposition: status, Comment: strings.TrimSpace(comment)}
+
+	if err := recordAnnotations(notesFile, strings.ToUpper(method), args[1:], note); err != nil {
+		Error("Failed to update %s: %s", notesFile, err)
+		return
+	}
+	Info("Marked %d URLs as %s in %s", len(args)-1, status, notesFile)
+}
+
+func isTriageState(status string) bool {
+	for _, state := range triageStates {
+		if status == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sets the note on the URLs in the notes file (which is created if it doesn't exist). The lines of notes on the same
+// method and URL are replaced (without a method, the notes on the URL for any method as well, as they would take
+// precedence over the new one), all other lines (including comments) are kept in their order
+func recordAnnotations(path string, method string, urls []string, note annotation) error {
+	var lines []string
+	file, err := os.Open(path)
+	if err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	keys := make(map[string]bool)
+	for _, url := range urls {
+		key := url
+		if method != "" {
+			key = method + " " + url
+		}
+		keys[key] = true
+	}
+
+	// drop the existing notes on the URLs, the new ones are appended below
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if key, _, err := parseAnnotationLine(trimmed); err == nil && replacesNote(key, method, keys) {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+
+	for _, url := range urls {
+		target := url
+		if method != "" {
+			target = method + " " + url
+		}
+		kept = append(kept, fmt.Sprintf("%s => %s", target, note))
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+}
+
+// reports whether the existing note with the key is replaced by the new ones. A note for all methods replaces the
+// notes for a single method on the same URL as well
+func replacesNote(key string, method string, keys map[string]bool) bool {
+	if keys[key] {
+		return true
+	}
+	if method != "" {
+		return false
+	}
+
+	_, url, found := strings.Cut(key, " ")
+	return found && keys[url]
+}
+
+// removes the results marked as false-positive in the `--notes` file from the results, so that they don't show up in
+// any of the outputs. Returns the number of removed results
+func suppressFalsePositives(urlStatuses map[int][]probe.Result) int {
+	if len(annotations) == 0 {
+		return 0
+	}
+
+	suppressed := 0
+	for status, results := range urlStatuses {
+		var kept []probe.Result
+		for _, result := range results {
+			if isFalsePositive(result.Method, result.URL) {
+				suppressed++
+				continue
+			}
+			kept = append(kept, result)
+		}
+
+		if len(kept) == 0 {
+			delete(urlStatuses, status)
+		} else {
+			urlStatuses[status] = kept
+		}
+	}
+
+	return suppressed
+}
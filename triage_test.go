@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sessionprobe/pkg/probe"
+)
+
+func TestRecordAnnotations(t *testing.T) {
+	EnsureOutputFolderExists(t)
+
+	path := filepath.Join(".", "testing", "test-triage-notes.txt")
+	content := "# triage notes\n" +
+		"https://example.com/profile => reviewed\n" +
+		"GET https://example.com/profile => confirmed\n" +
+		"GET https://example.com/admin => confirmed: lists all accounts\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write notes file: %v", err)
+	}
+
+	note := annotation{Disposition: triageFalsePositive, Comment: "public profile"}
+	if err := recordAnnotations(path, "", []string{"https://example.com/profile", "https://example.com/about"}, note); err != nil {
+		t.Fatalf("Failed to record the notes: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read notes file: %v", err)
+	}
+
+	expected := "# triage notes\n" +
+		"GET https://example.com/admin => confirmed: lists all accounts\n" +
+		"https://example.com/profile => false-positive: public profile\n" +
+		"https://example.com/about => false-positive: public profile\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q but got %q", expected, string(data))
+	}
+
+	// a new notes file is created
+	newPath := filepath.Join(".", "testing", "test-triage-new-notes.txt")
+	os.Remove(newPath)
+	if err := recordAnnotations(newPath, "POST", []string{"https://example.com/api"}, annotation{Disposition: triageReviewed}); err != nil {
+		t.Fatalf("Failed to record the notes: %v", err)
+	}
+	if data, _ := os.ReadFile(newPath); string(data) != "POST https://example.com/api => reviewed\n" {
+		t.Errorf("Expected the note in the new file but got %q", string(data))
+	}
+}
+
+func TestSuppressFalsePositives(t *testing.T) {
+	annotations = map[string]annotation{
+		"https://example.com/profile":   {Disposition: triageFalsePositive},
+		"POST https://example.com/api":  {Disposition: triageFalsePositive},
+		"https://example.com/dashboard": {Disposition: triageConfirmed},
+	}
+	defer func() {
+		annotations = nil
+	}()
+
+	urlStatuses := map[int][]probe.Result{
+		200: {
+			{Method: "GET", URL: "https://example.com/profile", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/dashboard", StatusCode: 200},
+			{Method: "GET", URL: "https://example.com/api", StatusCode: 200},
+		},
+		201: {
+			{Method: "POST", URL: "https://example.com/api", StatusCode: 201},
+		},
+	}
+
+	if suppressed := suppressFalsePositives(urlStatuses); suppressed != 2 {
+		t.Errorf("Expected 2 suppressed results but got %d", suppressed)
+	}
+	if _, ok := urlStatuses[201]; ok {
+		t.Errorf("Expected the status 201 to be removed but got %v", urlStatuses[201])
+	}
+	if len(urlStatuses[200]) != 2 || urlStatuses[200][0].URL != "https://example.com/dashboard" {
+		t.Errorf("Expected the dashboard and the GET API results but got %v", urlStatuses[200])
+	}
+
+	diffs := diffResults(
+		[]jsonResult{{Method: "GET", URL: "https://example.com/profile", StatusCode: 403}},
+		[]jsonResult{{Method: "GET", URL: "https://example.com/profile", StatusCode: 200}, {Method: "POST", URL: "https://example.com/api", StatusCode: 201}},
+	)
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences for false positives but got %v", diffs)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"sessionprobe/pkg/probe"
+)
+
+const (
+	// the other role is denied access (e.g. 401, 403 or a redirect to the login)
+	verdictBlocked = "BLOCKED"
+	// the other role gets a successful response, but with different content
+	verdictPartiallyExposed = "PARTIALLY-EXPOSED"
+	// the other role gets (almost) the same response as the primary role
+	verdictFullyExposed = "FULLY-EXPOSED"
+)
+
+// the order in which the verdicts are reported, starting with the most severe one
+var verdictOrder = []string{verdictFullyExposed, verdictPartiallyExposed, verdictBlocked}
+
+const (
+	// thresholds above which two successful responses are considered to be the same
+	minExposedSimilarity  = 0.95
+	maxExposedLengthDelta = 0.05
+)
+
+// set if the URLs are additionally probed as a second role (`--compare-unauth` or `--compare-headers`)
+var comparison *comparisonSet
+
+// the response of one role, reduced to what's needed to compare it to the other role's response
+type roleResponse struct {
+	statusCode int
+	length     int
+	simhash    uint64
+	hasBody    bool
+}
+
+// records the responses of the primary role (the configured headers) and of the other role for every method and URL
+type comparisonSet struct {
+	mu      sync.Mutex
+	primary map[string]roleResponse
+	other   map[string]roleResponse
+	// the beginning of the primary role's successful bodies, which are kept until the other role's response was
+	// diffed against them, i.e. until the second pass after the scan. Their total size is capped by
+	// maxPrimaryBodiesBytes, the responses beyond it get a verdict without a diff
+	primaryBodies      map[string][]byte
+	primaryBodiesBytes int
+	// unified diff excerpts of the bodies for the URLs the other role got a different successful response for
+	diffs map[string]string
+}
+
+type accessVerdict struct {
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Verdict    string  `json:"verdict"`
+	Status     int     `json:"status_code"`
+	OtherCode  int     `json:"other_status_code"`
+	Length     int     `json:"length"`
+	OtherLen   int     `json:"other_length"`
+	Similarity float64 `json:"similarity"`
+	// excerpt of the unified diff between the bodies of the primary and the other role
+	Diff string `json:"diff,omitempty"`
+}
+
+func newComparisonSet() *comparisonSet {
+	return &comparisonSet{
+		primary:       make(map[string]roleResponse),
+		other:         make(map[string]roleResponse),
+		primaryBodies: make(map[string][]byte),
+		diffs:         make(map[string]string),
+	}
+}
+
+// returns a ResponseHook that records the responses of the primary role or, if other is set, of the other role
+func (c *comparisonSet) hook(other bool) probe.ResponseHook {
+	return probe.ResponseHookFunc(func(result *probe.Result, body []byte) error {
+		// dynamic fragments (`--normalize-regex`) are removed from the bodies, and from their lengths, before comparing them
+		normalized := normalizeBody(body)
+		response := newRoleResponse(result, normalized)
+		response.length -= len(body) - len(normalized)
+		body = normalized
+
+		key := result.Method + " " + result.URL
+		if !other {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.primary[key] = response
+			c.keepPrimaryBody(key, result.StatusCode, body)
+			return nil
+		}
+
+		c.mu.Lock()
+		c.other[key] = response
+		primaryBody, ok := c.primaryBodies[key]
+		delete(c.primaryBodies, key)
+		c.primaryBodiesBytes -= len(primaryBody)
+		c.mu.Unlock()
+
+		// the diff is computed without the lock, so that the other workers don't have to wait for it
+		if ok {
+			if diff := diffBodies(primaryBody, result.StatusCode, body); diff != "" {
+				c.mu.Lock()
+				c.diffs[key] = diff
+				c.mu.Unlock()
+			}
+		}
+
+		return nil
+	})
+}
+
+// keeps the beginning of a successful primary body until the other role's response is diffed against it. Only
+// successful responses can be exposed, so the others aren't kept. Must be called with the lock held
+func (c *comparisonSet) keepPrimaryBody(key string, statusCode int, body []byte) {
+	if body == nil || statusCode < 200 || statusCode >= 300 {
+		return
+	}
+
+	body = body[:min(len(body), maxDiffBodyBytes)]
+	if c.primaryBodiesBytes+len(body) > maxPrimaryBodiesBytes {
+		return
+	}
+	c.primaryBodies[key] = append([]byte(nil), body...)
+	c.primaryBodiesBytes += len(body)
+}
+
+// diffs the body of the other role's response against the one of the primary role, as evidence for a (partial)
+// exposure. Blocked responses (e.g. a login page) aren't diffed
+func diffBodies(primaryBody []byte, statusCode int, body []byte) string {
+	if body == nil || statusCode < 200 || statusCode >= 300 {
+		return ""
+	}
+
+	return bodyDiff(primaryBody, body)
+}
+
+// probes all URLs as the other role, i.e. with the given headers instead of the configured ones
+func (c *comparisonSet) probeOther(urls map[string]bool, opts probe.Options, headers map[string][]string) error {
+	opts.URLs = nil
+	for url := range urls {
+		opts.URLs = append(opts.URLs, url)
+	}
+	opts.Methods = getMethods()
+	opts.Headers = headers
+	// the cookies of `--cookie-file` belong to the primary role
+	opts.RequestHooks = nil
+	templates, err := parseHeaderTemplates(headers)
+	if err != nil {
+		return err
+	}
+	if templates != nil {
+		opts.RequestHooks = append(opts.RequestHooks, templates)
+	}
+	if requestSigner != nil {
+		opts.RequestHooks = append(opts.RequestHooks, requestSigner)
+	}
+	opts.ResponseHooks = []probe.ResponseHook{c.hook(true), sessionCookieFindings.hook(isUnauthenticated(headers))}
+
+	scanner, err := probe.NewScanner(opts)
+	if err != nil {
+		return err
+	}
+
+	Info("Probing %d URLs again as the second role to compute access-control verdicts", len(opts.URLs))
+	for result := range scanner.Run(context.Background()) {
+		handleHTTPError(result.Err, result.URL)
+	}
+
+	return nil
+}
+
+// computes the verdicts for every method and URL for which both roles got a response, sorted by severity and URL
+func (c *comparisonSet) verdicts() []accessVerdict {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var verdicts []accessVerdict
+	for key, primary := range c.primary {
+		other, ok := c.other[key]
+		if !ok {
+			continue
+		}
+
+		method, url, _ := strings.Cut(key, " ")
+		verdict := computeVerdict(method, url, primary, other)
+		verdict.Diff = c.diffs[key]
+		verdicts = append(verdicts, verdict)
+	}
+
+	rank := make(map[string]int)
+	for i, verdict := range verdictOrder {
+		rank[verdict] = i
+	}
+
+	sort.Slice(verdicts, func(i, j int) bool {
+		a, b := verdicts[i], verdicts[j]
+		if a.Verdict != b.Verdict {
+			return rank[a.Verdict] < rank[b.Verdict]
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Method < b.Method
+	})
+
+	return verdicts
+}
+
+// decides whether the other role can access what the primary role can, based on the status codes, the length delta
+// and the similarity of the bodies
+func computeVerdict(method string, url string, primary roleResponse, other roleResponse) accessVerdict {
+	verdict := accessVerdict{
+		Method:    method,
+		URL:       url,
+		Status:    primary.statusCode,
+		OtherCode: other.statusCode,
+		Length:    primary.length,
+		OtherLen:  other.length,
+	}
+
+	verdict.Similarity = similarity(primary, other)
+
+	switch {
+	case other.statusCode < 200 || other.statusCode >= 300:
+		verdict.Verdict = verdictBlocked
+	case other.statusCode == primary.statusCode && verdict.Similarity >= minExposedSimilarity &&
+		lengthDelta(primary.length, other.length) <= maxExposedLengthDelta:
+		verdict.Verdict = verdictFullyExposed
+	default:
+		verdict.Verdict = verdictPartiallyExposed
+	}
+
+	return verdict
+}
+
+func newRoleResponse(result *probe.Result, body []byte) roleResponse {
+	response := roleResponse{statusCode: result.StatusCode, length: result.Length, hasBody: body != nil}
+	if body != nil {
+		response.simhash = simhash(body)
+	}
+
+	return response
+}
+
+// returns the similarity (between 0 and 1) of both bodies. Without bodies (e.g. `--no-body`), only the status codes
+// and lengths can be compared, so they are considered to be the same
+func similarity(a roleResponse, b roleResponse) float64 {
+	if !a.hasBody || !b.hasBody {
+		return 1
+	}
+
+	return 1 - float64(bits.OnesCount64(a.simhash^b.simhash))/64
+}
+
+// returns the difference between both lengths relative to the larger one
+func lengthDelta(a int, b int) float64 {
+	if a == b {
+		return 0
+	}
+
+	larger, diff := a, a-b
+	if b > a {
+		larger, diff = b, b-a
+	}
+	if larger <= 0 {
+		return 1
+	}
+
+	return float64(diff) / float64(larger)
+}
+
+// computes a 64-bit SimHash over the words of the body. Similar bodies have hashes with a small Hamming distance,
+// which allows comparing them without keeping the bodies in memory
+func simhash(body []byte) uint64 {
+	var weights [64]int
+	words := strings.FieldsFunc(string(body), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		hash := fnv64a(word)
+		for i := 0; i < 64; i++ {
+			if hash&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var hash uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+func fnv64a(s string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= 1099511628211
+	}
+
+	return hash
+}
+
+// writes the verdicts grouped by verdict, which replaces the status code buckets in the output file
+func writeVerdicts(writer *bufio.Writer, verdicts []accessVerdict) {
+	byVerdict := make(map[string][]accessVerdict)
+	for _, verdict := range verdicts {
+		byVerdict[verdict.Verdict] = append(byVerdict[verdict.Verdict], verdict)
+	}
+
+	for _, name := range verdictOrder {
+		if len(byVerdict[name]) == 0 {
+			continue
+		}
+
+		_, _ = writer.WriteString(fmt.Sprintf("Verdict: %s\n\n", name))
+		for _, v := range byVerdict[name] {
+			_, _ = writer.WriteString(fmt.Sprintf("| %s | %s => Status: %d/%d, Length: %s/%s, Similarity: %.0f%%\n",
+				v.Method, v.URL, v.Status, v.OtherCode, formatLength(v.Length), formatLength(v.OtherLen), v.Similarity*100))
+			// the diff excerpt is indented below the verdict
+			if v.Diff != "" {
+				for _, line := range strings.Split(v.Diff, "\n") {
+					_, _ = writer.WriteString("    " + line + "\n")
+				}
+			}
+		}
+		_, _ = writer.WriteString("\n")
+	}
+}
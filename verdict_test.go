@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestComputeVerdict(t *testing.T) {
+	page := simhash([]byte("Welcome back Alice, here are your orders: #1001 shoes, #1002 jacket, #1003 hat"))
+	otherPage := simhash([]byte("Please log in to continue. Forgot your password? Create an account"))
+
+	tests := map[string]struct {
+		primary, other roleResponse
+		expected       string
+	}{
+		"forbidden": {
+			roleResponse{200, 80, page, true}, roleResponse{403, 12, otherPage, true}, verdictBlocked,
+		},
+		"redirect to login": {
+			roleResponse{200, 80, page, true}, roleResponse{302, 0, 0, true}, verdictBlocked,
+		},
+		"same response": {
+			roleResponse{200, 80, page, true}, roleResponse{200, 80, page, true}, verdictFullyExposed,
+		},
+		"different content": {
+			roleResponse{200, 80, page, true}, roleResponse{200, 66, otherPage, true}, verdictPartiallyExposed,
+		},
+		"same length without bodies": {
+			roleResponse{200, 80, 0, false}, roleResponse{200, 80, 0, false}, verdictFullyExposed,
+		},
+	}
+
+	for name, test := range tests {
+		if actual := computeVerdict("GET", "https://example.com/", test.primary, test.other); actual.Verdict != test.expected {
+			t.Errorf("Expected %s for %s but got %s", test.expected, name, actual.Verdict)
+		}
+	}
+}
+
+func TestLengthDelta(t *testing.T) {
+	tests := map[[2]int]float64{
+		{100, 100}: 0,
+		{100, 95}:  0.05,
+		{50, 100}:  0.5,
+		{0, 10}:    1,
+	}
+
+	for lengths, expected := range tests {
+		if actual := lengthDelta(lengths[0], lengths[1]); actual != expected {
+			t.Errorf("Expected %v for %v but got %v", expected, lengths, actual)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/fatih/color"
 	"github.com/hashicorp/go-version"
@@ -15,6 +16,19 @@ import (
 var AppVersion string = "0.0.0"
 var latestRelease string = "https://github.com/dub-flow/sessionprobe/releases/latest"
 
+// the environment variable that disables the update check like `--no-update-check` (e.g. in air-gapped environments)
+const noUpdateCheckEnv = "SESSIONPROBE_NO_UPDATE_CHECK"
+
+// reports if the update check (and the lookup of the `./VERSION` file) is disabled via the flag or the environment
+func updateCheckDisabled(flag bool) bool {
+	if flag {
+		return true
+	}
+
+	disabled, err := strconv.ParseBool(os.Getenv(noUpdateCheckEnv))
+	return err == nil && disabled
+}
+
 func NotifyOfUpdates() {
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", latestRelease, nil)
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestUpdateCheckDisabled(t *testing.T) {
+	tests := []struct {
+		flag     bool
+		env      string
+		expected bool
+	}{
+		{false, "", false},
+		{true, "", true},
+		{false, "1", true},
+		{false, "true", true},
+		{false, "0", false},
+		{false, "invalid", false},
+		{true, "false", true},
+	}
+
+	for _, test := range tests {
+		t.Setenv(noUpdateCheckEnv, test.env)
+		if actual := updateCheckDisabled(test.flag); actual != test.expected {
+			t.Errorf("Expected %v for flag %v and %s=%q but got %v", test.expected, test.flag, noUpdateCheckEnv, test.env, actual)
+		}
+	}
+}